@@ -3,10 +3,13 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
-	"sync"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -23,10 +26,7 @@ func main() {
 		v   = flag.Bool("v", false, "print version information")
 
 		// bot vars
-		rules      = make(map[string]models.Rule)
-		hitRule    = make(chan models.Rule, 1)
-		inputMsgs  = make(chan models.Message, 1)
-		outputMsgs = make(chan models.Message, 1)
+		rules = make(map[string]models.Rule)
 	)
 
 	// parse the flagS
@@ -45,35 +45,69 @@ func main() {
 
 	log.Logger = log.Output(os.Stdout).With().Logger()
 
+	// Run the subcommand, if one was given
+	switch flag.Arg(0) {
+	case "":
+	case "validate":
+		os.Exit(validate())
+	case "test":
+		os.Exit(test(flag.Args()[1:]))
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %#q, use 'validate', 'test' or no command to run the bot\n", flag.Arg(0))
+		os.Exit(2)
+	}
+
 	// Configure the bot to the core framework
 	bot := models.NewBot()
 	core.Configure(bot)
 
-	// Populate the global rules map
-	core.Rules(&rules, bot)
+	// Queue messages between reading, matching and sending, so a slow step doesn't hold up the others
+	var (
+		inputMsgs  = make(chan models.Message, core.QueueSize(bot))
+		outputMsgs = make(chan models.Envelope, core.QueueSize(bot))
+	)
 
-	// Initialize and run Prometheus metrics logging
-	go core.Prommetric("init", bot)
+	// Shut down on SIGINT or SIGTERM, ie. when the pod is stopped
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Create the wait group for handling concurrent runs (see further down)
-	// Add 3 to the wait group so the three separate processes run concurrently
-	// - process 1: core.Remotes - reads messages
-	// - process 2: core.Matcher - processes messages
-	// - process 3: core.Outputs - sends out messages
-	var wg sync.WaitGroup
+	// Populate the global rules map
+	core.Rules(&rules, bot)
 
-	wg.Add(3)
+	ruleSet := models.NewRuleSet(rules)
 
-	go core.Remotes(inputMsgs, rules, bot)
-	go core.Matcher(inputMsgs, outputMsgs, rules, hitRule, bot)
-	go core.Outputs(outputMsgs, hitRule, bot)
+	// Reload rules when the rule files change
+	if bot.WatchRules {
+		go core.WatchRules(ctx, ruleSet, bot)
+	}
 
-	defer wg.Done()
+	// Initialize and run Prometheus metrics logging
+	go core.Prommetric("init", bot)
 
-	// This will run the bot indefinitely because the wait group will
-	// attempt to wait for the above never-ending go routines.
-	// Since said go routines run forever, they will never finish
-	// and so this program will wait, or essentially run, forever until
-	// terminated
-	wg.Wait()
+	// Run the three stages of the bot, each stage closes the channel it feeds once it's done,
+	// so a shutdown flows from reading messages through to sending them out
+	// - core.Remotes - reads messages until the context is canceled
+	// - core.Matcher - processes messages and waits for running rules to finish
+	// - core.Outputs - sends out messages until all of them are sent
+	done := make(chan struct{})
+
+	go core.Remotes(ctx, inputMsgs, ruleSet, bot)
+	go core.Matcher(inputMsgs, outputMsgs, ruleSet, bot)
+	go func() {
+		core.Outputs(outputMsgs, bot)
+		close(done)
+	}()
+
+	<-ctx.Done()
+	stop() // a second signal kills the bot right away
+
+	gracePeriod := core.ShutdownGracePeriod(bot)
+	log.Info().Msgf("shutting down, waiting up to %s for running rules and pending messages", gracePeriod)
+
+	select {
+	case <-done:
+		log.Info().Msg("shut down gracefully")
+	case <-time.After(gracePeriod):
+		log.Warn().Msgf("shutdown grace period of %s expired, running rules and pending messages are dropped", gracePeriod)
+	}
 }
@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/target/flottbot/internal/core"
+	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/ruletest"
+)
+
+// test runs the tests next to the rule files, printing a pass/fail report.
+// It returns the exit code, which is non-zero if any test failed.
+func test(args []string) int {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	junit := flags.String("junit", "", "also write the results as JUnit XML to this file")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	bot := models.NewBot()
+
+	// only the problems are of interest, not how the rules are loaded and run
+	zerolog.SetGlobalLevel(zerolog.ErrorLevel)
+
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+
+	rules := make(map[string]models.Rule)
+	core.Rules(&rules, bot)
+
+	files, err := core.RuleTestFiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not find test files: %v\n", err)
+		return 1
+	}
+
+	var tests []ruletest.Case
+
+	for _, file := range files {
+		// report the files relative to the working directory, as the validate command does
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, file); err == nil {
+				file = rel
+			}
+		}
+
+		cases, err := ruletest.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		tests = append(tests, cases...)
+	}
+
+	if len(tests) == 0 {
+		fmt.Println("no tests found, add them next to the rules, ie. 'hello_test.yml' next to 'hello.yml'")
+		return 0
+	}
+
+	results := ruletest.Run(tests, rules, bot)
+	ruletest.WriteReport(os.Stdout, results)
+
+	if *junit != "" {
+		if err := writeJUnit(*junit, results); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	for _, result := range results {
+		if !result.Passed() {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// writeJUnit writes the results as JUnit XML to the file.
+func writeJUnit(file string, results []ruletest.Result) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("could not create junit report: %w", err)
+	}
+
+	err = ruletest.WriteJUnit(f, results)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
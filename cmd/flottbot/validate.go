@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/target/flottbot/internal/core"
+)
+
+// validate checks the bot.yml and the rule files, printing the problems found.
+// It returns the exit code, which is non-zero if there are any problems other than warnings.
+func validate() int {
+	// only the problems are of interest, not how the rules are loaded
+	zerolog.SetGlobalLevel(zerolog.ErrorLevel)
+
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+
+	var errors, warnings int
+
+	for _, problem := range core.Validate() {
+		fmt.Println(problem)
+
+		if problem.Warning {
+			warnings++
+		} else {
+			errors++
+		}
+	}
+
+	if errors > 0 {
+		fmt.Printf("found %d problems and %d warnings\n", errors, warnings)
+
+		return 1
+	}
+
+	if warnings > 0 {
+		fmt.Printf("configuration is valid, with %d warnings\n", warnings)
+
+		return 0
+	}
+
+	fmt.Println("configuration is valid")
+
+	return 0
+}
@@ -0,0 +1,377 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package flottbottest runs the bot in memory, for tests that drive it from Go code.
+// A Harness runs the matcher and outputs of the bot with the given rules, reading the
+// messages injected into a fake chat application and capturing what the bot sends back:
+//
+//	h := flottbottest.Start(t, nil, map[string]flottbottest.Rule{
+//		"hello.yml": {Name: "hello", Active: true, Respond: "hello", FormatOutput: "hi ${_user.name}"},
+//	})
+//
+//	h.Say("hello")
+//	h.ExpectOutput("hi alice")
+//
+// Every harness runs its own bot, so harnesses may run in parallel.
+package flottbottest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/target/flottbot/internal/core"
+	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/remote"
+)
+
+// The types of the bot, for tests outside of this module. Along with the types
+// that rules and actions are made of, so that any rule can be written in Go.
+type (
+	Action         = models.Action
+	Arg            = models.Arg
+	Auth           = models.Auth
+	Bot            = models.Bot
+	Concurrency    = models.Concurrency
+	DiscordConfig  = models.DiscordConfig
+	Envelope       = models.Envelope
+	Flag           = models.Flag
+	Identity       = models.Identity
+	Message        = models.Message
+	MessageService = models.MessageService
+	MessageType    = models.MessageType
+	Op             = models.Op
+	RateLimit      = models.RateLimit
+	Remotes        = models.Remotes
+	Retry          = models.Retry
+	Rule           = models.Rule
+	SlackConfig    = models.SlackConfig
+	Step           = models.Step
+)
+
+// The types of arguments and flags.
+const (
+	ArgTypeString   = models.ArgTypeString
+	ArgTypeInt      = models.ArgTypeInt
+	ArgTypeFloat    = models.ArgTypeFloat
+	ArgTypeBool     = models.ArgTypeBool
+	ArgTypeEnum     = models.ArgTypeEnum
+	ArgTypeDuration = models.ArgTypeDuration
+	ArgTypeEmail    = models.ArgTypeEmail
+	ArgTypeUser     = models.ArgTypeUser
+	ArgTypeChannel  = models.ArgTypeChannel
+)
+
+// The scopes of rate limits and what happens to requests beyond the concurrency of a rule.
+const (
+	RateLimitScopeUser    = models.RateLimitScopeUser
+	RateLimitScopeChannel = models.RateLimitScopeChannel
+	RateLimitScopeGlobal  = models.RateLimitScopeGlobal
+	ConcurrencyQueue      = models.ConcurrencyQueue
+	ConcurrencyReject     = models.ConcurrencyReject
+)
+
+// The operations of an envelope.
+const (
+	OpSend    = models.OpSend
+	OpReact   = models.OpReact
+	OpUnreact = models.OpUnreact
+)
+
+// The services and types of a message.
+const (
+	MsgServiceChat      = models.MsgServiceChat
+	MsgServiceScheduler = models.MsgServiceScheduler
+	MsgTypeDirect       = models.MsgTypeDirect
+	MsgTypeChannel      = models.MsgTypeChannel
+)
+
+// The user and channel of the messages created by 'NewMessage'.
+const (
+	DefaultUser    = "alice"
+	DefaultChannel = "general"
+)
+
+// DefaultTimeout is how long the assertions of a harness wait for the bot by default.
+const DefaultTimeout = 5 * time.Second
+
+// instances counts the instances, so each registers its remote under its own name.
+var instances atomic.Int64
+
+// Instance is the bot running in memory, see 'Run'.
+type Instance struct {
+	Bot    *Bot
+	Remote *Remote
+
+	cancel context.CancelFunc
+	done   chan struct{} // closed once the outputs are sent
+}
+
+// Run runs the bot with the rules, keyed by the file they would be read from. A nil bot
+// runs the bot with the default configuration. The bot only reads from and sends to the
+// fake chat application of the instance, rooms the rules send to must be in 'Bot.Rooms'.
+// Unlike 'Start' it doesn't need a test, the instance runs until it is stopped.
+func Run(bot *Bot, rules map[string]Rule) *Instance {
+	if bot == nil {
+		bot = new(Bot)
+	}
+
+	i := &Instance{
+		Bot:    bot,
+		Remote: newRemote(fmt.Sprintf("flottbottest-%d", instances.Add(1))),
+		done:   make(chan struct{}),
+	}
+
+	remote.Register(i.Remote.Name(), remote.Registration{
+		New:    func(*models.Bot) remote.Remote { return i.Remote },
+		Output: func(_ remote.Remote, env models.Envelope, _ *models.Bot) { i.Remote.capture(env) },
+	})
+
+	bot.ChatApplications = []string{i.Remote.Name()}
+	bot.CLI = false
+	bot.Scheduler = false
+
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		ruleSet     = models.NewRuleSet(rules)
+		inputMsgs   = make(chan models.Message, core.QueueSize(bot))
+		outputMsgs  = make(chan models.Envelope, core.QueueSize(bot))
+	)
+
+	i.cancel = cancel
+
+	go core.Remotes(ctx, inputMsgs, ruleSet, bot)
+	go core.Matcher(inputMsgs, outputMsgs, ruleSet, bot)
+	go func() {
+		core.Outputs(outputMsgs, bot)
+		close(i.done)
+	}()
+
+	return i
+}
+
+// Stop stops reading messages and waits at most the timeout for the running rules
+// to finish and their outputs to be sent.
+func (i *Instance) Stop(timeout time.Duration) error {
+	i.cancel()
+
+	defer remote.Unregister(i.Remote.Name())
+
+	select {
+	case <-i.done:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("the bot did not stop within %s", timeout)
+	}
+}
+
+// Harness runs the bot in memory for a test, see 'Start'.
+type Harness struct {
+	*Instance
+
+	// Timeout is how long the assertions wait for the bot, defaults to 'DefaultTimeout'.
+	Timeout time.Duration
+
+	tb testing.TB
+}
+
+// Start runs the bot with the rules like 'Run', the harness is stopped once the test is done.
+func Start(tb testing.TB, bot *Bot, rules map[string]Rule) *Harness {
+	tb.Helper()
+
+	h := &Harness{
+		Instance: Run(bot, rules),
+		Timeout:  DefaultTimeout,
+		tb:       tb,
+	}
+
+	tb.Cleanup(h.Stop)
+
+	return h
+}
+
+// Stop stops reading messages and waits for the running rules to finish and their outputs to be sent.
+func (h *Harness) Stop() {
+	h.tb.Helper()
+
+	if err := h.Instance.Stop(h.Timeout); err != nil {
+		h.tb.Errorf("%v", err)
+	}
+}
+
+// NewArgs creates the arguments of a rule from their declarations, ie. 'app', 'env?' or 'rest+'.
+func NewArgs(declarations ...string) []Arg {
+	return models.NewArgs(declarations...)
+}
+
+// NewMessage creates a message that mentions the bot, sent by 'DefaultUser' in 'DefaultChannel'.
+func NewMessage(input string) Message {
+	message := models.NewMessage()
+	message.Service = models.MsgServiceChat
+	message.Type = models.MsgTypeChannel
+	message.Input = input
+	message.Timestamp = strconv.FormatInt(message.StartTime, 10)
+	message.BotMentioned = true
+	message.ChannelID = DefaultChannel
+	message.ChannelName = DefaultChannel
+
+	message.Vars["_user.name"] = DefaultUser
+	message.Vars["_user.id"] = DefaultUser
+	message.Vars["_channel.name"] = DefaultChannel
+	message.Vars["_channel.id"] = DefaultChannel
+
+	return message
+}
+
+// Send hands the message to the bot.
+func (h *Harness) Send(message Message) {
+	h.tb.Helper()
+
+	if message.Vars == nil {
+		message.Vars = make(map[string]string)
+	}
+
+	if message.Attributes == nil {
+		message.Attributes = make(map[string]string)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.Timeout)
+	defer cancel()
+
+	if err := h.Remote.Inject(ctx, message); err != nil {
+		h.tb.Fatalf("the bot did not read the message %#q within %s", message.Input, h.Timeout)
+	}
+}
+
+// Say sends a message created by 'NewMessage' to the bot and returns it.
+func (h *Harness) Say(input string) Message {
+	h.tb.Helper()
+
+	message := NewMessage(input)
+	h.Send(message)
+
+	return message
+}
+
+// WaitFor waits for the bot to send an envelope that matches and returns it. Each envelope is returned
+// only once, so waiting twice for the same output expects the bot to send it twice. The test fails if
+// no envelope matches within the timeout, 'what' describes the envelope for the failure.
+func (h *Harness) WaitFor(what string, match func(Envelope) bool) Envelope {
+	h.tb.Helper()
+
+	timeout := time.After(h.Timeout)
+
+	for {
+		env, changed, ok := h.Remote.consume(match)
+		if ok {
+			return env
+		}
+
+		select {
+		case <-changed:
+		case <-timeout:
+			h.tb.Fatalf("the bot did not send %s within %s, it sent:\n%s", what, h.Timeout, describe(h.pending()))
+
+			return Envelope{}
+		}
+	}
+}
+
+// ExpectOutput waits for the bot to send the output, the routing is in the message of the envelope.
+func (h *Harness) ExpectOutput(want string) Envelope {
+	h.tb.Helper()
+
+	return h.WaitFor(fmt.Sprintf("output %q", want), func(env Envelope) bool {
+		return env.Has(OpSend) && env.Message.Output == want
+	})
+}
+
+// ExpectOutputToRooms waits for the bot to send the output to exactly the rooms, in this order.
+func (h *Harness) ExpectOutputToRooms(want string, rooms ...string) Envelope {
+	h.tb.Helper()
+
+	return h.WaitFor(fmt.Sprintf("output %q to rooms %v", want, rooms), func(env Envelope) bool {
+		return env.Has(OpSend) && env.Message.Output == want && slices.Equal(env.Message.OutputToRooms, rooms)
+	})
+}
+
+// ExpectDirectOutput waits for the bot to send the output as direct message.
+func (h *Harness) ExpectDirectOutput(want string) Envelope {
+	h.tb.Helper()
+
+	return h.WaitFor(fmt.Sprintf("output %q as direct message", want), func(env Envelope) bool {
+		direct := env.Message.DirectMessageOnly || env.Message.Type == models.MsgTypeDirect
+		return env.Has(OpSend) && env.Message.Output == want && direct
+	})
+}
+
+// ExpectReaction waits for the bot to add the reaction to a message.
+func (h *Harness) ExpectReaction(want string) Envelope {
+	h.tb.Helper()
+
+	return h.WaitFor(fmt.Sprintf("reaction %#q", want), func(env Envelope) bool {
+		return env.Has(OpReact) && env.Rule.Reaction == want
+	})
+}
+
+// ExpectNothing fails the test if the bot sends anything that wasn't returned by the other assertions within d.
+func (h *Harness) ExpectNothing(d time.Duration) {
+	h.tb.Helper()
+
+	timeout := time.After(d)
+
+	for {
+		pending, changed := h.Remote.pending()
+		if len(pending) > 0 {
+			h.tb.Errorf("the bot unexpectedly sent:\n%s", describe(pending))
+
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-timeout:
+			return
+		}
+	}
+}
+
+// pending returns the envelopes the assertions didn't return yet.
+func (h *Harness) pending() []Envelope {
+	pending, _ := h.Remote.pending()
+
+	return pending
+}
+
+// describe lists the envelopes for a failure.
+func describe(envelopes []Envelope) string {
+	if len(envelopes) == 0 {
+		return "\tnothing"
+	}
+
+	var b strings.Builder
+
+	for _, env := range envelopes {
+		fmt.Fprintf(&b, "\trule %#q: ", env.Rule.Name)
+
+		if env.Has(OpSend) {
+			fmt.Fprintf(&b, "output %q to rooms %v, users %v ", env.Message.Output, env.Message.OutputToRooms, env.Message.OutputToUsers)
+		}
+
+		if env.Has(OpReact) {
+			fmt.Fprintf(&b, "reaction %#q ", env.Rule.Reaction)
+		}
+
+		if env.Has(OpUnreact) {
+			fmt.Fprintf(&b, "removed reaction %#q", env.Rule.RemoveReaction)
+		}
+
+		b.WriteString("\n")
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
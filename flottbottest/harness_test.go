@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package flottbottest_test
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/target/flottbot/flottbottest"
+)
+
+func TestHarness(t *testing.T) {
+	bot := &flottbottest.Bot{Rooms: map[string]string{"ops": "C1"}}
+
+	h := flottbottest.Start(t, bot, map[string]flottbottest.Rule{
+		"hello.yml": {Name: "hello", Active: true, Respond: "hello", FormatOutput: "hi ${_user.name}", Reaction: "wave"},
+		"deploy.yml": {
+			Name: "deploy", Active: true, Respond: "deploy", Args: []flottbottest.Arg{{Name: "app"}},
+			FormatOutput: "deploying ${app}", OutputToRooms: []string{"ops"},
+		},
+		"secret.yml": {Name: "secret", Active: true, Respond: "secret", FormatOutput: "psst", DirectMessageOnly: true},
+	})
+
+	h.Say("hello")
+	h.ExpectReaction("wave")
+	h.ExpectOutput("hi alice")
+
+	h.Say("deploy api")
+	env := h.ExpectOutputToRooms("deploying api", "C1")
+
+	if env.Rule.Name != "deploy" {
+		t.Errorf("ExpectOutputToRooms() rule = %#q, want %#q", env.Rule.Name, "deploy")
+	}
+
+	h.Say("secret")
+	h.ExpectDirectOutput("psst")
+
+	message := flottbottest.NewMessage("hello")
+	message.Vars["_user.name"] = "bob"
+	message.BotMentioned = false
+
+	h.Send(message)
+	h.ExpectNothing(100 * time.Millisecond)
+
+	if got := len(h.Remote.Envelopes()); got != 4 {
+		t.Errorf("Envelopes() = %d envelopes, want 4", got)
+	}
+}
+
+func TestHarness_conversation(t *testing.T) {
+	h := flottbottest.Start(t, nil, map[string]flottbottest.Rule{
+		"release.yml": {
+			Name: "release", Active: true, Respond: "release", Args: flottbottest.NewArgs("app"),
+			Flags:        []flottbottest.Flag{{Name: "force", Type: flottbottest.ArgTypeBool}},
+			Steps:        []flottbottest.Step{{Prompt: "which environment?", Var: "env", Validate: "^(dev|prod)$"}},
+			Concurrency:  flottbottest.Concurrency{Max: 1, OnLimit: flottbottest.ConcurrencyReject},
+			FormatOutput: "releasing ${app} to ${env}",
+		},
+	})
+
+	h.Say("release api --force")
+	h.ExpectOutput("which environment? (say `cancel` to stop)")
+
+	h.Say("prod")
+	h.ExpectOutput("releasing api to prod")
+}
+
+func TestHarness_parallel(t *testing.T) {
+	// the rule may only run once per channel, harnesses that share their rate limits would throttle each other
+	rules := map[string]flottbottest.Rule{
+		"ping.yml": {
+			Name: "ping", Active: true, Respond: "ping", FormatOutput: "pong",
+			RateLimit: []flottbottest.RateLimit{{Scope: flottbottest.RateLimitScopeChannel, Count: 1, Window: time.Hour}},
+		},
+	}
+
+	for _, name := range []string{"First", "Second", "Third"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			h := flottbottest.Start(t, nil, rules)
+
+			h.Say("ping")
+			h.ExpectOutput("pong")
+		})
+	}
+}
+
+func TestHarness_failures(t *testing.T) {
+	rules := map[string]flottbottest.Rule{
+		"hello.yml": {Name: "hello", Active: true, Respond: "hello", FormatOutput: "hi"},
+	}
+
+	tests := []struct {
+		name string
+		run  func(h *flottbottest.Harness)
+	}{
+		{"Wrong output", func(h *flottbottest.Harness) {
+			h.Say("hello")
+			h.ExpectOutput("bye")
+		}},
+		{"Output already returned", func(h *flottbottest.Harness) {
+			h.Say("hello")
+			h.ExpectOutput("hi")
+			h.ExpectOutput("hi")
+		}},
+		{"Unexpected output", func(h *flottbottest.Harness) {
+			h.Say("hello")
+			h.ExpectNothing(time.Second)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ft := &fakeT{T: t}
+
+			// run in its own goroutine, as failing a test stops it with 'runtime.Goexit'
+			done := make(chan struct{})
+
+			go func() {
+				defer close(done)
+
+				h := flottbottest.Start(ft, nil, rules)
+				h.Timeout = 200 * time.Millisecond
+
+				tt.run(h)
+			}()
+
+			<-done
+
+			if !ft.failed {
+				t.Error("the assertion did not fail the test")
+			}
+		})
+	}
+}
+
+// fakeT records failures instead of failing the test.
+type fakeT struct {
+	*testing.T
+
+	failed bool
+}
+
+func (f *fakeT) Errorf(string, ...any) { f.failed = true }
+
+func (f *fakeT) Fatalf(string, ...any) {
+	f.failed = true
+	runtime.Goexit()
+}
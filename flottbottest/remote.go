@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package flottbottest
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+// Remote is a fake chat application. Messages injected into it are read by the bot
+// as if they came from a chat application, and what the bot sends back is captured.
+type Remote struct {
+	name     string
+	messages chan Message
+
+	mu        sync.Mutex
+	envelopes []Envelope
+	consumed  []bool        // envelopes already returned by 'Harness.WaitFor'
+	changed   chan struct{} // closed when an envelope is captured
+}
+
+func newRemote(name string) *Remote {
+	return &Remote{
+		name:     name,
+		messages: make(chan Message),
+		changed:  make(chan struct{}),
+	}
+}
+
+// Inject hands the message to the bot, it blocks until the bot read it or the context is canceled.
+func (r *Remote) Inject(ctx context.Context, message Message) error {
+	select {
+	case r.messages <- message:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Name returns the name the remote is registered under.
+func (r *Remote) Name() string {
+	return r.name
+}
+
+// Read passes the injected messages on to the bot until the context is canceled,
+// a message that was injected always reaches the bot.
+func (r *Remote) Read(ctx context.Context, inputMsgs chan<- models.Message, _ *models.RuleSet, _ *models.Bot) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message := <-r.messages:
+			inputMsgs <- message
+		}
+	}
+}
+
+// Send captures the message.
+func (r *Remote) Send(message models.Message, _ *models.Bot) {
+	r.capture(models.Send(message))
+}
+
+// Reaction captures the reactions of the rule.
+func (r *Remote) Reaction(message models.Message, rule models.Rule, _ *models.Bot) {
+	env := Envelope{Message: message, Rule: rule}
+
+	if rule.Reaction != "" {
+		env.Ops |= OpReact
+	}
+
+	if rule.RemoveReaction != "" {
+		env.Ops |= OpUnreact
+	}
+
+	r.capture(env)
+}
+
+// Envelopes returns everything the bot sent so far, in the order it was sent.
+func (r *Remote) Envelopes() []Envelope {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.envelopes)
+}
+
+func (r *Remote) capture(env Envelope) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.envelopes = append(r.envelopes, env)
+	r.consumed = append(r.consumed, false)
+
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// consume returns the first envelope that matches and wasn't returned before, if there is one.
+// Otherwise it returns a channel that is closed once another envelope is captured.
+func (r *Remote) consume(match func(Envelope) bool) (Envelope, <-chan struct{}, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, env := range r.envelopes {
+		if !r.consumed[i] && match(env) {
+			r.consumed[i] = true
+
+			return env, nil, true
+		}
+	}
+
+	return Envelope{}, r.changed, false
+}
+
+// pending returns the envelopes that weren't returned yet,
+// along with a channel that is closed once another envelope is captured.
+func (r *Remote) pending() ([]Envelope, <-chan struct{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var pending []Envelope
+
+	for i, env := range r.envelopes {
+		if !r.consumed[i] {
+			pending = append(pending, env)
+		}
+	}
+
+	return pending, r.changed
+}
@@ -6,15 +6,77 @@ import (
 	"slices"
 	"strings"
 
-	"github.com/bwmarrin/discordgo"
 	"github.com/rs/zerolog/log"
-	"github.com/slack-go/slack"
 
 	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/remote"
 )
 
 // CanTrigger ensures the user is allowed to use the respective rule.
-func CanTrigger(currentUserName string, currentUserID string, rule models.Rule, bot *models.Bot) bool {
+// The chat application is used to look up the user's group memberships.
+func CanTrigger(chatApp string, currentUserName string, currentUserID string, rule models.Rule, bot *models.Bot) bool {
+	return canTrigger(currentUserName, currentUserID, rule, func(userGroups []string) (bool, error) {
+		return isMemberOfGroup(chatApp, currentUserID, userGroups, bot)
+	})
+}
+
+// Membership checks which rules a user can trigger, remembering the groups the user is part of.
+// Checking many rules for the same message, ie. to show help, asks the chat application
+// about each group only once, instead of once for every rule that restricts groups.
+type Membership struct {
+	chatApp  string
+	userName string
+	userID   string
+	bot      *models.Bot
+	groups   map[string]groupMembership
+}
+
+// groupMembership is whether the user is part of a group, or why that couldn't be checked.
+type groupMembership struct {
+	member bool
+	err    error
+}
+
+// NewMembership creates the membership of a user in a chat application, the groups are looked up when needed.
+func NewMembership(chatApp string, currentUserName string, currentUserID string, bot *models.Bot) *Membership {
+	return &Membership{
+		chatApp:  chatApp,
+		userName: currentUserName,
+		userID:   currentUserID,
+		bot:      bot,
+		groups:   make(map[string]groupMembership),
+	}
+}
+
+// CanTrigger ensures the user is allowed to use the respective rule, like 'CanTrigger'.
+func (m *Membership) CanTrigger(rule models.Rule) bool {
+	return canTrigger(m.userName, m.userID, rule, m.isMember)
+}
+
+// isMember checks whether the user is part of any of the user groups, looking up each group once.
+func (m *Membership) isMember(userGroups []string) (bool, error) {
+	for _, group := range userGroups {
+		known, ok := m.groups[group]
+		if !ok {
+			known.member, known.err = isMemberOfGroup(m.chatApp, m.userID, []string{group}, m.bot)
+			m.groups[group] = known
+		}
+
+		if known.err != nil {
+			return false, known.err
+		}
+
+		if known.member {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// canTrigger ensures the user is allowed to use the rule, isMember checks whether
+// the user is part of any of the given user groups.
+func canTrigger(currentUserName string, currentUserID string, rule models.Rule, isMember func([]string) (bool, error)) bool {
 	var canRunRule bool
 
 	// no restriction were given for this rule, allow to proceed
@@ -29,7 +91,7 @@ func CanTrigger(currentUserName string, currentUserID string, rule models.Rule,
 	}
 
 	// are they part of a usergroup to be ignored? deny
-	isIgnored, err := isMemberOfGroup(currentUserID, rule.IgnoreUserGroups, bot)
+	isIgnored, err := isMember(rule.IgnoreUserGroups)
 	// deny access if unable to check group membership due to error
 	if err != nil {
 		return false
@@ -60,7 +122,7 @@ func CanTrigger(currentUserName string, currentUserID string, rule models.Rule,
 	// if they still can't run the rule,
 	// check if they are a member of any of the supplied allowed user groups
 	if !canRunRule && len(rule.AllowUserGroups) > 0 {
-		isAllowed, err := isMemberOfGroup(currentUserID, rule.AllowUserGroups, bot)
+		isAllowed, err := isMember(rule.AllowUserGroups)
 		// deny access if unable to check group membership due to error
 		if err != nil {
 			return false
@@ -90,64 +152,20 @@ func CanTrigger(currentUserName string, currentUserID string, rule models.Rule,
 }
 
 // utility function to check if a user is part of the specified user groups,
-// if it's unable to check groupmembership, it will return an error
-// TODO: Refactor to keep remote specific stuff in remote, also to allow increase testability.
-func isMemberOfGroup(currentUserID string, userGroups []string, bot *models.Bot) (bool, error) {
+// if it's unable to check groupmembership, it will return an error.
+// Group membership is looked up by the registered remote of the chat application.
+func isMemberOfGroup(chatApp string, currentUserID string, userGroups []string, bot *models.Bot) (bool, error) {
 	if len(userGroups) == 0 {
 		return false, nil
 	}
 
-	capp := strings.ToLower(bot.ChatApplication)
-	switch capp {
-	case models.ChatAppDiscord:
-		var usr *discordgo.Member
-
-		dg, err := discordgo.New("Bot " + bot.DiscordToken)
-		if err != nil {
-			return false, err
-		}
-
-		usr, err = dg.GuildMember(bot.DiscordServerID, currentUserID)
-		if err != nil {
-			log.Error().Msgf("error while searching for user - error: %v", err)
-			return false, nil
-		}
-
-		for _, group := range userGroups {
-			for _, uGroup := range usr.Roles {
-				if strings.EqualFold(bot.UserGroups[group], uGroup) {
-					return true, nil
-				}
-			}
-		}
-
-		return false, nil
-	case models.ChatAppSlack:
-		// Check if we are restricting by usergroup
-		api := slack.New(bot.SlackToken)
-
-		for _, usergroupName := range userGroups {
-			// Get the ID of the group from the usergroups the bot is aware of
-			for knownUserGroupName, knownUserGroupID := range bot.UserGroups {
-				if knownUserGroupName == usergroupName {
-					// Get the members of the group
-					userGroupMembers, err := api.GetUserGroupMembers(knownUserGroupID)
-					if err != nil {
-						log.Error().Msgf("unable to retrieve user group members, %v", err)
-					}
-					// Check if any of the members are the current user
-					if slices.Contains(userGroupMembers, currentUserID) {
-						return true, nil
-					}
-
-					break
-				}
-			}
-		}
+	capp := strings.ToLower(chatApp)
 
-		return false, nil
-	default:
+	reg, ok := remote.Lookup(capp)
+	if !ok || reg.IsMemberOfGroup == nil {
 		log.Error().Msgf("chat application %#q is not supported", capp)
 		return false, nil
 	}
+
+	return reg.IsMemberOfGroup(currentUserID, userGroups, bot)
 }
@@ -3,9 +3,12 @@
 package auth
 
 import (
+	"maps"
+	"slices"
 	"testing"
 
 	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/remote"
 )
 
 func TestCanTrigger(t *testing.T) {
@@ -17,13 +20,24 @@ func TestCanTrigger(t *testing.T) {
 	}
 
 	testBot := new(models.Bot)
-	testBot.ChatApplication = models.ChatAppSlack
+	testBot.ChatApplications = []string{models.ChatAppSlack}
 
 	discordBot := new(models.Bot)
-	discordBot.ChatApplication = models.ChatAppDiscord
+	discordBot.ChatApplications = []string{models.ChatAppDiscord}
 
 	strangeBot := new(models.Bot)
-	strangeBot.ChatApplication = "strange"
+	strangeBot.ChatApplications = []string{"strange"}
+
+	// fake chat application where jane.doe is part of the admins group
+	remote.Register("fake", remote.Registration{
+		IsMemberOfGroup: func(userID string, userGroups []string, _ *models.Bot) (bool, error) {
+			return userID == "F123456" && slices.Contains(userGroups, "admins"), nil
+		},
+	})
+	defer remote.Unregister("fake")
+
+	fakeBot := new(models.Bot)
+	fakeBot.ChatApplications = []string{"fake"}
 
 	tests := []struct {
 		name string
@@ -41,18 +55,57 @@ func TestCanTrigger(t *testing.T) {
 		{"User is not allowed and ignored", args{"john.doe", "F123456", models.Rule{AllowUsers: []string{"jane.doe"}, IgnoreUsers: []string{"john.doe", "jack.jill"}}, testBot}, false},
 		{"Group - Discord - Not supported", args{"jane.doe", "F123456", models.Rule{AllowUserGroups: []string{"admins"}}, discordBot}, false},
 		{"Group - Chat network not supported", args{"jane.doe", "F123456", models.Rule{AllowUserGroups: []string{"admins"}}, strangeBot}, false},
-		// TODO: figure out how to test this below:
-		// {"User in allow group but ignored", args{"jane.doe", "F123456", models.Rule{}, testBot}, false},
-		// {"User in ignore group but allowed", args{"jane.doe", "F123456", models.Rule{}, testBot}, false},
-		// {"User in ignore group and allow group", args{"jane.doe", "F123456", models.Rule{}, testBot}, false},
-		// {"User in allow group and not ignored", args{"jane.doe", "F123456", models.Rule{}, testBot}, true},
-		// {"User is not in allow group and not ignored", args{"jane.doe", "F123456", models.Rule{}, testBot}, false},
+		{"User in allow group but ignored", args{"jane.doe", "F123456", models.Rule{AllowUserGroups: []string{"admins"}, IgnoreUsers: []string{"jane.doe"}}, fakeBot}, false},
+		{"User in ignore group but allowed", args{"jane.doe", "F123456", models.Rule{AllowUsers: []string{"jane.doe"}, IgnoreUserGroups: []string{"admins"}}, fakeBot}, false},
+		{"User in ignore group and allow group", args{"jane.doe", "F123456", models.Rule{AllowUserGroups: []string{"admins"}, IgnoreUserGroups: []string{"admins"}}, fakeBot}, false},
+		{"User in allow group and not ignored", args{"jane.doe", "F123456", models.Rule{AllowUserGroups: []string{"admins"}}, fakeBot}, true},
+		{"User is not in allow group and not ignored", args{"john.doe", "G123456", models.Rule{AllowUserGroups: []string{"admins"}}, fakeBot}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := CanTrigger(tt.args.currentUserName, tt.args.currentUserID, tt.args.rule, tt.args.bot); got != tt.want {
+			if got := CanTrigger(tt.args.bot.ChatApplications[0], tt.args.currentUserName, tt.args.currentUserID, tt.args.rule, tt.args.bot); got != tt.want {
 				t.Errorf("CanTrigger() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func TestMembership(t *testing.T) {
+	lookups := map[string]int{}
+
+	// fake chat application where jane.doe is part of the admins group
+	remote.Register("fake", remote.Registration{
+		IsMemberOfGroup: func(userID string, userGroups []string, _ *models.Bot) (bool, error) {
+			for _, group := range userGroups {
+				lookups[group]++
+			}
+
+			return userID == "F123456" && slices.Contains(userGroups, "admins"), nil
+		},
+	})
+	defer remote.Unregister("fake")
+
+	rules := []struct {
+		rule models.Rule
+		want bool
+	}{
+		{models.Rule{Name: "open"}, true},
+		{models.Rule{Name: "admins", AllowUserGroups: []string{"admins"}}, true},
+		{models.Rule{Name: "admins or ops", AllowUserGroups: []string{"ops", "admins"}}, true},
+		{models.Rule{Name: "ops", AllowUserGroups: []string{"ops"}}, false},
+		{models.Rule{Name: "not admins", IgnoreUserGroups: []string{"admins"}}, false},
+	}
+
+	membership := NewMembership("fake", "jane.doe", "F123456", new(models.Bot))
+
+	for _, r := range rules {
+		if got := membership.CanTrigger(r.rule); got != r.want {
+			t.Errorf("CanTrigger(%#q) = %v, want %v", r.rule.Name, got, r.want)
+		}
+	}
+
+	// each group is looked up once for all rules
+	if want := map[string]int{"admins": 1, "ops": 1}; !maps.Equal(lookups, want) {
+		t.Errorf("CanTrigger() looked up groups %v, want %v", lookups, want)
+	}
+}
@@ -3,20 +3,19 @@
 package chat
 
 import (
-	"strings"
-
 	"github.com/rs/zerolog/log"
 
 	"github.com/target/flottbot/internal/models"
 )
 
-// GetRoomIDs helps find a room by name, if we have 'cached' it.
-func GetRoomIDs(wantRooms []string, bot *models.Bot) []string {
+// GetRoomIDs helps find rooms by name, among the rooms of the chat application,
+// an empty chat application means the primary one.
+func GetRoomIDs(chatApp string, wantRooms []string, bot *models.Bot) []string {
 	rooms := []string{}
 
 	for _, room := range wantRooms {
-		roomMatch := bot.Rooms[strings.ToLower(room)]
-		if roomMatch != "" {
+		roomMatch, ok := bot.RoomID(chatApp, room)
+		if ok {
 			rooms = append(rooms, roomMatch)
 		} else {
 			log.Error().Msgf("room %#q does not exist", room)
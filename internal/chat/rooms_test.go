@@ -11,6 +11,7 @@ import (
 
 func TestGetRoomIDs(t *testing.T) {
 	type args struct {
+		chatApp   string
 		wantRooms []string
 		bot       *models.Bot
 	}
@@ -33,6 +34,11 @@ func TestGetRoomIDs(t *testing.T) {
 
 	RoomDoesNotExistWant := []string{}
 
+	// For rooms with the same name on multiple chat applications
+	multipleBot := &models.Bot{ChatApplications: []string{"slack", "mattermost"}}
+	multipleBot.AddRooms("slack", map[string]string{"general": "C123"})
+	multipleBot.AddRooms("mattermost", map[string]string{"general": "m456"})
+
 	tests := []struct {
 		name string
 		args args
@@ -40,11 +46,13 @@ func TestGetRoomIDs(t *testing.T) {
 	}{
 		{"Basic", args{}, []string{}},
 		{"Room exists", args{wantRooms: RoomExistsIn, bot: &models.Bot{Rooms: RoomExistsActive}}, RoomExistsWant},
+		{"Room of the chat application", args{chatApp: "mattermost", wantRooms: []string{"general"}, bot: multipleBot}, []string{"m456"}},
+		{"Room of the primary chat application", args{wantRooms: []string{"general"}, bot: multipleBot}, []string{"C123"}},
 		{"Room does not exist", args{wantRooms: RoomDoesNotExistIn, bot: &models.Bot{Rooms: RoomDoesNotExistActive}}, RoomDoesNotExistWant},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := GetRoomIDs(tt.args.wantRooms, tt.args.bot); !reflect.DeepEqual(got, tt.want) {
+			if got := GetRoomIDs(tt.args.chatApp, tt.args.wantRooms, tt.args.bot); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("GetRoomIDs() = %v, want %v", got, tt.want)
 			}
 		})
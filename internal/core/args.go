@@ -0,0 +1,378 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+
+	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/text"
+)
+
+var (
+	userMentionRegex    = regexp.MustCompile(`^<@!?([\w.-]+)(?:\|[^>]*)?>$`)
+	userNameRegex       = regexp.MustCompile(`^@([\w.-]+)$`)
+	channelMentionRegex = regexp.MustCompile(`^<#([\w-]+)(?:\|[^>]*)?>$`)
+	mailtoRegex         = regexp.MustCompile(`^<mailto:([^|>]+)(?:\|[^>]*)?>$`)
+)
+
+// argDecodeHook allows arguments to be declared by name, ie. 'args: [app, env?, notes+]',
+// as well as with their fields, or a mix of both.
+func argDecodeHook() mapstructure.DecodeHookFuncType {
+	return func(from, to reflect.Type, data any) (any, error) {
+		if from.Kind() != reflect.String || to != reflect.TypeFor[models.Arg]() {
+			return data, nil
+		}
+
+		return models.NewArg(data.(string)), nil
+	}
+}
+
+// validateArgs checks the argument declarations of a rule.
+func validateArgs(args []models.Arg) error {
+	var (
+		errs     []error
+		names    = map[string]bool{}
+		optional bool
+		variadic int
+	)
+
+	for i, arg := range args {
+		if arg.Name == "" {
+			errs = append(errs, fmt.Errorf("argument %d has no name", i+1))
+			continue
+		}
+
+		if names[arg.Name] {
+			errs = append(errs, fmt.Errorf("argument %#q is declared more than once", arg.Name))
+		}
+
+		names[arg.Name] = true
+
+		errs = append(errs, validateArgType("argument", arg)...)
+
+		if arg.Optional || arg.Default != "" {
+			optional = true
+		}
+
+		if arg.Variadic {
+			variadic++
+
+			if i != len(args)-1 {
+				errs = append(errs, errors.New("you must specify the variable argument in the last argument position"))
+			}
+		}
+	}
+
+	if variadic > 1 {
+		errs = append(errs, errors.New("you cannot specify more than 1 variable argument"))
+	}
+
+	if variadic > 0 && optional {
+		errs = append(errs, errors.New("you cannot combine optional arguments with variable arguments"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateArgType checks the type, pattern and default of an argument or flag.
+func validateArgType(kind string, arg models.Arg) []error {
+	if !slices.Contains(argTypes, argType(arg)) {
+		return []error{fmt.Errorf("%s %#q has unknown type %#q, use one of %s", kind, arg.Name, arg.Type, strings.Join(argTypes, ", "))}
+	}
+
+	var errs []error
+
+	if argType(arg) == models.ArgTypeEnum && len(arg.Values) == 0 {
+		errs = append(errs, fmt.Errorf("%s %#q of type 'enum' needs 'values'", kind, arg.Name))
+	}
+
+	if arg.Pattern != "" {
+		if _, err := regexp.Compile(arg.Pattern); err != nil {
+			errs = append(errs, fmt.Errorf("%s %#q has an invalid pattern: %w", kind, arg.Name, err))
+		}
+	}
+
+	if arg.Default != "" {
+		if _, err := convertArg(arg, arg.Default, "", nil); err != nil {
+			errs = append(errs, fmt.Errorf("%s %#q has an invalid default: %w", kind, arg.Name, err))
+		}
+	}
+
+	return errs
+}
+
+// argTypes are the supported argument types.
+var argTypes = []string{
+	models.ArgTypeString,
+	models.ArgTypeInt,
+	models.ArgTypeFloat,
+	models.ArgTypeBool,
+	models.ArgTypeEnum,
+	models.ArgTypeDuration,
+	models.ArgTypeEmail,
+	models.ArgTypeUser,
+	models.ArgTypeChannel,
+}
+
+// argType returns the type of the argument, 'string' if none was declared.
+func argType(arg models.Arg) string {
+	if arg.Type == "" {
+		return models.ArgTypeString
+	}
+
+	return strings.ToLower(arg.Type)
+}
+
+// parseArgs converts the values supplied by the user to the declared arguments of the rule.
+// Missing arguments get their default value, or are empty.
+func parseArgs(rule models.Rule, values []string, chatApp string, bot *models.Bot) (map[string]string, error) {
+	vars := map[string]string{}
+
+	for index, arg := range rule.Args {
+		if index >= len(values) {
+			vars[arg.Name] = arg.Default
+			continue
+		}
+
+		// a variadic argument takes the remaining values
+		if arg.Variadic {
+			converted := make([]string, 0, len(values)-index)
+
+			for _, value := range values[index:] {
+				c, err := convertArg(arg, value, chatApp, bot)
+				if err != nil {
+					return nil, argError(rule, arg, value, err)
+				}
+
+				converted = append(converted, c)
+			}
+
+			vars[arg.Name] = strings.Join(converted, " ")
+
+			break
+		}
+
+		value, err := convertArg(arg, values[index], chatApp, bot)
+		if err != nil {
+			return nil, argError(rule, arg, values[index], err)
+		}
+
+		vars[arg.Name] = value
+	}
+
+	return vars, nil
+}
+
+// convertArg validates the value against the declared argument and returns it normalized,
+// ie. mentions are resolved to IDs. Channel names are only resolved if a bot is given,
+// among the rooms of the chat application the value came from. Users are mentioned by
+// name, ie. '@someone', in chat applications that don't encode mentions like Slack and Discord.
+func convertArg(arg models.Arg, value, chatApp string, bot *models.Bot) (string, error) {
+	if arg.Pattern != "" {
+		re, err := regexp.Compile(arg.Pattern)
+		if err != nil {
+			return "", fmt.Errorf("invalid pattern %#q", arg.Pattern)
+		}
+
+		if !re.MatchString(value) {
+			return "", fmt.Errorf("expected a value matching %#q", arg.Pattern)
+		}
+	}
+
+	switch argType(arg) {
+	case models.ArgTypeInt:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "", errors.New("expected an integer")
+		}
+
+		return strconv.FormatInt(i, 10), nil
+	case models.ArgTypeFloat:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", errors.New("expected a number")
+		}
+
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	case models.ArgTypeBool:
+		switch strings.ToLower(value) {
+		case "true", "yes", "y", "on", "1":
+			return "true", nil
+		case "false", "no", "n", "off", "0":
+			return "false", nil
+		}
+
+		return "", errors.New("expected one of true, false, yes, no, on, off")
+	case models.ArgTypeEnum:
+		for _, allowed := range arg.Values {
+			if strings.EqualFold(allowed, value) {
+				return allowed, nil
+			}
+		}
+
+		return "", fmt.Errorf("expected one of %s", strings.Join(arg.Values, ", "))
+	case models.ArgTypeDuration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return "", errors.New("expected a duration, ie. 90s, 15m or 1h30m")
+		}
+
+		return d.String(), nil
+	case models.ArgTypeEmail:
+		// slack links email addresses
+		if m := mailtoRegex.FindStringSubmatch(value); m != nil {
+			value = m[1]
+		}
+
+		addr, err := mail.ParseAddress(value)
+		if err != nil || addr.Address != value {
+			return "", errors.New("expected an email address")
+		}
+
+		return addr.Address, nil
+	case models.ArgTypeUser:
+		if m := userMentionRegex.FindStringSubmatch(value); m != nil {
+			return m[1], nil
+		}
+
+		// mattermost, telegram and google chat keep mentions as typed, the name identifies the user
+		if m := userNameRegex.FindStringSubmatch(value); m != nil && !encodesMentions(chatApp) {
+			return m[1], nil
+		}
+
+		return "", errors.New("expected a user mention, ie. @someone")
+	case models.ArgTypeChannel:
+		if m := channelMentionRegex.FindStringSubmatch(value); m != nil {
+			return m[1], nil
+		}
+
+		name := strings.TrimPrefix(value, "#")
+		if bot == nil {
+			return name, nil
+		}
+
+		if id, ok := bot.RoomID(chatApp, name); ok {
+			return id, nil
+		}
+
+		return "", errors.New("expected a channel mention, ie. #general")
+	default:
+		return value, nil
+	}
+}
+
+// encodesMentions tells whether the chat application replaces mentions with the ID of
+// the user, ie. '<@U123>', so that a name typed after an '@' didn't mention anyone.
+func encodesMentions(chatApp string) bool {
+	switch strings.ToLower(chatApp) {
+	case models.ChatAppSlack, models.ChatAppDiscord:
+		return true
+	default:
+		return false
+	}
+}
+
+// argError describes why the value is not valid for the argument.
+func argError(rule models.Rule, arg models.Arg, value string, err error) error {
+	return usageError(rule, fmt.Sprintf("invalid value %#q for argument %#q: %v", value, arg.Name, err))
+}
+
+// usageError adds the usage of the rule and the descriptions of its arguments and flags to the message.
+func usageError(rule models.Rule, msg string) error {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%s\n```%s```", msg, argsUsage(rule))
+
+	for _, a := range rule.Args {
+		if a.Description != "" {
+			fmt.Fprintf(&sb, "\n • %#q: %s", a.Name, a.Description)
+		}
+	}
+
+	for _, f := range rule.Flags {
+		if f.Description != "" {
+			fmt.Fprintf(&sb, "\n • %#q: %s", "--"+f.Name, f.Description)
+		}
+	}
+
+	return errors.New(sb.String())
+}
+
+// ruleUsage returns the help text of the rule, or the usage generated from its arguments and flags if it has none.
+func ruleUsage(rule models.Rule) string {
+	if rule.HelpText != "" || rule.Respond == "" || len(rule.Args)+len(rule.Flags) == 0 {
+		return rule.HelpText
+	}
+
+	return argsUsage(rule)
+}
+
+// argsUsage generates the usage of a 'respond' rule from its arguments and flags,
+// ie. 'deploy <app> <env:dev|qa|prod> [count:int=1] <notes...> [--dry-run]'.
+func argsUsage(rule models.Rule) string {
+	usage := []string{rule.Respond}
+
+	for _, arg := range rule.Args {
+		name := arg.Name
+
+		switch t := argType(arg); t {
+		case models.ArgTypeString:
+		case models.ArgTypeEnum:
+			name += ":" + strings.Join(arg.Values, "|")
+		default:
+			name += ":" + t
+		}
+
+		if arg.Default != "" {
+			name += "=" + arg.Default
+		}
+
+		if arg.Variadic {
+			name += "..."
+		}
+
+		if arg.IsRequired() {
+			usage = append(usage, "<"+name+">")
+		} else {
+			usage = append(usage, "["+name+"]")
+		}
+	}
+
+	usage = append(usage, flagsUsage(rule)...)
+
+	return strings.Join(usage, " ")
+}
+
+// substituteDefaults applies environment variables to the default values of the arguments and flags.
+func substituteDefaults(r *models.Rule) error {
+	for i := range r.Args {
+		value, err := text.Substitute(r.Args[i].Default, map[string]string{})
+		if err != nil {
+			return fmt.Errorf("could not configure default of argument %#q: %w", r.Args[i].Name, err)
+		}
+
+		r.Args[i].Default = value
+	}
+
+	for i := range r.Flags {
+		value, err := text.Substitute(r.Flags[i].Default, map[string]string{})
+		if err != nil {
+			return fmt.Errorf("could not configure default of flag %#q: %w", r.Flags[i].Name, err)
+		}
+
+		r.Flags[i].Default = value
+	}
+
+	return nil
+}
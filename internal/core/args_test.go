@@ -0,0 +1,150 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+func Test_validateArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []models.Arg
+		wantErr bool
+	}{
+		{"Declared by name", models.NewArgs("app", "env?"), false},
+		{"Variadic", models.NewArgs("app", "notes+"), false},
+		{"Typed", []models.Arg{{Name: "count", Type: "int", Default: "1"}, {Name: "env", Type: "enum", Values: []string{"dev", "prod"}}}, false},
+		{"No name", []models.Arg{{Type: "int"}}, true},
+		{"Duplicate name", models.NewArgs("app", "app"), true},
+		{"Unknown type", []models.Arg{{Name: "count", Type: "integer"}}, true},
+		{"Enum without values", []models.Arg{{Name: "env", Type: "enum"}}, true},
+		{"Invalid pattern", []models.Arg{{Name: "app", Pattern: "("}}, true},
+		{"Invalid default", []models.Arg{{Name: "wait", Type: "duration", Default: "soon"}}, true},
+		{"More than one variadic", models.NewArgs("app+", "notes+"), true},
+		{"Variadic not last", models.NewArgs("notes+", "app"), true},
+		{"Optional and variadic", models.NewArgs("app?", "notes+"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateArgs(tt.args); (err != nil) != tt.wantErr {
+				t.Errorf("validateArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_parseArgs(t *testing.T) {
+	bot := &models.Bot{Rooms: map[string]string{"general": "C0GENERAL"}}
+
+	tests := []struct {
+		name    string
+		args    []models.Arg
+		values  []string
+		want    map[string]string
+		wantErr string
+	}{
+		{"Strings", models.NewArgs("app", "env?"), []string{"flottbot"}, map[string]string{"app": "flottbot", "env": ""}, ""},
+		{"Variadic", models.NewArgs("app", "notes+"), []string{"flottbot", "hello", "there"}, map[string]string{"app": "flottbot", "notes": "hello there"}, ""},
+		{"Int", []models.Arg{{Name: "count", Type: "int"}}, []string{"042"}, map[string]string{"count": "42"}, ""},
+		{"Invalid int", []models.Arg{{Name: "count", Type: "int"}}, []string{"x"}, nil, "invalid value `x` for argument `count`: expected an integer\n```deploy <count:int>```"},
+		{"Float", []models.Arg{{Name: "ratio", Type: "float"}}, []string{"0.50"}, map[string]string{"ratio": "0.5"}, ""},
+		{"Bool", []models.Arg{{Name: "force", Type: "bool"}}, []string{"Yes"}, map[string]string{"force": "true"}, ""},
+		{"Enum", []models.Arg{{Name: "env", Type: "enum", Values: []string{"dev", "prod"}}}, []string{"PROD"}, map[string]string{"env": "prod"}, ""},
+		{"Invalid enum", []models.Arg{{Name: "env", Type: "enum", Values: []string{"dev", "prod"}, Description: "where to deploy"}}, []string{"qa"}, nil, "invalid value `qa` for argument `env`: expected one of dev, prod\n```deploy <env:dev|prod>```\n • `env`: where to deploy"},
+		{"Duration", []models.Arg{{Name: "wait", Type: "duration"}}, []string{"90s"}, map[string]string{"wait": "1m30s"}, ""},
+		{"Pattern", []models.Arg{{Name: "version", Pattern: `^v\d+$`}}, []string{"v1"}, map[string]string{"version": "v1"}, ""},
+		{"Invalid pattern match", []models.Arg{{Name: "version", Pattern: `^v\d+$`}}, []string{"1"}, nil, "invalid value `1` for argument `version`: expected a value matching `^v\\d+$`\n```deploy <version>```"},
+		{"Email", []models.Arg{{Name: "email", Type: "email"}}, []string{"<mailto:bot@example.com|bot@example.com>"}, map[string]string{"email": "bot@example.com"}, ""},
+		{"Invalid email", []models.Arg{{Name: "email", Type: "email"}}, []string{"bot"}, nil, "invalid value `bot` for argument `email`: expected an email address\n```deploy <email:email>```"},
+		{"User", []models.Arg{{Name: "who", Type: "user"}}, []string{"<@U123|someone>"}, map[string]string{"who": "U123"}, ""},
+		{"Users", []models.Arg{{Name: "who", Type: "user", Variadic: true}}, []string{"<@U123>", "<@!456>"}, map[string]string{"who": "U123 456"}, ""},
+		{"Invalid user", []models.Arg{{Name: "who", Type: "user"}}, []string{"someone"}, nil, "invalid value `someone` for argument `who`: expected a user mention, ie. @someone\n```deploy <who:user>```"},
+		{"Channel mention", []models.Arg{{Name: "where", Type: "channel"}}, []string{"<#C123|random>"}, map[string]string{"where": "C123"}, ""},
+		{"Channel name", []models.Arg{{Name: "where", Type: "channel"}}, []string{"#General"}, map[string]string{"where": "C0GENERAL"}, ""},
+		{"Unknown channel", []models.Arg{{Name: "where", Type: "channel"}}, []string{"#nope"}, nil, "invalid value `#nope` for argument `where`: expected a channel mention, ie. #general\n```deploy <where:channel>```"},
+		{"Default", []models.Arg{{Name: "app"}, {Name: "count", Type: "int", Default: "1"}}, []string{"flottbot"}, map[string]string{"app": "flottbot", "count": "1"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseArgs(models.Rule{Respond: "deploy", Args: tt.args}, tt.values, "", bot)
+			if err != nil {
+				if err.Error() != tt.wantErr {
+					t.Fatalf("parseArgs() error = %q, want %q", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if tt.wantErr != "" {
+				t.Fatalf("parseArgs() expected error %q", tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_convertArg_user(t *testing.T) {
+	arg := models.Arg{Name: "who", Type: models.ArgTypeUser}
+
+	tests := []struct {
+		name    string
+		value   string
+		chatApp string
+		want    string
+		wantErr bool
+	}{
+		{"Slack mention", "<@U123|someone>", models.ChatAppSlack, "U123", false},
+		{"Discord mention", "<@!456>", models.ChatAppDiscord, "456", false},
+		{"Mattermost name", "@some.one", models.ChatAppMattermost, "some.one", false},
+		{"Telegram name", "@some_one", models.ChatAppTelegram, "some_one", false},
+		{"Google Chat name", "@someone", models.ChatAppGoogleChat, "someone", false},
+		{"Name on Slack", "@someone", models.ChatAppSlack, "", true},
+		{"Name without @", "someone", models.ChatAppMattermost, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertArg(arg, tt.value, tt.chatApp, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("convertArg() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("convertArg() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ruleUsage(t *testing.T) {
+	args := []models.Arg{
+		{Name: "app"},
+		{Name: "env", Type: "enum", Values: []string{"dev", "qa", "prod"}},
+		{Name: "count", Type: "int", Default: "1"},
+		{Name: "dry_run", Type: "bool", Optional: true},
+	}
+
+	tests := []struct {
+		name string
+		rule models.Rule
+		want string
+	}{
+		{"Help text", models.Rule{Respond: "deploy", Args: args, HelpText: "deploy an app"}, "deploy an app"},
+		{"Generated", models.Rule{Respond: "deploy", Args: args}, "deploy <app> <env:dev|qa|prod> [count:int=1] [dry_run:bool]"},
+		{"Variadic", models.Rule{Respond: "say", Args: models.NewArgs("where", "text+")}, "say <where> <text...>"},
+		{"No args", models.Rule{Respond: "ping"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ruleUsage(tt.rule); got != tt.want {
+				t.Errorf("ruleUsage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/slack-go/slack"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+// attachmentDecodeHook decodes the slack attachments of a rule by their JSON keys,
+// ie. 'callback_id', as they are documented by Slack.
+func attachmentDecodeHook() mapstructure.DecodeHookFuncType {
+	return func(_, to reflect.Type, data any) (any, error) {
+		if to != reflect.TypeFor[slack.Attachment]() {
+			return data, nil
+		}
+
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+
+		attachment := slack.Attachment{}
+
+		err = json.Unmarshal(raw, &attachment)
+		if err != nil {
+			return nil, fmt.Errorf("invalid slack attachment: %w", err)
+		}
+
+		return attachment, nil
+	}
+}
+
+// renderBlocks applies the variables and templates of the message to the blocks of a rule.
+// Blocks given as list are rendered value by value, blocks given as string are rendered as a whole,
+// which allows templates to produce the JSON of the blocks, ie. with 'range' or 'toJson'.
+func renderBlocks(blocks any, msg *models.Message) (any, error) {
+	var errs []error
+
+	rendered := walkBlocks(blocks, func(value string) string {
+		out, err := renderString(value, msg)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		return out
+	})
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return rendered, nil
+}
+
+// renderString substitutes the variables in the value and executes it as template, if it is one.
+func renderString(value string, msg *models.Message) (string, error) {
+	out, err := substituteTemplate(value, msg.Vars)
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.Contains(out, "{{") {
+		return unescapeTemplate(out), nil
+	}
+
+	// unlike 'format_output', blocks use text/template, html escaping would break their JSON
+	t, err := template.New("blocks").Funcs(templateFuncs(msg)).Parse(out)
+	if err != nil {
+		return "", err
+	}
+
+	buf := new(bytes.Buffer)
+
+	err = t.Execute(buf, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return unescapeTemplate(buf.String()), nil
+}
+
+// validateBlockTemplates checks that the templates used in the blocks of a rule can be parsed.
+func validateBlockTemplates(blocks any) error {
+	funcs := templateFuncs(&models.Message{Vars: map[string]string{}})
+
+	var errs []error
+
+	walkBlocks(blocks, func(value string) string {
+		if strings.Contains(value, "{{") {
+			if _, err := template.New("blocks").Funcs(funcs).Parse(value); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		return value
+	})
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid template in slack blocks: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// walkBlocks returns a copy of the blocks with fn applied to each string,
+// the blocks of the rule are shared between messages and must not be changed.
+func walkBlocks(blocks any, fn func(string) string) any {
+	switch v := blocks.(type) {
+	case string:
+		return fn(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = walkBlocks(item, fn)
+		}
+
+		return out
+	case []map[string]any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = walkBlocks(item, fn)
+		}
+
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[key] = walkBlocks(item, fn)
+		}
+
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+func Test_renderBlocks(t *testing.T) {
+	msg := models.NewMessage()
+	msg.Vars["app"] = `my "app"`
+	msg.Vars["env"] = "qa"
+
+	ruleBlocks := []any{
+		map[string]any{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": "deploy ${app} {{ upper \"now\" }}?"}},
+		map[string]any{"type": "divider", "extra": 1},
+	}
+
+	tests := []struct {
+		name    string
+		blocks  any
+		want    any
+		wantErr bool
+	}{
+		{"No blocks", nil, nil, false},
+		{"List", ruleBlocks, []any{
+			map[string]any{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": `deploy my "app" NOW?`}},
+			map[string]any{"type": "divider", "extra": 1},
+		}, false},
+		{"Template", `[{{ range $i := until 2 }}{{ if $i }},{{ end }}{"type":"section","text":{"type":"plain_text","text":{{ "${env}" | upper | toJson }}}}{{ end }}]`,
+			`[{"type":"section","text":{"type":"plain_text","text":"QA"}},{"type":"section","text":{"type":"plain_text","text":"QA"}}]`, false},
+		{"Undefined variable", []any{map[string]any{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": "${nope}"}}}, nil, true},
+		{"Failing template", `{{ fail "nope" }}`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderBlocks(tt.blocks, &msg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("renderBlocks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("renderBlocks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// the blocks of the rule are left untouched
+	if ruleBlocks[0].(map[string]any)["text"].(map[string]any)["text"] != "deploy ${app} {{ upper \"now\" }}?" {
+		t.Error("renderBlocks() changed the blocks of the rule")
+	}
+}
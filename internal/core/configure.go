@@ -8,13 +8,11 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/target/flottbot/internal/models"
-	"github.com/target/flottbot/internal/remote/gchat"
+	"github.com/target/flottbot/internal/remote"
+	"github.com/target/flottbot/internal/store"
 	"github.com/target/flottbot/internal/text"
-	"github.com/target/flottbot/internal/validation"
 )
 
-var defaultSlackListenerPort = "3000"
-
 // Configure searches the config directory for the bot.yml to create a Bot object.
 // The Bot object will be passed around to make accessible system-specific information.
 func Configure(bot *models.Bot) {
@@ -24,11 +22,15 @@ func Configure(bot *models.Bot) {
 
 	configureChatApplication(bot)
 
+	configureRedis(bot)
+
+	configureStore(bot)
+
 	log.Info().Msgf("configured bot %#q!", bot.Name)
 }
 
-// configureChatApplication configures a user's specified chat application
-// TODO: Refactor to keep remote specifics in remote/.
+// configureChatApplication configures each of a user's specified chat applications
+// using the configuration of the registered remote.
 func configureChatApplication(bot *models.Bot) {
 	// emptyMap for substitute function
 	// (it will only replace from env vars)
@@ -42,177 +44,68 @@ func configureChatApplication(bot *models.Bot) {
 
 	bot.Name = token
 
-	if bot.ChatApplication != "" {
-		log.Info().Msgf("looking for chat application '%#q'", bot.ChatApplication)
-
-		switch strings.ToLower(bot.ChatApplication) {
-		//nolint:goconst // refactor
-		case models.ChatAppDiscord:
-			// Discord bot token
-			token, err := text.Substitute(bot.DiscordToken, emptyMap)
-			if err != nil {
-				log.Error().Msgf("could not set 'discord_token': %s", err.Error())
-
-				bot.RunChat = false
-			}
-
-			bot.DiscordToken = token
-
-			// Discord Server ID
-			// See https://support.discordapp.com/hc/en-us/articles/206346498-Where-can-I-find-my-User-Server-Message-ID-
-			serverID, err := text.Substitute(bot.DiscordServerID, emptyMap)
-			if err != nil {
-				log.Error().Msgf("could not set 'discord_server_id': %s", err.Error())
-
-				bot.RunChat = false
-			}
-
-			bot.DiscordServerID = serverID
-
-			if !validation.IsSet(token, serverID) {
-				log.Error().Msg("bot is not configured correctly for discord - check that 'discord_token' and 'discord_server_id' are set")
-
-				bot.RunChat = false
-			}
-
-		//nolint:goconst // refactor
-		case models.ChatAppSlack:
-			configureSlackBot(bot)
+	for i, chatApp := range bot.ChatApplications {
+		chatApp = strings.ToLower(chatApp)
+		bot.ChatApplications[i] = chatApp
 
-		//nolint:goconst // refactor
-		case models.ChatAppMattermost:
-			log.Info().Msgf("configuring remote '%#q'", bot.ChatApplication)
-			token, err := text.Substitute(bot.MatterMostToken, emptyMap)
+		log.Info().Msgf("looking for chat application %#q", chatApp)
 
-			if err != nil {
-				log.Error().Msgf("could not set 'mattermost_token': %v", err.Error())
+		reg, ok := remote.Lookup(chatApp)
+		if !ok {
+			log.Error().Msgf("chat application %#q is not supported - supported chat applications: %s", chatApp, strings.Join(remote.Registered(), ", "))
 
-				bot.RunChat = false
-			}
-
-			bot.MatterMostToken = token
-
-			server, err := text.Substitute(bot.MatterMostServer, emptyMap)
-
-			if err != nil {
-				log.Error().Msgf("could not set 'mattermost_server': %v", err.Error())
-
-				bot.RunChat = false
-			}
-
-			bot.MatterMostServer = server
-
-			insc, err := text.Substitute(bot.MatterMostInsecureProtocol, emptyMap)
-			if err != nil {
-				log.Info().Msgf("could not retrieve insecure flag: '%v'", err.Error())
-			}
-
-			bot.MatterMostInsecureProtocol = insc
-
-		//nolint:goconst // refactor
-		case models.ChatAppTelegram:
-			token, err := text.Substitute(bot.TelegramToken, emptyMap)
-			if err != nil {
-				log.Error().Msgf("could not set 'telegram_token': %s", err.Error())
-
-				bot.RunChat = false
-			}
-
-			if !validation.IsSet(token) {
-				log.Error().Msg("bot is not configured correctly for telegram - check that 'telegram_token' is set")
-
-				bot.RunChat = false
-			}
+			bot.RunChat = false
 
-			bot.TelegramToken = token
+			continue
+		}
 
-		//nolint:goconst // refactor
-		case models.ChatAppGoogleChat:
-			gchat.Configure(bot)
+		err := reg.Configure(bot)
+		if err != nil {
+			log.Error().Msgf("could not configure chat application %#q: %v", chatApp, err)
 
-		default:
-			log.Error().Msgf("chat application %#q is not supported", bot.ChatApplication)
 			bot.RunChat = false
 		}
 	}
 }
 
-func configureSlackBot(bot *models.Bot) {
-	// emptyMap for substitute function
-	// (it will only replace from env vars)
-	emptyMap := map[string]string{}
-
-	// slack_token
-	token, err := text.Substitute(bot.SlackToken, emptyMap)
-	if err != nil {
-		log.Error().Msgf("could not set 'slack_token': %s", err.Error())
-	}
-
-	bot.SlackToken = token
-
-	// slack_app_token
-	appToken, err := text.Substitute(bot.SlackAppToken, emptyMap)
+// configureRedis substitutes environment variables in the 'redis_url',
+// which is shared by the redis store and the redis scheduler lock.
+func configureRedis(bot *models.Bot) {
+	url, err := text.Substitute(bot.RedisURL, map[string]string{})
 	if err != nil {
-		log.Warn().Msgf("could not set 'slack_app_token': %s", err.Error())
+		log.Warn().Msgf("could not set 'redis_url': %s", err.Error())
 	}
 
-	bot.SlackAppToken = appToken
-
-	// slack_signing_secret
-	signingSecret, err := text.Substitute(bot.SlackSigningSecret, emptyMap)
-	if err != nil {
-		log.Warn().Msgf("could not set 'slack_signing_secret': %s", err.Error())
-	}
-
-	bot.SlackSigningSecret = signingSecret
+	bot.RedisURL = url
+}
 
-	// slack_events_callback_path
-	eCallbackPath, err := text.Substitute(bot.SlackEventsCallbackPath, emptyMap)
-	if err != nil {
-		log.Warn().Msgf("could not set 'slack_events_callback_path': %s", err.Error())
+// configureStore sets up the key/value store used by rules,
+// falling back to an in-memory store if none is configured.
+func configureStore(bot *models.Bot) {
+	if bot.Store == "" {
+		return
 	}
 
-	bot.SlackEventsCallbackPath = eCallbackPath
-
-	// slack_interactions_callback_path
-	iCallbackPath, err := text.Substitute(bot.SlackInteractionsCallbackPath, emptyMap)
+	path, err := text.Substitute(bot.StorePath, map[string]string{})
 	if err != nil {
-		log.Warn().Msgf("could not set 'slack_interactions_callback_path': %s", err.Error())
+		log.Warn().Msgf("could not set 'store_path': %s", err.Error())
 	}
 
-	bot.SlackInteractionsCallbackPath = iCallbackPath
+	bot.StorePath = path
 
-	// slack_listener_port
-	lPort, err := text.Substitute(bot.SlackListenerPort, emptyMap)
+	s, err := store.New(bot.Store, bot)
 	if err != nil {
-		log.Warn().Msgf("could not set 'slack_listener_port': %s", err.Error())
-	}
-
-	// set slack http listener port from config file or default
-	if !validation.IsSet(lPort) {
-		log.Warn().Msgf("'slack_listener_port' not set: %#q", lPort)
-		log.Info().Str("defaultSlackListenerPort", defaultSlackListenerPort).Msg("using default slack listener port.")
-		lPort = defaultSlackListenerPort
+		log.Error().Msgf("could not configure store %#q, values will not be persisted: %v", bot.Store, err)
+		return
 	}
 
-	bot.SlackListenerPort = lPort
+	store.SetDefault(s)
 
-	// check for valid setup
-	// needs one of the following to be valid
-	// 1. SLACK_TOKEN + SLACK_APP_TOKEN (socket mode)
-	// 2. SLACK_TOKEN + SLACK_SIGNING_SECRET + SLACK_EVENTS_CALLBACK_PATH (events api)
-	isSocketMode := validation.IsSet(token, appToken)
-	isEventsAPI := validation.IsSet(token, signingSecret, eCallbackPath)
-
-	if !isSocketMode && !isEventsAPI {
-		log.Error().Msg("must have either 'slack_token', 'slack_app_token' or 'slack_token', 'slack_signing_secret', and 'slack_events_callback_path' set")
-
-		bot.RunChat = false
-	}
+	log.Info().Msgf("using store %#q", bot.Store)
 }
 
 func validateRemoteSetup(bot *models.Bot) {
-	if bot.ChatApplication != "" {
+	if len(bot.ChatApplications) > 0 {
 		bot.RunChat = true
 	}
 
@@ -220,22 +113,29 @@ func validateRemoteSetup(bot *models.Bot) {
 		bot.RunCLI = true
 	}
 
-	if !bot.CLI && bot.ChatApplication == "" {
+	if !bot.CLI && len(bot.ChatApplications) == 0 {
 		log.Error().Msgf("no 'chat_application' specified and cli mode is not enabled. exiting...")
 	}
 
 	if bot.Scheduler {
 		bot.RunScheduler = true
-		if bot.CLI && bot.ChatApplication == "" {
+		if bot.CLI && len(bot.ChatApplications) == 0 {
 			log.Warn().Msg("scheduler does not support scheduled outputs to cli mode")
 
 			bot.RunScheduler = false
 		}
 
-		if bot.ChatApplication == "" {
+		if len(bot.ChatApplications) == 0 {
 			log.Warn().Msg("scheduler did not find any configured chat applications - scheduler is closing")
 
 			bot.RunScheduler = false
 		}
 	}
 }
+
+// primaryChatApplication returns the first configured chat application,
+// which is used for messages that did not come from a chat application,
+// ie. scheduled messages.
+func primaryChatApplication(bot *models.Bot) string {
+	return bot.PrimaryChatApplication()
+}
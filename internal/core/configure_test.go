@@ -19,41 +19,41 @@ func Test_configureChatApplication(t *testing.T) {
 
 	testBotNoChat := new(models.Bot)
 	testBotNoChat.CLI = true
-	testBotNoChat.ChatApplication = ""
+	testBotNoChat.ChatApplications = nil
 	validateRemoteSetup(testBotNoChat)
 
 	testBotInvalidChat := new(models.Bot)
 	testBotInvalidChat.CLI = true
-	testBotInvalidChat.ChatApplication = "fart"
+	testBotInvalidChat.ChatApplications = []string{"fart"}
 	validateRemoteSetup(testBotInvalidChat)
 
 	testBotSlackNoToken := new(models.Bot)
 	testBotSlackNoToken.CLI = true
-	testBotSlackNoToken.ChatApplication = models.ChatAppSlack
+	testBotSlackNoToken.ChatApplications = []string{models.ChatAppSlack}
 	validateRemoteSetup(testBotSlackNoToken)
 
 	testBotBadName := new(models.Bot)
 	testBotBadName.CLI = true
-	testBotBadName.ChatApplication = models.ChatAppSlack
+	testBotBadName.ChatApplications = []string{models.ChatAppSlack}
 	testBotBadName.Name = "${BOT_NAME}"
 	validateRemoteSetup(testBotBadName)
 
 	testBotSlackBadToken := new(models.Bot)
 	testBotSlackBadToken.CLI = true
-	testBotSlackBadToken.ChatApplication = models.ChatAppSlack
+	testBotSlackBadToken.ChatApplications = []string{models.ChatAppSlack}
 	testBotSlackBadToken.SlackToken = "${TOKEN}"
 	validateRemoteSetup(testBotSlackBadToken)
 
 	testBotSlackBadSigningSecret := new(models.Bot)
 	testBotSlackBadSigningSecret.CLI = true
-	testBotSlackBadSigningSecret.ChatApplication = models.ChatAppSlack
+	testBotSlackBadSigningSecret.ChatApplications = []string{models.ChatAppSlack}
 	testBotSlackBadSigningSecret.SlackToken = "${TOKEN}"
 	testBotSlackBadSigningSecret.SlackSigningSecret = "${TEST_BAD_SIGNING_SECRET}"
 	validateRemoteSetup(testBotSlackBadSigningSecret)
 
 	testBotSlack := new(models.Bot)
 	testBotSlack.CLI = true
-	testBotSlack.ChatApplication = models.ChatAppSlack
+	testBotSlack.ChatApplications = []string{models.ChatAppSlack}
 	testBotSlack.SlackToken = "${TEST_SLACK_TOKEN}"
 	testBotSlack.SlackAppToken = "${TEST_SLACK_APP_TOKEN}"
 
@@ -64,18 +64,18 @@ func Test_configureChatApplication(t *testing.T) {
 
 	testBotDiscordNoToken := new(models.Bot)
 	testBotDiscordNoToken.CLI = true
-	testBotDiscordNoToken.ChatApplication = models.ChatAppDiscord
+	testBotDiscordNoToken.ChatApplications = []string{models.ChatAppDiscord}
 	validateRemoteSetup(testBotDiscordNoToken)
 
 	testBotDiscordBadToken := new(models.Bot)
 	testBotDiscordBadToken.CLI = true
-	testBotDiscordBadToken.ChatApplication = models.ChatAppDiscord
+	testBotDiscordBadToken.ChatApplications = []string{models.ChatAppDiscord}
 	testBotDiscordBadToken.DiscordToken = "${TOKEN}"
 	validateRemoteSetup(testBotDiscordBadToken)
 
 	testBotDiscordServerID := new(models.Bot)
 	testBotDiscordServerID.CLI = true
-	testBotDiscordServerID.ChatApplication = models.ChatAppDiscord
+	testBotDiscordServerID.ChatApplications = []string{models.ChatAppDiscord}
 	testBotDiscordServerID.DiscordToken = "${TEST_DISCORD_TOKEN}"
 	testBotDiscordServerID.DiscordServerID = "${TEST_DISCORD_SERVER_ID}"
 
@@ -86,7 +86,7 @@ func Test_configureChatApplication(t *testing.T) {
 
 	testBotDiscordBadServerID := new(models.Bot)
 	testBotDiscordBadServerID.CLI = true
-	testBotDiscordBadServerID.ChatApplication = models.ChatAppDiscord
+	testBotDiscordBadServerID.ChatApplications = []string{models.ChatAppDiscord}
 	testBotDiscordBadServerID.DiscordToken = "${TEST_DISCORD_TOKEN}"
 	testBotDiscordBadServerID.DiscordServerID = "${TOKEN}"
 
@@ -94,7 +94,7 @@ func Test_configureChatApplication(t *testing.T) {
 
 	testBotTelegram := new(models.Bot)
 	testBotTelegram.CLI = true
-	testBotTelegram.ChatApplication = models.ChatAppTelegram
+	testBotTelegram.ChatApplications = []string{models.ChatAppTelegram}
 	testBotTelegram.TelegramToken = "${TEST_TELEGRAM_TOKEN}"
 
 	t.Setenv("TEST_TELEGRAM_TOKEN", "TESTTOKEN")
@@ -103,12 +103,12 @@ func Test_configureChatApplication(t *testing.T) {
 
 	testBotTelegramNoToken := new(models.Bot)
 	testBotTelegramNoToken.CLI = true
-	testBotTelegramNoToken.ChatApplication = models.ChatAppTelegram
+	testBotTelegramNoToken.ChatApplications = []string{models.ChatAppTelegram}
 	validateRemoteSetup(testBotTelegramNoToken)
 
 	testBotTelegramBadToken := new(models.Bot)
 	testBotTelegramBadToken.CLI = true
-	testBotTelegramBadToken.ChatApplication = models.ChatAppTelegram
+	testBotTelegramBadToken.ChatApplications = []string{models.ChatAppTelegram}
 	testBotTelegramBadToken.TelegramToken = "${TOKEN}"
 	validateRemoteSetup(testBotTelegramBadToken)
 
@@ -151,7 +151,7 @@ func Test_setSlackListenerPort(t *testing.T) {
 	baseBot := func() *models.Bot {
 		bot := new(models.Bot)
 		bot.CLI = true
-		bot.ChatApplication = models.ChatAppSlack
+		bot.ChatApplications = []string{models.ChatAppSlack}
 		bot.SlackToken = "${TEST_SLACK_TOKEN}"
 		bot.SlackInteractionsCallbackPath = "${TEST_SLACK_INTERACTIONS_CALLBACK_PATH}"
 
@@ -180,7 +180,7 @@ func Test_setSlackListenerPort(t *testing.T) {
 		validateRemoteSetup(bot)
 		configureChatApplication(bot)
 
-		expected := defaultSlackListenerPort
+		expected := "3000" // default slack listener port
 		actual := bot.SlackListenerPort
 
 		if expected != actual {
@@ -194,7 +194,7 @@ func Test_setSlackListenerPort(t *testing.T) {
 		validateRemoteSetup(bot)
 		configureChatApplication(bot)
 
-		expected := defaultSlackListenerPort
+		expected := "3000" // default slack listener port
 		actual := bot.SlackListenerPort
 
 		if expected != actual {
@@ -215,20 +215,20 @@ func Test_validateRemoteSetup(t *testing.T) {
 
 	testBotCLIChat := new(models.Bot)
 	testBotCLIChat.CLI = true
-	testBotCLIChat.ChatApplication = models.ChatAppSlack
+	testBotCLIChat.ChatApplications = []string{models.ChatAppSlack}
 
 	testBotCLIChatScheduler := new(models.Bot)
 	testBotCLIChatScheduler.CLI = true
-	testBotCLIChatScheduler.ChatApplication = models.ChatAppSlack
+	testBotCLIChatScheduler.ChatApplications = []string{models.ChatAppSlack}
 	testBotCLIChatScheduler.Scheduler = true
 
 	testBotChatScheduler := new(models.Bot)
-	testBotChatScheduler.ChatApplication = models.ChatAppSlack
+	testBotChatScheduler.ChatApplications = []string{models.ChatAppSlack}
 	testBotChatScheduler.Scheduler = true
 
 	testBotCLIChatSchedulerFail := new(models.Bot)
 	testBotCLIChatSchedulerFail.CLI = true
-	testBotCLIChatSchedulerFail.ChatApplication = ""
+	testBotCLIChatSchedulerFail.ChatApplications = nil
 	testBotCLIChatSchedulerFail.Scheduler = true
 
 	testBotCLIScheduler := new(models.Bot)
@@ -237,7 +237,7 @@ func Test_validateRemoteSetup(t *testing.T) {
 
 	testNoChatNoCLI := new(models.Bot)
 	testNoChatNoCLI.CLI = false
-	testNoChatNoCLI.ChatApplication = ""
+	testNoChatNoCLI.ChatApplications = nil
 
 	tests := []struct {
 		name               string
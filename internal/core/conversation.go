@@ -0,0 +1,295 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mohae/deepcopy"
+	"github.com/rs/zerolog/log"
+
+	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/text"
+)
+
+var (
+	defaultConversationTimeout = 5 * time.Minute
+	defaultCancelKeyword       = "cancel"
+)
+
+// conversation keeps track of a rule that is waiting for the answers of a user.
+type conversation struct {
+	rule    models.Rule
+	message models.Message // the message that started the conversation, collecting the answers as vars
+	steps   []models.Step  // the remaining questions, the first one is waiting for an answer
+	timer   *time.Timer
+}
+
+// conversationManager keeps track of the active conversations, there is
+// at most one conversation per user in a channel or thread.
+type conversationManager struct {
+	mu      sync.Mutex
+	active  map[string]*conversation
+	expired sync.WaitGroup // replies of expired conversations that are being sent
+	workers *workerPool    // runs the rule once all questions were answered
+}
+
+func newConversationManager(workers *workerPool) *conversationManager {
+	return &conversationManager{
+		active:  make(map[string]*conversation),
+		workers: workers,
+	}
+}
+
+// conversationKey identifies the conversation of the user that sent the message
+// in the channel or thread the message was sent in.
+func conversationKey(message models.Message) string {
+	user := message.Vars["_user.id"]
+	if user == "" {
+		user = message.Vars["_user.name"]
+	}
+
+	return strings.Join([]string{message.Remote, message.ChannelID, message.ThreadTimestamp, user}, "|")
+}
+
+// conversationSteps returns the questions to ask before the actions of the rule can run;
+// a question for each missing required argument (if 'prompt_for_args' is set), followed by the rule's steps.
+func conversationSteps(rule models.Rule, processedInput string) []models.Step {
+	steps := []models.Step{}
+
+	if rule.PromptForArgs && rule.Respond != "" {
+		// the flags were already checked when the rule was hit
+		args, _, _ := parseFlags(rule, text.RuleArgTokenizer(processedInput), "", nil)
+		supplied := len(args)
+
+		for index, arg := range rule.Args {
+			if index < supplied || arg.Optional || arg.Default != "" {
+				continue
+			}
+
+			question := fmt.Sprintf("what's the value for %#q?", arg.Name)
+			if arg.Description != "" {
+				question = fmt.Sprintf("what's the value for %#q (%s)?", arg.Name, arg.Description)
+			}
+
+			steps = append(steps, models.Step{
+				Prompt: question,
+				Var:    arg.Name,
+			})
+		}
+	}
+
+	return append(steps, rule.Steps...)
+}
+
+// promptedArg returns the argument of the rule the step asks for, if any.
+func promptedArg(rule models.Rule, step models.Step) (models.Arg, bool) {
+	if !rule.PromptForArgs {
+		return models.Arg{}, false
+	}
+
+	for _, arg := range rule.Args {
+		if arg.Name == step.Var {
+			return arg, true
+		}
+	}
+
+	return models.Arg{}, false
+}
+
+// start begins a conversation for the hit rule and asks the first question.
+func (cm *conversationManager) start(outputMsgs chan<- models.Envelope, message models.Message, rule models.Rule, steps []models.Step) {
+	// follow-up questions go to the same thread the rule's output would go to
+	if rule.StartMessageThread && message.ThreadTimestamp == "" {
+		message.ThreadTimestamp = message.Timestamp
+	}
+
+	key := conversationKey(message)
+	conv := &conversation{rule: rule, message: message, steps: steps}
+
+	cm.mu.Lock()
+	// a new conversation replaces an unfinished one
+	if prev, ok := cm.active[key]; ok {
+		prev.timer.Stop()
+	}
+
+	cm.active[key] = conv
+	conv.timer = time.AfterFunc(conversationTimeout(rule), func() {
+		cm.expire(outputMsgs, key, conv)
+	})
+
+	env := prompt(conv, "")
+	cm.mu.Unlock()
+
+	log.Info().Msgf("started conversation for rule %#q", rule.Name)
+
+	outputMsgs <- env
+}
+
+// handle processes the message as an answer, if the user has an active conversation.
+// It returns false if there is no active conversation for the message.
+func (cm *conversationManager) handle(outputMsgs chan<- models.Envelope, message models.Message, bot *models.Bot) bool {
+	// the conversation is updated under the lock, replies are sent once it is released
+	env, run, ok := cm.answer(message, bot)
+	if !ok {
+		return false
+	}
+
+	if run {
+		cm.workers.runRule(outputMsgs, env.Message, env.Rule, bot)
+		return true
+	}
+
+	outputMsgs <- env
+
+	return true
+}
+
+// answer takes the message as answer to the current question of the conversation of the user.
+// It returns the reply to send, or the message to run the rule with once all questions were answered.
+func (cm *conversationManager) answer(message models.Message, bot *models.Bot) (env models.Envelope, run, ok bool) {
+	key := conversationKey(message)
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	conv, ok := cm.active[key]
+	if !ok {
+		return models.Envelope{}, false, false
+	}
+
+	answer := strings.TrimSpace(message.Input)
+	step := conv.steps[0]
+
+	// the user gave up
+	if strings.EqualFold(answer, cancelKeyword(conv.rule)) {
+		cm.stop(key, conv)
+
+		return reply(conv, fmt.Sprintf("ok, %#q was canceled.", conv.rule.Name)), false, true
+	}
+
+	if !step.Accepts(answer) {
+		errMsg := step.Error
+		if errMsg == "" {
+			errMsg = fmt.Sprintf("%#q is not a valid answer.", answer)
+		}
+
+		conv.timer.Reset(conversationTimeout(conv.rule))
+
+		return prompt(conv, errMsg+" "), false, true
+	}
+
+	// answers for the rule's arguments have to match their declaration
+	if arg, ok := promptedArg(conv.rule, step); ok {
+		value, err := convertArg(arg, answer, conv.message.Remote, bot)
+		if err != nil {
+			conv.timer.Reset(conversationTimeout(conv.rule))
+
+			return prompt(conv, fmt.Sprintf("%#q is not a valid answer: %v. ", answer, err)), false, true
+		}
+
+		answer = value
+	}
+
+	conv.message.Vars[step.Var] = answer
+	conv.steps = conv.steps[1:]
+
+	// more questions to ask
+	if len(conv.steps) > 0 {
+		conv.timer.Reset(conversationTimeout(conv.rule))
+
+		return prompt(conv, ""), false, true
+	}
+
+	// all questions were answered, run the rule
+	cm.stop(key, conv)
+
+	log.Info().Msgf("finished conversation for rule %#q", conv.rule.Name)
+
+	msg := deepcopy.Copy(conv.message).(models.Message)
+
+	return models.Envelope{Message: msg, Rule: conv.rule}, true, true
+}
+
+// expire ends the conversation when the user did not answer in time.
+func (cm *conversationManager) expire(outputMsgs chan<- models.Envelope, key string, conv *conversation) {
+	cm.mu.Lock()
+
+	// the conversation may have finished or been replaced in the meantime
+	if cm.active[key] != conv {
+		cm.mu.Unlock()
+		return
+	}
+
+	cm.stop(key, conv)
+
+	env := reply(conv, fmt.Sprintf("i stopped waiting for an answer, %#q was canceled.", conv.rule.Name))
+
+	cm.expired.Add(1)
+	defer cm.expired.Done()
+
+	cm.mu.Unlock()
+
+	log.Info().Msgf("conversation for rule %#q timed out", conv.rule.Name)
+
+	outputMsgs <- env
+}
+
+// stop removes the conversation, the caller must hold the lock.
+func (cm *conversationManager) stop(key string, conv *conversation) {
+	conv.timer.Stop()
+	delete(cm.active, key)
+}
+
+// stopAll ends all conversations without a reply, ie. when the bot shuts down.
+// It waits for the replies of conversations that expired just before to be sent.
+func (cm *conversationManager) stopAll() {
+	cm.mu.Lock()
+
+	for key, conv := range cm.active {
+		cm.stop(key, conv)
+	}
+
+	cm.mu.Unlock()
+
+	cm.expired.Wait()
+}
+
+// prompt returns the question of the current step, the caller must hold the lock.
+func prompt(conv *conversation, prefix string) models.Envelope {
+	question, err := text.Substitute(conv.steps[0].Prompt, conv.message.Vars)
+	if err != nil {
+		log.Warn().Msgf("unable to substitute variables in prompt for var %#q: %v", conv.steps[0].Var, err)
+	}
+
+	return reply(conv, fmt.Sprintf("%s%s (say %#q to stop)", prefix, question, cancelKeyword(conv.rule)))
+}
+
+// reply returns a message to where the conversation takes place, the caller must hold the lock.
+func reply(conv *conversation, output string) models.Envelope {
+	msg := deepcopy.Copy(conv.message).(models.Message)
+	msg.Output = output
+
+	return models.Envelope{Message: msg, Rule: conv.rule, Ops: models.OpSend}
+}
+
+// conversationTimeout returns how long to wait for an answer.
+func conversationTimeout(rule models.Rule) time.Duration {
+	if rule.ConversationTimeout > 0 {
+		return rule.ConversationTimeout
+	}
+
+	return defaultConversationTimeout
+}
+
+// cancelKeyword returns the answer that ends the conversation.
+func cancelKeyword(rule models.Rule) string {
+	if rule.CancelKeyword != "" {
+		return rule.CancelKeyword
+	}
+
+	return defaultCancelKeyword
+}
@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+func Test_conversationSteps(t *testing.T) {
+	steps := []models.Step{{Prompt: "which environment?", Var: "env"}}
+
+	tests := []struct {
+		name           string
+		rule           models.Rule
+		processedInput string
+		wantVars       []string
+	}{
+		{"No steps", models.Rule{Respond: "deploy", Args: models.NewArgs("app")}, "", []string{}},
+		{"Missing arg without prompting", models.Rule{Respond: "deploy", Args: models.NewArgs("app"), Steps: steps}, "", []string{"env"}},
+		{"Missing arg", models.Rule{Respond: "deploy", Args: models.NewArgs("app"), PromptForArgs: true}, "", []string{"app"}},
+		{"Missing args and steps", models.Rule{Respond: "deploy", Args: models.NewArgs("app", "version?", "notes+"), PromptForArgs: true, Steps: steps}, "", []string{"app", "notes", "env"}},
+		{"Supplied args", models.Rule{Respond: "deploy", Args: models.NewArgs("app", "notes+"), PromptForArgs: true}, "flottbot", []string{"notes"}},
+		{"All args supplied", models.Rule{Respond: "deploy", Args: models.NewArgs("app"), PromptForArgs: true}, "flottbot", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := conversationSteps(tt.rule, tt.processedInput)
+
+			gotVars := []string{}
+			for _, step := range got {
+				gotVars = append(gotVars, step.Var)
+			}
+
+			if strings.Join(gotVars, ",") != strings.Join(tt.wantVars, ",") {
+				t.Errorf("conversationSteps() = %v, want %v", gotVars, tt.wantVars)
+			}
+		})
+	}
+}
+
+func Test_conversationManager(t *testing.T) {
+	outputMsgs := make(chan models.Envelope, 10)
+	bot := new(models.Bot)
+
+	rule := models.Rule{
+		Name:          "deploy",
+		Respond:       "deploy",
+		Args:          models.NewArgs("app"),
+		PromptForArgs: true,
+		FormatOutput:  "deploying ${app} to ${env}",
+		Steps: []models.Step{
+			{Prompt: "which environment for ${app}?", Var: "env", Validate: "^(dev|prod)$", Error: "try dev or prod."},
+		},
+	}
+
+	newMessage := func(user, input string) models.Message {
+		msg := models.NewMessage()
+		msg.Service = models.MsgServiceChat
+		msg.ChannelID = "C123"
+		msg.Input = input
+		msg.Vars["_user.id"] = user
+
+		return msg
+	}
+
+	expectOutput := func(want string) {
+		t.Helper()
+
+		select {
+		case env := <-outputMsgs:
+			msg := env.Message
+
+			if !strings.HasPrefix(msg.Output, want) {
+				t.Errorf("expected output %#q, got %#q", want, msg.Output)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected output %#q, got none", want)
+		}
+	}
+
+	workers := newWorkerPool(1, 10)
+	cm := newConversationManager(workers)
+
+	cm.start(outputMsgs, newMessage("U1", "deploy"), rule, conversationSteps(rule, ""))
+	expectOutput("what's the value for `app`?")
+
+	// other users and channels are not part of the conversation
+	if cm.handle(outputMsgs, newMessage("U2", "flottbot"), bot) {
+		t.Errorf("handle() expected message of another user to be ignored")
+	}
+
+	cm.handle(outputMsgs, newMessage("U1", "flottbot"), bot)
+	expectOutput("which environment for flottbot?")
+
+	cm.handle(outputMsgs, newMessage("U1", "staging"), bot)
+	expectOutput("try dev or prod. which environment for flottbot?")
+
+	cm.handle(outputMsgs, newMessage("U1", "prod"), bot)
+	expectOutput("deploying flottbot to prod")
+
+	if cm.handle(outputMsgs, newMessage("U1", "prod"), bot) {
+		t.Errorf("handle() expected conversation to be finished")
+	}
+
+	// canceling a conversation
+	cm.start(outputMsgs, newMessage("U1", "deploy"), rule, conversationSteps(rule, ""))
+	expectOutput("what's the value for `app`?")
+
+	cm.handle(outputMsgs, newMessage("U1", "CANCEL"), bot)
+	expectOutput("ok, `deploy` was canceled.")
+
+	// timing out a conversation
+	rule.ConversationTimeout = 100 * time.Millisecond
+
+	cm.start(outputMsgs, newMessage("U1", "deploy"), rule, conversationSteps(rule, ""))
+	expectOutput("what's the value for `app`?")
+
+	select {
+	case env := <-outputMsgs:
+		msg := env.Message
+
+		if !strings.HasPrefix(msg.Output, "i stopped waiting") {
+			t.Errorf("expected timeout output, got %#q", msg.Output)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("expected conversation to time out")
+	}
+
+	if cm.handle(outputMsgs, newMessage("U1", "flottbot"), bot) {
+		t.Errorf("handle() expected conversation to be timed out")
+	}
+
+	// a reply that can't be sent yet doesn't hold up the conversations of others
+	rule.ConversationTimeout = 0
+	blocked := make(chan models.Envelope)
+
+	cm.start(outputMsgs, newMessage("U1", "deploy"), rule, conversationSteps(rule, ""))
+	expectOutput("what's the value for `app`?")
+
+	go cm.handle(blocked, newMessage("U1", "flottbot"), bot)
+
+	cm.start(outputMsgs, newMessage("U2", "deploy"), rule, conversationSteps(rule, ""))
+	expectOutput("what's the value for `app`?")
+
+	if env := <-blocked; !strings.HasPrefix(env.Message.Output, "which environment for flottbot?") {
+		t.Errorf("expected output %#q, got %#q", "which environment for flottbot?", env.Message.Output)
+	}
+
+	cm.stopAll()
+	workers.stop()
+}
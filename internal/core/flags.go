@@ -0,0 +1,235 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+var shortFlagRegex = regexp.MustCompile(`^[A-Za-z]$`)
+
+// validateFlags checks the flag declarations of a rule.
+func validateFlags(flags []models.Flag) error {
+	var (
+		errs  []error
+		names = map[string]bool{}
+	)
+
+	for i, flag := range flags {
+		if flag.Name == "" || strings.HasPrefix(flag.Name, "-") || strings.ContainsAny(flag.Name, " =") {
+			errs = append(errs, fmt.Errorf("flag %d has an invalid name %#q", i+1, flag.Name))
+			continue
+		}
+
+		if names[flag.Name] {
+			errs = append(errs, fmt.Errorf("flag %#q is declared more than once", flag.Name))
+		}
+
+		names[flag.Name] = true
+
+		if flag.Short != "" {
+			if !shortFlagRegex.MatchString(flag.Short) {
+				errs = append(errs, fmt.Errorf("flag %#q has an invalid short name %#q, use a single letter", flag.Name, flag.Short))
+			} else if names["-"+flag.Short] {
+				errs = append(errs, fmt.Errorf("short name %#q of flag %#q is already used", flag.Short, flag.Name))
+			}
+
+			names["-"+flag.Short] = true
+		}
+
+		if flag.IsBool() && flag.Repeated {
+			errs = append(errs, fmt.Errorf("flag %#q of type 'bool' cannot be repeated", flag.Name))
+		}
+
+		// flags share their types with args
+		arg := flagArg(flag)
+		arg.Default = flag.Default
+
+		errs = append(errs, validateArgType("flag", arg)...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// flagArg returns the flag as argument, to convert its values.
+func flagArg(flag models.Flag) models.Arg {
+	return models.Arg{Name: flag.Name, Type: flag.Type, Values: flag.Values, Pattern: flag.Pattern}
+}
+
+// parseFlags separates the flags from the positional values supplied by the user, if the rule declares flags.
+// Flags are given as '--name value', '--name=value', '-s value' or '-s=value'; boolean flags take no value,
+// but accept '--name=false', and short ones can be combined, ie. '-fv'. Everything after '--' is positional.
+// The flags are returned as 'flag.<name>' variables, flags that weren't given get their default value.
+func parseFlags(rule models.Rule, values []string, chatApp string, bot *models.Bot) ([]string, map[string]string, error) {
+	if len(rule.Flags) == 0 {
+		return values, map[string]string{}, nil
+	}
+
+	positional := []string{}
+	given := map[string][]string{}
+
+	set := func(flag models.Flag, token, value string) error {
+		if !flag.Repeated && len(given[flag.Name]) > 0 {
+			return usageError(rule, fmt.Sprintf("flag %#q can only be given once", token))
+		}
+
+		converted, err := convertArg(flagArg(flag), value, chatApp, bot)
+		if err != nil {
+			return usageError(rule, fmt.Sprintf("invalid value %#q for flag %#q: %v", value, token, err))
+		}
+
+		given[flag.Name] = append(given[flag.Name], converted)
+
+		return nil
+	}
+
+	for i := 0; i < len(values); i++ {
+		token := values[i]
+
+		if token == "--" {
+			positional = append(positional, values[i+1:]...)
+			break
+		}
+
+		if !isFlag(rule, token) {
+			positional = append(positional, token)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(token, "=")
+
+		var flags []models.Flag
+
+		if strings.HasPrefix(name, "--") {
+			flag, ok := lookupFlag(rule, strings.TrimPrefix(name, "--"), false)
+			if !ok {
+				return nil, nil, usageError(rule, fmt.Sprintf("unknown flag %#q", name))
+			}
+
+			flags = append(flags, flag)
+		} else {
+			// combined short flags, ie. '-fv', have to be booleans, except the last one
+			for _, short := range strings.TrimPrefix(name, "-") {
+				flag, ok := lookupFlag(rule, string(short), true)
+				if !ok {
+					return nil, nil, usageError(rule, fmt.Sprintf("unknown flag %#q", "-"+string(short)))
+				}
+
+				flags = append(flags, flag)
+			}
+		}
+
+		for j, flag := range flags {
+			last := j == len(flags)-1
+
+			switch {
+			case flag.IsBool() && !(last && hasValue):
+				value = "true"
+			case !last:
+				return nil, nil, usageError(rule, fmt.Sprintf("flag %#q needs a value and cannot be combined", "-"+flag.Short))
+			case !hasValue:
+				if i+1 >= len(values) {
+					return nil, nil, usageError(rule, fmt.Sprintf("flag %#q needs a value", name))
+				}
+
+				i++
+				value = values[i]
+			}
+
+			if err := set(flag, name, value); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	vars := map[string]string{}
+
+	for _, flag := range rule.Flags {
+		switch {
+		case len(given[flag.Name]) > 0:
+			vars["flag."+flag.Name] = strings.Join(given[flag.Name], ",")
+		case flag.Default != "":
+			vars["flag."+flag.Name] = flag.Default
+		case flag.IsBool():
+			vars["flag."+flag.Name] = "false"
+		default:
+			vars["flag."+flag.Name] = ""
+		}
+	}
+
+	return positional, vars, nil
+}
+
+// isFlag tells whether the token is a flag, negative numbers are values unless there is a matching short flag.
+func isFlag(rule models.Rule, token string) bool {
+	if len(token) < 2 || !strings.HasPrefix(token, "-") {
+		return false
+	}
+
+	if _, err := strconv.ParseFloat(token, 64); err == nil {
+		_, ok := lookupFlag(rule, token[1:2], true)
+		return ok
+	}
+
+	return true
+}
+
+// lookupFlag finds the declared flag by its name or short name.
+func lookupFlag(rule models.Rule, name string, short bool) (models.Flag, bool) {
+	i := slices.IndexFunc(rule.Flags, func(flag models.Flag) bool {
+		if short {
+			return flag.Short == name
+		}
+
+		return flag.Name == name
+	})
+	if i < 0 {
+		return models.Flag{}, false
+	}
+
+	return rule.Flags[i], true
+}
+
+// flagsUsage generates the usage of the flags of a rule, ie. '[--env|-e <dev|qa|prod>] [--dry-run]'.
+func flagsUsage(rule models.Rule) []string {
+	usage := make([]string, 0, len(rule.Flags))
+
+	for _, flag := range rule.Flags {
+		name := "--" + flag.Name
+		if flag.Short != "" {
+			name += "|-" + flag.Short
+		}
+
+		if !flag.IsBool() {
+			value := argType(flagArg(flag))
+
+			switch value {
+			case models.ArgTypeString:
+				value = flag.Name
+			case models.ArgTypeEnum:
+				value = strings.Join(flag.Values, "|")
+			}
+
+			if flag.Default != "" {
+				value += "=" + flag.Default
+			}
+
+			name += " <" + value + ">"
+		}
+
+		if flag.Repeated {
+			name += "..."
+		}
+
+		usage = append(usage, "["+name+"]")
+	}
+
+	return usage
+}
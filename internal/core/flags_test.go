@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+var testFlags = []models.Flag{
+	{Name: "env", Short: "e", Type: "enum", Values: []string{"dev", "prod"}, Default: "dev", Description: "where to deploy"},
+	{Name: "dry-run", Short: "n", Type: "bool"},
+	{Name: "verbose", Short: "v", Type: "bool"},
+	{Name: "retries", Short: "r", Type: "int"},
+	{Name: "tag", Short: "t", Repeated: true},
+}
+
+func Test_validateFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   []models.Flag
+		wantErr bool
+	}{
+		{"Valid", testFlags, false},
+		{"No name", []models.Flag{{Short: "e"}}, true},
+		{"Dashes in name", []models.Flag{{Name: "--env"}}, true},
+		{"Duplicate name", []models.Flag{{Name: "env"}, {Name: "env"}}, true},
+		{"Long short name", []models.Flag{{Name: "env", Short: "en"}}, true},
+		{"Duplicate short name", []models.Flag{{Name: "env", Short: "e"}, {Name: "echo", Short: "e"}}, true},
+		{"Repeated bool", []models.Flag{{Name: "verbose", Type: "bool", Repeated: true}}, true},
+		{"Unknown type", []models.Flag{{Name: "retries", Type: "number"}}, true},
+		{"Invalid default", []models.Flag{{Name: "retries", Type: "int", Default: "many"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateFlags(tt.flags); (err != nil) != tt.wantErr {
+				t.Errorf("validateFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_parseFlags(t *testing.T) {
+	rule := models.Rule{Respond: "deploy", Args: models.NewArgs("app"), Flags: testFlags}
+	defaults := map[string]string{"flag.env": "dev", "flag.dry-run": "false", "flag.verbose": "false", "flag.retries": "", "flag.tag": ""}
+
+	withDefaults := func(vars map[string]string) map[string]string {
+		out := maps.Clone(defaults)
+		maps.Copy(out, vars)
+
+		return out
+	}
+
+	tests := []struct {
+		name           string
+		rule           models.Rule
+		values         []string
+		wantPositional []string
+		wantVars       map[string]string
+		wantErr        string
+	}{
+		{"No flags declared", models.Rule{Respond: "deploy"}, []string{"api", "--env", "prod"}, []string{"api", "--env", "prod"}, map[string]string{}, ""},
+		{"Defaults", rule, []string{"api"}, []string{"api"}, withDefaults(nil), ""},
+		{"Long flags", rule, []string{"api", "--env", "prod", "--dry-run", "--retries=3"}, []string{"api"}, withDefaults(map[string]string{"flag.env": "prod", "flag.dry-run": "true", "flag.retries": "3"}), ""},
+		{"Short flags", rule, []string{"-e", "prod", "api", "-r", "3"}, []string{"api"}, withDefaults(map[string]string{"flag.env": "prod", "flag.retries": "3"}), ""},
+		{"Combined short flags", rule, []string{"api", "-nvr", "3"}, []string{"api"}, withDefaults(map[string]string{"flag.dry-run": "true", "flag.verbose": "true", "flag.retries": "3"}), ""},
+		{"Bool with value", rule, []string{"api", "--dry-run=no"}, []string{"api"}, withDefaults(nil), ""},
+		{"Repeated", rule, []string{"api", "-t", "a", "--tag", "b"}, []string{"api"}, withDefaults(map[string]string{"flag.tag": "a,b"}), ""},
+		{"Negative number", rule, []string{"-5"}, []string{"-5"}, withDefaults(nil), ""},
+		{"End of flags", rule, []string{"api", "--", "--env"}, []string{"api", "--env"}, withDefaults(nil), ""},
+		{"Unknown flag", rule, []string{"api", "--force"}, nil, nil, "unknown flag `--force`\n```deploy <app> [--env|-e <dev|prod=dev>] [--dry-run|-n] [--verbose|-v] [--retries|-r <int>] [--tag|-t <tag>...]```\n • `--env`: where to deploy"},
+		{"Unknown short flag", rule, []string{"api", "-nx"}, nil, nil, "unknown flag `-x`"},
+		{"Missing value", rule, []string{"api", "--env"}, nil, nil, "flag `--env` needs a value"},
+		{"Value in combined flags", rule, []string{"api", "-rn", "3"}, nil, nil, "flag `-r` needs a value and cannot be combined"},
+		{"Invalid value", rule, []string{"api", "-r", "x"}, nil, nil, "invalid value `x` for flag `-r`: expected an integer"},
+		{"Not repeated", rule, []string{"api", "-e", "dev", "--env", "prod"}, nil, nil, "flag `--env` can only be given once"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			positional, vars, err := parseFlags(tt.rule, tt.values, "", nil)
+			if err != nil {
+				if tt.wantErr == "" || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("parseFlags() error = %q, want %q", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if tt.wantErr != "" {
+				t.Fatalf("parseFlags() expected error %q", tt.wantErr)
+			}
+
+			if !slices.Equal(positional, tt.wantPositional) {
+				t.Errorf("parseFlags() positional = %v, want %v", positional, tt.wantPositional)
+			}
+
+			if !reflect.DeepEqual(vars, tt.wantVars) {
+				t.Errorf("parseFlags() vars = %v, want %v", vars, tt.wantVars)
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/target/flottbot/internal/auth"
+	"github.com/target/flottbot/internal/models"
+)
+
+// defaultHelpCategory is the category of rules that don't declare one.
+const defaultHelpCategory = "general"
+
+// helpRequest returns the search term if the message asks the bot for help, ie. 'help' or 'help deploy'.
+func helpRequest(message models.Message) (string, bool) {
+	if message.Type != models.MsgTypeDirect && !message.BotMentioned {
+		return "", false
+	}
+
+	fields := strings.Fields(message.Input)
+	if len(fields) == 0 || !strings.EqualFold(fields[0], "help") {
+		return "", false
+	}
+
+	return strings.Join(fields[1:], " "), true
+}
+
+// handleHelp answers a request for help, an overview without a search term,
+// otherwise the details of a command, the commands of a category or the commands matching the term.
+func handleHelp(outputMsgs chan<- models.Envelope, message models.Message, rules map[string]models.Rule, term string, bot *models.Bot) {
+	log.Info().Msgf("showing help for %#q", term)
+
+	Prommetric(bot.Name+"-Help", bot)
+
+	visible := helpRules(message, rules, bot)
+
+	output := helpOverview(visible, bot)
+	if term != "" {
+		output = helpSearch(visible, term)
+	}
+
+	sendHelp(outputMsgs, message, output, bot)
+}
+
+// helpRules returns the rules to show in help, those that are active, included in help,
+// have a help text or usage, and that the user who asked is allowed to run.
+func helpRules(message models.Message, rules map[string]models.Rule, bot *models.Bot) []models.Rule {
+	chatApp := message.Remote
+	if chatApp == "" {
+		chatApp = primaryChatApplication(bot)
+	}
+
+	// the groups of the user are looked up once for all rules
+	membership := auth.NewMembership(chatApp, message.Vars["_user.name"], message.Vars["_user.id"], bot)
+	visible := []models.Rule{}
+
+	for _, rule := range sortRules(rules) {
+		// 'hear' rules don't show in help
+		if !rule.Active || rule.Hear != "" || !rule.IncludeInHelp || ruleUsage(rule) == "" {
+			continue
+		}
+
+		if !membership.CanTrigger(rule) {
+			continue
+		}
+
+		visible = append(visible, rule)
+	}
+
+	return visible
+}
+
+// helpCategory returns the category of the rule.
+func helpCategory(rule models.Rule) string {
+	if rule.Category == "" {
+		return defaultHelpCategory
+	}
+
+	return rule.Category
+}
+
+// helpOverview lists the commands, grouped by category if any rule declares one.
+func helpOverview(rules []models.Rule, bot *models.Bot) string {
+	// Set custom_help_text if it is set in bot.yml
+	if bot.CustomHelpText != "" {
+		return bot.CustomHelpText
+	}
+
+	helpMsg := "I understand these commands: \n"
+	if bot.CustomHelpTextPrefix != "" {
+		helpMsg = bot.CustomHelpTextPrefix + "\n"
+	}
+
+	categorized := slices.ContainsFunc(rules, func(rule models.Rule) bool { return rule.Category != "" })
+	if !categorized {
+		for _, rule := range rules {
+			helpMsg += fmt.Sprintf("\n • %s", ruleUsage(rule))
+		}
+
+		return helpMsg
+	}
+
+	categories := map[string][]string{}
+
+	for _, rule := range rules {
+		category := helpCategory(rule)
+		categories[category] = append(categories[category], fmt.Sprintf("%#q", helpName(rule)))
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(categories)) {
+		helpMsg += fmt.Sprintf("\n*%s*: %s", name, strings.Join(categories[name], ", "))
+	}
+
+	return helpMsg + "\n\nsay `help <category>` or `help <command>` for details, or `help <term>` to search."
+}
+
+// helpName returns what to call the rule in help, its command if it has one.
+func helpName(rule models.Rule) string {
+	if rule.Respond != "" && !strings.HasPrefix(rule.Respond, "/") {
+		return rule.Respond
+	}
+
+	return rule.Name
+}
+
+// helpSearch looks up the term as command or rule name, then as category,
+// and finally searches the names, help texts and patterns of the rules.
+func helpSearch(rules []models.Rule, term string) string {
+	for _, rule := range rules {
+		if strings.EqualFold(term, rule.Name) || strings.EqualFold(term, helpName(rule)) {
+			return helpDetails(rule)
+		}
+	}
+
+	inCategory := slices.DeleteFunc(slices.Clone(rules), func(rule models.Rule) bool {
+		return !strings.EqualFold(term, helpCategory(rule))
+	})
+	if len(inCategory) > 0 {
+		return helpList(fmt.Sprintf("*%s* commands:", helpCategory(inCategory[0])), inCategory)
+	}
+
+	lower := strings.ToLower(term)
+
+	found := slices.DeleteFunc(slices.Clone(rules), func(rule models.Rule) bool {
+		for _, value := range []string{rule.Name, rule.HelpText, rule.Respond, ruleUsage(rule)} {
+			if strings.Contains(strings.ToLower(value), lower) {
+				return false
+			}
+		}
+
+		return true
+	})
+	if len(found) > 0 {
+		return helpList(fmt.Sprintf("commands matching %#q:", term), found)
+	}
+
+	return fmt.Sprintf("i couldn't find any commands for %#q, say `help` to see what i can do.", term)
+}
+
+// helpList lists the usage of the rules below the title.
+func helpList(title string, rules []models.Rule) string {
+	var sb strings.Builder
+
+	sb.WriteString(title)
+
+	for _, rule := range rules {
+		fmt.Fprintf(&sb, "\n • %s", ruleUsage(rule))
+	}
+
+	return sb.String()
+}
+
+// helpDetails describes a rule, its usage generated from the args and flags, and its examples.
+func helpDetails(rule models.Rule) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "*%s*", rule.Name)
+
+	if rule.HelpText != "" {
+		fmt.Fprintf(&sb, "\n%s", rule.HelpText)
+	}
+
+	if rule.Respond != "" {
+		fmt.Fprintf(&sb, "\nusage: `%s`", argsUsage(rule))
+	}
+
+	if len(rule.Args) > 0 {
+		sb.WriteString("\narguments:")
+
+		for _, arg := range rule.Args {
+			fmt.Fprintf(&sb, "\n • %#q%s", arg.Name, helpArgDetails(arg, arg.Default, arg.Description))
+		}
+	}
+
+	if len(rule.Flags) > 0 {
+		sb.WriteString("\nflags:")
+
+		for _, flag := range rule.Flags {
+			name := fmt.Sprintf("%#q", "--"+flag.Name)
+			if flag.Short != "" {
+				name += fmt.Sprintf(", %#q", "-"+flag.Short)
+			}
+
+			fmt.Fprintf(&sb, "\n • %s%s", name, helpArgDetails(flagArg(flag), flag.Default, flag.Description))
+		}
+	}
+
+	if len(rule.UsageExamples) > 0 {
+		sb.WriteString("\nexamples:")
+
+		for _, example := range rule.UsageExamples {
+			fmt.Fprintf(&sb, "\n • `%s`", example)
+		}
+	}
+
+	return sb.String()
+}
+
+// helpArgDetails describes the type, default and purpose of an argument or flag.
+func helpArgDetails(arg models.Arg, def, description string) string {
+	details := []string{}
+
+	switch t := argType(arg); t {
+	case models.ArgTypeString:
+	case models.ArgTypeEnum:
+		details = append(details, "one of "+strings.Join(arg.Values, ", "))
+	default:
+		details = append(details, t)
+	}
+
+	if def != "" {
+		details = append(details, fmt.Sprintf("default %#q", def))
+	}
+
+	out := ""
+	if len(details) > 0 {
+		out = " (" + strings.Join(details, ", ") + ")"
+	}
+
+	if description != "" {
+		out += ": " + description
+	}
+
+	return out
+}
+
+// sendHelp sends the help, as direct message if it is longer than 'help_direct_message_lines'.
+func sendHelp(outputMsgs chan<- models.Envelope, message models.Message, output string, bot *models.Bot) {
+	lines := strings.Count(output, "\n") + 1
+
+	if bot.HelpDirectMessageLines > 0 && lines > bot.HelpDirectMessageLines && message.Type != models.MsgTypeDirect {
+		note := message
+		note.Output = "that's a lot of help, i sent it to you as direct message."
+		outputMsgs <- models.Send(note)
+
+		message.Type = models.MsgTypeDirect
+		message.DirectMessageOnly = true
+		message.ThreadTimestamp = ""
+	}
+
+	message.Output = output
+	outputMsgs <- models.Send(message)
+}
@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"testing"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+var testHelpRules = map[string]models.Rule{
+	"deploy.yml": {
+		Name:          "deploy",
+		Respond:       "deploy",
+		Args:          []models.Arg{{Name: "app", Description: "app to deploy"}, {Name: "env", Type: "enum", Values: []string{"dev", "prod"}, Default: "dev"}},
+		Flags:         []models.Flag{{Name: "dry-run", Short: "n", Type: "bool"}},
+		Category:      "ops",
+		UsageExamples: []string{"deploy api prod"},
+		Active:        true,
+		IncludeInHelp: true,
+	},
+	"restart.yml": {
+		Name:          "restart",
+		Respond:       "restart",
+		HelpText:      "restart <app> - restarts the app",
+		Category:      "ops",
+		AllowUsers:    []string{"admin"},
+		Active:        true,
+		IncludeInHelp: true,
+	},
+	"joke.yml": {
+		Name:          "joke",
+		Respond:       "joke",
+		HelpText:      "joke - tells a joke",
+		Active:        true,
+		IncludeInHelp: true,
+	},
+	"hidden.yml": {
+		Name:     "hidden",
+		Respond:  "hidden",
+		HelpText: "hidden",
+		Active:   true,
+	},
+	"hear.yml": {
+		Name:          "hear",
+		Hear:          "/joke/",
+		HelpText:      "hear",
+		Active:        true,
+		IncludeInHelp: true,
+	},
+}
+
+func Test_helpRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  models.Message
+		wantTerm string
+		wantOk   bool
+	}{
+		{"Help", models.Message{Input: "help", BotMentioned: true}, "", true},
+		{"Help with term", models.Message{Input: "Help  deploy  app", Type: models.MsgTypeDirect}, "deploy app", true},
+		{"Bot not addressed", models.Message{Input: "help"}, "", false},
+		{"Not help", models.Message{Input: "helpful", BotMentioned: true}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term, ok := helpRequest(tt.message)
+			if term != tt.wantTerm || ok != tt.wantOk {
+				t.Errorf("helpRequest() = %q, %v, want %q, %v", term, ok, tt.wantTerm, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_handleHelp(t *testing.T) {
+	bot := &models.Bot{Name: "bot", ChatApplications: []string{"slack"}}
+	user := map[string]string{"_user.name": "someone"}
+	admin := map[string]string{"_user.name": "admin"}
+
+	tests := []struct {
+		name string
+		term string
+		vars map[string]string
+		want string
+	}{
+		{"Overview", "", user, "I understand these commands: \n\n*general*: `joke`\n*ops*: `deploy`\n\nsay `help <category>` or `help <command>` for details, or `help <term>` to search."},
+		{"Overview of allowed rules", "", admin, "I understand these commands: \n\n*general*: `joke`\n*ops*: `deploy`, `restart`\n\nsay `help <category>` or `help <command>` for details, or `help <term>` to search."},
+		{"Command", "Deploy", user, "*deploy*\nusage: `deploy <app> [env:dev|prod=dev] [--dry-run|-n]`\narguments:\n • `app`: app to deploy\n • `env` (one of dev, prod, default `dev`)\nflags:\n • `--dry-run`, `-n` (bool)\nexamples:\n • `deploy api prod`"},
+		{"Category", "ops", admin, "*ops* commands:\n • deploy <app> [env:dev|prod=dev] [--dry-run|-n]\n • restart <app> - restarts the app"},
+		{"Search", "tells", user, "commands matching `tells`:\n • joke - tells a joke"},
+		{"Search is limited to allowed rules", "restart", user, "i couldn't find any commands for `restart`, say `help` to see what i can do."},
+		{"Hidden rules are not searched", "hidden", user, "i couldn't find any commands for `hidden`, say `help` to see what i can do."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputMsgs := make(chan models.Envelope, 1)
+
+			handleHelp(outputMsgs, models.Message{Vars: tt.vars}, testHelpRules, tt.term, bot)
+
+			if got := (<-outputMsgs).Message.Output; got != tt.want {
+				t.Errorf("handleHelp() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_sendHelp(t *testing.T) {
+	bot := &models.Bot{HelpDirectMessageLines: 2}
+
+	outputMsgs := make(chan models.Envelope, 2)
+
+	sendHelp(outputMsgs, models.Message{Type: models.MsgTypeChannel, ThreadTimestamp: "1"}, "one\ntwo\nthree", bot)
+
+	note := (<-outputMsgs).Message
+	if note.Type != models.MsgTypeChannel || note.DirectMessageOnly {
+		t.Errorf("sendHelp() expected a note in the channel, got %+v", note)
+	}
+
+	help := (<-outputMsgs).Message
+	if help.Type != models.MsgTypeDirect || !help.DirectMessageOnly || help.ThreadTimestamp != "" || help.Output != "one\ntwo\nthree" {
+		t.Errorf("sendHelp() expected the help as direct message, got %+v", help)
+	}
+}
@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/text"
+)
+
+// compiledPattern is a pattern that was compiled, or the reason it couldn't be.
+type compiledPattern struct {
+	pattern *text.Pattern
+	err     error
+}
+
+// patternCache holds the compiled patterns of the rules, so they are compiled once when the rules
+// are indexed rather than for every message. Patterns of rules that were removed or changed stay
+// cached, there are only ever as many as the rule files saw edits.
+type patternCache struct {
+	mu       sync.RWMutex
+	compiled map[string]compiledPattern
+}
+
+func newPatternCache() *patternCache {
+	return &patternCache{compiled: make(map[string]compiledPattern)}
+}
+
+// compile returns the compiled pattern, compiling it if it isn't cached yet.
+func (pc *patternCache) compile(pattern string) (*text.Pattern, error) {
+	pc.mu.RLock()
+	c, ok := pc.compiled[pattern]
+	pc.mu.RUnlock()
+
+	if ok {
+		return c.pattern, c.err
+	}
+
+	p, err := text.Compile(pattern)
+
+	pc.mu.Lock()
+	pc.compiled[pattern] = compiledPattern{pattern: p, err: err}
+	pc.mu.Unlock()
+
+	return p, err
+}
+
+// match checks the value against the compiled pattern, see 'text.Match'.
+func (pc *patternCache) match(pattern, value string, trimValue bool) (string, bool) {
+	p, err := pc.compile(pattern)
+	if err != nil {
+		log.Error().Msgf("unsupported regex: %s", pattern)
+
+		return "", false
+	}
+
+	return p.Match(value, trimValue)
+}
+
+// compileRulePatterns checks that the patterns of a rule compile, so they are ready to match messages.
+func compileRulePatterns(rule models.Rule) error {
+	fields := []struct{ name, pattern string }{
+		{"respond", rule.Respond},
+		{"hear", rule.Hear},
+		{"reactions_added", rule.ReactionsAdded},
+		{"reactions_removed", rule.ReactionsRemoved},
+		{"block_actions", rule.BlockActions},
+		{"view_submission", rule.ViewSubmission},
+	}
+
+	var errs []error
+
+	for _, field := range fields {
+		if field.pattern == "" {
+			continue
+		}
+
+		if _, err := text.Compile(field.pattern); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %#q pattern: %w", field.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ruleIndex holds the active rules in the order they are matched against messages, along with
+// the rules grouped by the first word of the input they respond to or hear. This way a message
+// is only matched against the rules for its first word, and the rules that can't be grouped.
+type ruleIndex struct {
+	rules    map[string]models.Rule
+	sorted   []models.Rule
+	keywords map[string][]int // first word of the input -> positions in sorted
+	scan     []int            // positions of the rules without a first word
+}
+
+func newRuleIndex(rules map[string]models.Rule, patterns *patternCache) *ruleIndex {
+	idx := &ruleIndex{
+		rules:    rules,
+		keywords: make(map[string][]int),
+	}
+
+	for _, rule := range sortRules(rules) {
+		if !rule.Active {
+			continue
+		}
+
+		idx.sorted = append(idx.sorted, rule)
+		pos := len(idx.sorted) - 1
+
+		if keyword := ruleKeyword(rule, patterns); keyword != "" {
+			idx.keywords[keyword] = append(idx.keywords[keyword], pos)
+		} else {
+			idx.scan = append(idx.scan, pos)
+		}
+	}
+
+	return idx
+}
+
+// ruleKeyword returns the first word of any input the rule responds to or hears, if there is one.
+func ruleKeyword(rule models.Rule, patterns *patternCache) string {
+	pattern := rule.Respond
+	if pattern == "" {
+		pattern = rule.Hear
+	}
+
+	if pattern == "" {
+		return ""
+	}
+
+	p, err := patterns.compile(pattern)
+	if err != nil {
+		return ""
+	}
+
+	return p.Keyword()
+}
+
+// candidates returns the rules that may match the message, in the order they are to be matched.
+func (idx *ruleIndex) candidates(message models.Message) []models.Rule {
+	isInteraction := message.BlockAction != "" || message.ViewSubmission != ""
+	if message.Service != models.MsgServiceChat && message.Service != models.MsgServiceCLI || isInteraction {
+		return idx.sorted
+	}
+
+	var keyword []int
+	if fields := strings.Fields(message.Input); len(fields) > 0 {
+		keyword = idx.keywords[strings.ToLower(fields[0])]
+	}
+
+	// merge the positions of both lists, keeping the order of the rules
+	rules := make([]models.Rule, 0, len(keyword)+len(idx.scan))
+
+	i, j := 0, 0
+	for i < len(keyword) || j < len(idx.scan) {
+		if j == len(idx.scan) || i < len(keyword) && keyword[i] < idx.scan[j] {
+			rules = append(rules, idx.sorted[keyword[i]])
+			i++
+		} else {
+			rules = append(rules, idx.sorted[idx.scan[j]])
+			j++
+		}
+	}
+
+	return rules
+}
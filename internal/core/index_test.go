@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/text"
+)
+
+func Test_ruleIndex_candidates(t *testing.T) {
+	rules := map[string]models.Rule{
+		"deploy.yml":   {Name: "deploy", Active: true, Respond: "deploy"},
+		"regex.yml":    {Name: "regex deploy", Active: true, Respond: `/^deploy\s+(\w+)/`},
+		"rollback.yml": {Name: "rollback", Active: true, Respond: "rollback"},
+		"audit.yml":    {Name: "audit", Active: true, Hear: "/deploy/", Priority: 10},
+		"reaction.yml": {Name: "reaction", Active: true, ReactionsAdded: "thumbsup"},
+		"inactive.yml": {Name: "inactive", Active: false, Respond: "deploy"},
+		"schedule.yml": {Name: "schedule", Active: true, Schedule: "@daily"},
+	}
+
+	tests := []struct {
+		name    string
+		message models.Message
+		want    []string
+	}{
+		{"First word", models.Message{Service: models.MsgServiceChat, Input: "Deploy app"}, []string{"audit", "deploy", "reaction", "regex deploy", "schedule"}},
+		{"Other word", models.Message{Service: models.MsgServiceCLI, Input: "rollback app"}, []string{"audit", "reaction", "rollback", "schedule"}},
+		{"No input", models.Message{Service: models.MsgServiceChat}, []string{"audit", "reaction", "schedule"}},
+		{"Interaction", models.Message{Service: models.MsgServiceChat, Input: "deploy", BlockAction: "approve"}, []string{"audit", "deploy", "reaction", "regex deploy", "rollback", "schedule"}},
+		{"Scheduler", models.Message{Service: models.MsgServiceScheduler}, []string{"audit", "deploy", "reaction", "regex deploy", "rollback", "schedule"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, rule := range newRuleIndex(rules, newPatternCache()).candidates(tt.message) {
+				got = append(got, rule.Name)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("candidates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// benchmarkRules creates a large rule set, mostly commands with a distinct first word.
+func benchmarkRules(n int) map[string]models.Rule {
+	rules := make(map[string]models.Rule, n)
+
+	for i := range n {
+		rule := models.Rule{Name: fmt.Sprintf("rule %d", i), Active: true, Respond: fmt.Sprintf(`/^cmd%d\s+(?P<arg>\w+)/`, i)}
+
+		// a few rules can't be indexed
+		if i%50 == 0 {
+			rule.Respond = ""
+			rule.Hear = fmt.Sprintf(`/(hello|hi) %d/`, i)
+		}
+
+		rules[fmt.Sprintf("rule%03d.yml", i)] = rule
+	}
+
+	return rules
+}
+
+func Benchmark_matchRules(b *testing.B) {
+	rules := benchmarkRules(300)
+	message := models.Message{Service: models.MsgServiceChat, Input: "cmd299 flottbot", Vars: map[string]string{}}
+
+	match := func(candidates []models.Rule, hit func(models.Rule) bool) {
+		for _, rule := range candidates {
+			if hit(rule) {
+				return
+			}
+		}
+
+		b.Fatal("expected a rule to match")
+	}
+
+	b.Run("scan compiling patterns", func(b *testing.B) {
+		for b.Loop() {
+			match(sortRules(rules), func(rule models.Rule) bool {
+				pattern := rule.Respond
+				if pattern == "" {
+					pattern = rule.Hear
+				}
+
+				_, hit := text.Match(pattern, message.Input, true)
+
+				return hit
+			})
+		}
+	})
+
+	b.Run("scan", func(b *testing.B) {
+		m := newMatcherState(new(models.Bot))
+
+		for b.Loop() {
+			match(sortRules(rules), func(rule models.Rule) bool {
+				_, hit := m.getProccessedInputAndHitValue(message, rule)
+				return hit
+			})
+		}
+	})
+
+	b.Run("indexed", func(b *testing.B) {
+		m := newMatcherState(new(models.Bot))
+		index := newRuleIndex(rules, m.patterns)
+
+		for b.Loop() {
+			match(index.candidates(message), func(rule models.Rule) bool {
+				_, hit := m.getProccessedInputAndHitValue(message, rule)
+				return hit
+			})
+		}
+	})
+}
@@ -9,7 +9,6 @@ import (
 	"html"
 	"html/template"
 	"maps"
-	"regexp"
 	"strconv"
 	"strings"
 
@@ -21,54 +20,123 @@ import (
 	"github.com/target/flottbot/internal/chat"
 	"github.com/target/flottbot/internal/handlers"
 	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/store"
 	"github.com/target/flottbot/internal/text"
 )
 
 // Matcher will search through the map of loaded rules, determine if a rule was hit, and process said rule to be sent out as a message.
-func Matcher(inputMsgs <-chan models.Message, outputMsgs chan<- models.Message, rules map[string]models.Rule, hitRule chan<- models.Rule, bot *models.Bot) {
-	for {
-		message := <-inputMsgs
-		matcherLoop(message, outputMsgs, rules, hitRule, bot)
+// Rate limits start out fresh with every run. Once inputMsgs is closed, Matcher waits for the running rules
+// to finish and closes outputMsgs.
+func Matcher(inputMsgs <-chan models.Message, outputMsgs chan<- models.Envelope, rules *models.RuleSet, bot *models.Bot) {
+	m := newMatcherState(bot)
+
+	changed := rules.Subscribe()
+	index := newRuleIndex(rules.Rules(), m.patterns)
+
+	for message := range inputMsgs {
+		// rebuild the index when the rules were reloaded
+		select {
+		case <-changed:
+			index = newRuleIndex(rules.Rules(), m.patterns)
+		default:
+		}
+
+		m.matcherLoop(message, outputMsgs, index, bot)
+	}
+
+	// the remotes stopped reading, let the running rules finish before closing the outputs
+	log.Info().Msg("waiting for running rules to finish")
+
+	m.workers.stop()
+	m.conversations.stopAll()
+
+	close(outputMsgs)
+}
+
+// matcherState is what a running 'Matcher' keeps track of. Every 'Matcher' has its own,
+// so bots in the same process, ie. in tests, don't share their running rules or rate limits.
+type matcherState struct {
+	workers       *workerPool
+	rateLimits    *rateLimiter
+	conversations *conversationManager
+	patterns      *patternCache
+}
+
+func newMatcherState(bot *models.Bot) *matcherState {
+	// the actions of hit rules run on a fixed number of workers
+	workers := newWorkerPool(workerCount(bot), QueueSize(bot))
+
+	return &matcherState{
+		workers:       workers,
+		rateLimits:    newRateLimiter(),
+		conversations: newConversationManager(workers),
+		patterns:      newPatternCache(),
+	}
+}
+
+// isAnswer tells whether the message may answer a question of a conversation;
+// interactions, reactions and messages without text are no answers.
+func isAnswer(message models.Message) bool {
+	if message.Service != models.MsgServiceChat && message.Service != models.MsgServiceCLI {
+		return false
+	}
+
+	if message.BlockAction != "" || message.ViewSubmission != "" {
+		return false
+	}
+
+	if message.ReactionAdded != "" || message.ReactionRemoved != "" {
+		return false
 	}
+
+	return strings.TrimSpace(message.Input) != ""
 }
 
-func matcherLoop(message models.Message, outputMsgs chan<- models.Message, rules map[string]models.Rule, hitRule chan<- models.Rule, bot *models.Bot) {
+func (m *matcherState) matcherLoop(message models.Message, outputMsgs chan<- models.Envelope, index *ruleIndex, bot *models.Bot) {
 	match := false
 
+	// answers to follow-up questions of a conversation are not matched against the rules
+	if isAnswer(message) && m.conversations.handle(outputMsgs, message, bot) {
+		return
+	}
+
 RuleSearch:
-	// Look through rules to see if we can find a match
-	for _, rule := range rules {
-		// Only check active rules.
-		if rule.Active {
-			// Init some variables for use below
-			processedInput, hit := getProccessedInputAndHitValue(message, rule)
-			// Determine what service we are processing the rule for
-			switch message.Service {
-			case models.MsgServiceChat, models.MsgServiceCLI:
-				foundMatch, stopSearch := handleChatServiceRule(outputMsgs, message, hitRule, rule, processedInput, hit, bot)
-				match = foundMatch
-
-				if stopSearch {
-					break RuleSearch
-				}
-			case models.MsgServiceScheduler:
-				foundMatch, stopSearch := handleSchedulerServiceRule(outputMsgs, message, hitRule, rule, bot)
-				match = foundMatch
+	// Look through the active rules that may match to see if we can find a match
+	for _, rule := range index.candidates(message) {
+		// Init some variables for use below
+		processedInput, hit := m.getProccessedInputAndHitValue(message, rule)
+		// Determine what service we are processing the rule for
+		switch message.Service {
+		case models.MsgServiceChat, models.MsgServiceCLI:
+			foundMatch, stopSearch := m.handleChatServiceRule(outputMsgs, message, rule, processedInput, hit, bot)
+			match = match || foundMatch
+
+			// let the following rules have a go at the message as well
+			if stopSearch && !rule.ContinueMatching {
+				break RuleSearch
+			}
+		case models.MsgServiceScheduler:
+			foundMatch, stopSearch := m.handleSchedulerServiceRule(outputMsgs, message, rule, bot)
+			match = match || foundMatch
 
-				if stopSearch {
-					break RuleSearch
-				}
+			if stopSearch {
+				break RuleSearch
 			}
 		}
 	}
-	// No rule was matched
+	// No rule was matched, the built-in help is used unless a rule handles 'help'
+	if term, ok := helpRequest(message); !match && ok {
+		handleHelp(outputMsgs, message, index.rules, term, bot)
+		return
+	}
+
 	if !match {
-		handleNoMatch(outputMsgs, message, hitRule, rules, bot)
+		handleNoMatch(outputMsgs, message, index.rules, bot)
 	}
 }
 
 // getProccessedInputAndHitValue gets the processed input from the message input and the true/false if it was a successfully hit rule.
-func getProccessedInputAndHitValue(message models.Message, rule models.Rule) (string, bool) {
+func (m *matcherState) getProccessedInputAndHitValue(message models.Message, rule models.Rule) (string, bool) {
 	processedInput, hit := "", false
 
 	ruleRespondValue := rule.Respond
@@ -76,16 +144,20 @@ func getProccessedInputAndHitValue(message models.Message, rule models.Rule) (st
 
 	if rule.Respond != "" {
 		messageInput := message.Input
-		processedInput, hit = text.Match(ruleRespondValue, messageInput, true)
+		processedInput, hit = m.patterns.match(ruleRespondValue, messageInput, true)
 	} else if rule.Hear != "" { // Are we listening to everything?
 		messageInput := message.Input
-		_, hit = text.Match(ruleHearValue, messageInput, false)
+		_, hit = m.patterns.match(ruleHearValue, messageInput, false)
 	} else if rule.ReactionsAdded != "" {
 		messageReaction := message.ReactionAdded
-		processedInput, hit = text.Match(rule.ReactionsAdded, messageReaction, false)
+		processedInput, hit = m.patterns.match(rule.ReactionsAdded, messageReaction, false)
 	} else if rule.ReactionsRemoved != "" {
 		messageReaction := message.ReactionRemoved
-		processedInput, hit = text.Match(rule.ReactionsRemoved, messageReaction, false)
+		processedInput, hit = m.patterns.match(rule.ReactionsRemoved, messageReaction, false)
+	} else if rule.BlockActions != "" && message.BlockAction != "" {
+		processedInput, hit = m.patterns.match(rule.BlockActions, message.BlockAction, false)
+	} else if rule.ViewSubmission != "" && message.ViewSubmission != "" {
+		processedInput, hit = m.patterns.match(rule.ViewSubmission, message.ViewSubmission, false)
 	}
 
 	return processedInput, hit
@@ -105,13 +177,17 @@ func isValidChatRule(rule models.Rule) bool {
 		return true
 	}
 
+	if rule.BlockActions != "" || rule.ViewSubmission != "" {
+		return true
+	}
+
 	return false
 }
 
 // handleChatServiceRule handles the processing logic for a rule that came from either the chat application or CLI remote.
 //
 //nolint:gocyclo // refactor candidate
-func handleChatServiceRule(outputMsgs chan<- models.Message, message models.Message, hitRule chan<- models.Rule, rule models.Rule, processedInput string, hit bool, bot *models.Bot) (bool, bool) {
+func (m *matcherState) handleChatServiceRule(outputMsgs chan<- models.Envelope, message models.Message, rule models.Rule, processedInput string, hit bool, bot *models.Bot) (bool, bool) {
 	match, stopSearch := false, false
 
 	if isValidChatRule(rule) {
@@ -175,17 +251,26 @@ func handleChatServiceRule(outputMsgs chan<- models.Message, message models.Mess
 			message.Vars["_is_thread_message"] = strconv.FormatBool(message.ThreadTimestamp != "")
 
 			// Do additional checks on the rule before running
-			if !isValidHitChatRule(&message, rule, processedInput, bot) {
-				outputMsgs <- message
-
-				hitRule <- models.Rule{}
+			if !m.isValidHitChatRule(&message, rule, processedInput, bot) {
+				outputMsgs <- models.Envelope{Message: message, Rule: rule, Ops: models.OpSend}
 				// prevent actions from being run; exit early
 				return match, stopSearch
 			}
 
+			// Suppress rules in cooldown and users that trigger the rule too often
+			if m.rateLimits.isThrottled(outputMsgs, message, rule, bot) {
+				return match, stopSearch
+			}
+
+			// ask for missing args and the rule's steps before running the actions
+			if steps := conversationSteps(rule, processedInput); len(steps) > 0 {
+				m.conversations.start(outputMsgs, message, rule, steps)
+				return match, stopSearch
+			}
+
 			msg := deepcopy.Copy(message).(models.Message)
 
-			go doRuleActions(msg, outputMsgs, rule, hitRule, bot)
+			m.workers.runRule(outputMsgs, msg, rule, bot)
 
 			return match, stopSearch
 		}
@@ -195,13 +280,13 @@ func handleChatServiceRule(outputMsgs chan<- models.Message, message models.Mess
 }
 
 // handleSchedulerServiceRule handles the processing logic for a rule that came from the Scheduler remote.
-func handleSchedulerServiceRule(outputMsgs chan<- models.Message, message models.Message, hitRule chan<- models.Rule, rule models.Rule, bot *models.Bot) (bool, bool) {
+func (m *matcherState) handleSchedulerServiceRule(outputMsgs chan<- models.Envelope, message models.Message, rule models.Rule, bot *models.Bot) (bool, bool) {
 	match, stopSearch := false, false
 	if rule.Schedule != "" && rule.Name == message.Attributes["from_schedule"] {
 		match, stopSearch = true, true // Don't go through more rules if rule is matched
 		msg := deepcopy.Copy(message).(models.Message)
 
-		go doRuleActions(msg, outputMsgs, rule, hitRule, bot)
+		m.workers.runRule(outputMsgs, msg, rule, bot)
 
 		return match, stopSearch
 	}
@@ -210,50 +295,57 @@ func handleSchedulerServiceRule(outputMsgs chan<- models.Message, message models
 }
 
 // handleNoMatch - handles logic for unmatched rule.
-func handleNoMatch(outputMsgs chan<- models.Message, message models.Message, hitRule chan<- models.Rule, rules map[string]models.Rule, bot *models.Bot) {
+func handleNoMatch(outputMsgs chan<- models.Envelope, message models.Message, rules map[string]models.Rule, bot *models.Bot) {
+	// Interactions (ie. button clicks) without a rule don't need an answer
+	if message.BlockAction != "" || message.ViewSubmission != "" {
+		log.Debug().Msg("no rule matched the interaction")
+		return
+	}
+
 	// If bot was addressed or was private messaged, print help text by default
 	if message.Type == models.MsgTypeDirect || message.BotMentioned {
+		// bots without help stay silent, unless suggestions were turned on explicitly
+		if bot.DisableNoMatchHelp && bot.SuggestionMaxDistance <= 0 {
+			return
+		}
+
+		// only suggest and show what the user is allowed to run
+		visible := helpRules(message, rules, bot)
+
+		// Suggest the commands that are close to what the user typed, if any
+		if suggestions := suggestCommands(message, visible, bot); len(suggestions) > 0 {
+			log.Info().Msg("bot was addressed, but no rule matched - suggesting similar commands")
+			Prommetric(bot.Name+"-None", bot)
+			sendHelp(outputMsgs, message, didYouMean(suggestions), bot)
+
+			return
+		}
+
 		// Do not send help message if DisableNoMatchHelp is true
 		if !bot.DisableNoMatchHelp {
 			log.Info().Msg("bot was addressed, but no rule matched - showing help")
 			// Publish metric as none
 			Prommetric(bot.Name+"-None", bot)
-			// Set custom_help_text if it is set in bot.yml
-			helpMsg := bot.CustomHelpText
-			// If custom_help_text is not set, use default Help Text, for each rule use help_text from rule file
-			if helpMsg == "" {
-				helpMsg = "I understand these commands: \n"
-				if bot.CustomHelpTextPrefix != "" {
-					helpMsg = bot.CustomHelpTextPrefix + "\n"
-				}
-
-				// Go through all the rules and collect the help_text
-				for _, rule := range rules {
-					// Is the rule active and does the user want to expose the help for it? 'hear' rules don't show in help by default
-					if rule.Active && rule.Hear == "" && rule.IncludeInHelp && rule.HelpText != "" {
-						helpMsg = helpMsg + fmt.Sprintf("\n • %s", rule.HelpText)
-					}
-				}
-			}
-			// Populate output with help text defined above
-			message.Output = helpMsg
-			outputMsgs <- message
-
-			hitRule <- models.Rule{}
+			sendHelp(outputMsgs, message, helpOverview(visible, bot), bot)
 		}
 	}
 }
 
 // isValidHitChatRule does additional checks on a successfully hit rule that came from the chat or CLI service.
-func isValidHitChatRule(message *models.Message, rule models.Rule, processedInput string, bot *models.Bot) bool {
-	// Check rule has one of Hear, Respond, ReactionsAdded or ReactionsRemoved
+func (m *matcherState) isValidHitChatRule(message *models.Message, rule models.Rule, processedInput string, bot *models.Bot) bool {
+	// Check rule has one of Hear, Respond, ReactionsAdded, ReactionsRemoved, BlockActions or ViewSubmission
 	if !isValidChatRule(rule) {
-		message.Output = "Rule does not have one of Hear, Respond, ReactionsAdded or ReactionsRemoved defined "
+		message.Output = "Rule does not have one of Hear, Respond, ReactionsAdded, ReactionsRemoved, BlockActions or ViewSubmission defined "
 		return false
 	}
 
 	// Check to honor allow_users or allow_usergroups
-	canRunRule := auth.CanTrigger(message.Vars["_user.name"], message.Vars["_user.id"], rule, bot)
+	chatApp := message.Remote
+	if chatApp == "" {
+		chatApp = primaryChatApplication(bot)
+	}
+
+	canRunRule := auth.CanTrigger(chatApp, message.Vars["_user.name"], message.Vars["_user.id"], rule, bot)
 	if !canRunRule {
 		message.Output = fmt.Sprintf("You are not allowed to run the %#q rule.", rule.Name)
 		// forcing direct message
@@ -264,138 +356,98 @@ func isValidHitChatRule(message *models.Message, rule models.Rule, processedInpu
 		return false
 	}
 
-	maps.Copy(message.Vars, parseArgumentsFromRegex(rule.Hear, message.Input))
+	maps.Copy(message.Vars, m.patterns.parseArgumentsFromRegex(rule.Hear, message.Input))
 
-	maps.Copy(message.Vars, parseArgumentsFromRegex(rule.Respond, message.Input))
+	maps.Copy(message.Vars, m.patterns.parseArgumentsFromRegex(rule.Respond, message.Input))
 
 	// If this is a "respond" type, handle args
 	if rule.Respond != "" {
-		// Get all the args that the message sender supplied
-		args := text.RuleArgTokenizer(processedInput)
-
-		var (
-			optionalArgs int
-			requiredArgs int
-			varArgs      int
-		)
-
-		// take note of all optional args that end with a '?'
-		for _, arg := range rule.Args {
-			if strings.HasSuffix(arg, "?") {
-				optionalArgs++
-			}
-
-			if strings.HasSuffix(arg, "+") {
-				varArgs++
-			}
+		if err := validateArgs(rule.Args); err != nil {
+			message.Output = err.Error()
+			return false
 		}
 
-		if varArgs > 1 {
-			// error, can ony have 1
-			message.Output = "you cannot specify more than 1 variable argument"
-
+		// Get all the args that the message sender supplied, separated from the flags if the rule has any
+		args, flags, err := parseFlags(rule, text.RuleArgTokenizer(processedInput), message.Remote, bot)
+		if err != nil {
+			message.Output = err.Error()
 			return false
 		}
 
-		if len(rule.Args) > 0 && strings.HasSuffix(rule.Args[len(rule.Args)-1], "+") {
-			if optionalArgs > 0 {
-				// error, cannot combine optional and varargs
-				message.Output = "you cannot combine optional arguments with variable arguments"
+		maps.Copy(message.Vars, flags)
+
+		requiredArgs := 0
 
-				return false
+		for _, arg := range rule.Args {
+			if arg.IsRequired() {
+				requiredArgs++
 			}
-		} else if varArgs == 1 {
-			// error, vararg but not in last position
-			message.Output = "you must specify the variable argument in the last argument position"
+		}
 
+		// Are we expecting a number of args but don't have as many as the rule defines? Send a helpful message,
+		// unless the user will be prompted for the missing args
+		if requiredArgs > len(args) && !rule.PromptForArgs {
+			message.Output = fmt.Sprintf("you might be missing an argument or two - this is what i'm looking for\n```%s```", ruleUsage(rule))
 			return false
 		}
 
-		// ensure we only require args that don't end with '?'
-		requiredArgs = len(rule.Args) - optionalArgs
-
-		// Are we expecting a number of args but don't have as many as the rule defines? Send a helpful message
-		if len(rule.Args) > 0 && requiredArgs > len(args) {
-			message.Output = fmt.Sprintf("you might be missing an argument or two - this is what i'm looking for\n```%s```", rule.HelpText)
+		// Convert the supplied args to their declared types and make them available as variables
+		vars, err := parseArgs(rule, args, message.Remote, bot)
+		if err != nil {
+			message.Output = err.Error()
 			return false
 		}
 
-		// Go through the supplied args and make them available as variables
-		for index, arg := range rule.Args {
-			// If this is a varag method, then join all the remaining args and end
-			if strings.HasSuffix(arg, "+") {
-				message.Vars[strings.TrimSuffix(arg, "+")] = strings.Join(args[index:], " ")
-				break
-			}
-
-			// strip '?' from end of arg
-			arg = strings.TrimSuffix(arg, "?")
-
-			// index starts at 0 so we need to account for that
-			if index > (len(args) - 1) {
-				message.Vars[arg] = ""
-			} else {
-				message.Vars[arg] = args[index]
-			}
-		}
+		maps.Copy(message.Vars, vars)
 	}
 
 	return true
 }
 
 // core handler routing for all allowed actions.
-func doRuleActions(message models.Message, outputMsgs chan<- models.Message, rule models.Rule, hitRule chan<- models.Rule, bot *models.Bot) {
+func doRuleActions(message models.Message, outputMsgs chan<- models.Envelope, rule models.Rule, bot *models.Bot) {
 	// React to message which triggered rule
 	if rule.Reaction != "" {
 		copyrule := deepcopy.Copy(rule).(models.Rule)
 		copymessage := deepcopy.Copy(message).(models.Message)
-		handleReaction(outputMsgs, &copymessage, hitRule, copyrule)
+		handleReaction(outputMsgs, &copymessage, copyrule, models.OpReact)
 	}
 
 	// Deal with the actions associated with the rule asynchronously
-	for _, action := range rule.Actions {
-		var err error
-
-		switch strings.ToLower(action.Type) {
-		// HTTP actions.
-		case "get", "post", "put":
-			log.Debug().Msgf("executing action %#q...", action.Name)
-			err = handleHTTP(action, &message)
-		// Exec (script) actions
-		case "exec":
-			log.Debug().Msgf("executing action %#q...", action.Name)
-			err = handleExec(action, &message)
-		// Normal message/log actions
-		case "message", "log":
-			log.Debug().Msgf("executing action %#q...", action.Name)
-			// Log actions cannot direct message users by default
-			directive := rule.DirectMessageOnly
-			if action.Type == "log" {
-				directive = false
-			}
-			// Create copy of message so as to not overwrite other message action type messages
-			dcopy := deepcopy.Copy(message).(models.Message)
-			err = handleMessage(action, outputMsgs, &dcopy, directive, rule.StartMessageThread, hitRule, bot)
-		// Fallback to error if action type is invalid
-		default:
-			log.Error().Msgf("the rule %#q of type %#q is not a supported action", action.Name, action.Type)
-		}
+	stopped, failure := runActions(rule.Actions, &message, outputMsgs, &rule, bot)
 
-		// Handle reaction update
-		updateReaction(action, &rule, message.Vars)
+	// Let the rule handle its failure
+	handledFailure := failure != nil && len(rule.OnFailure) > 0
+	if handledFailure {
+		log.Debug().Msgf("running 'on_failure' actions of rule %#q", rule.Name)
+		runActions(rule.OnFailure, &message, outputMsgs, &rule, bot)
+	}
 
-		// Handle error
-		if err != nil {
-			log.Error().Msg(err.Error())
+	// The error handling actions took care of the output, only update the reaction
+	if handledFailure || (stopped && failure == nil) {
+		if rule.RemoveReaction != "" {
+			handleReaction(outputMsgs, &message, rule, models.OpUnreact|models.OpReact)
 		}
+
+		return
 	}
 
 	// Match supplied room names to IDs
-	message.OutputToRooms = chat.GetRoomIDs(rule.OutputToRooms, bot)
+	message.OutputToRooms = chat.GetRoomIDs(message.Remote, rule.OutputToRooms, bot)
 
 	// Populate message output to users
 	message.OutputToUsers = rule.OutputToUsers
 
+	// Pass along remote specific settings, ie. Slack blocks
+	message.Remotes = rule.Remotes
+
+	blocks, err := renderBlocks(rule.Remotes.Slack.Blocks, &message)
+	if err != nil {
+		log.Error().Msgf("unable to render slack blocks of rule %#q: %v", rule.Name, err)
+	}
+
+	message.Remotes.Slack.Blocks = blocks
+
 	// Start a thread if the message is not already part of a thread and
 	// start_message_thread was set for the Rule
 	if rule.StartMessageThread && message.ThreadTimestamp == "" {
@@ -404,11 +456,15 @@ func doRuleActions(message models.Message, outputMsgs chan<- models.Message, rul
 
 	// After running through all the actions, compose final message
 	val, err := craftResponse(rule, message)
+	if stopped {
+		// the remaining actions were skipped, let the user know why
+		val, err = failure.Error(), nil
+	}
+
 	if err != nil {
 		log.Error().Msg(err.Error())
 
 		message.Output = err.Error()
-		outputMsgs <- message
 	} else {
 		message.Output = val
 		// Override out with an error message, if one was set
@@ -417,10 +473,120 @@ func doRuleActions(message models.Message, outputMsgs chan<- models.Message, rul
 		}
 		// Pass along whether the message should be a direct message
 		message.DirectMessageOnly = rule.DirectMessageOnly
-		outputMsgs <- message
 	}
-	// Channel completed rule
-	hitRule <- rule
+
+	// Send the response along with the completed rule, swapping the reaction if an action updated it
+	ops := models.OpSend
+	if rule.RemoveReaction != "" {
+		ops |= models.OpUnreact | models.OpReact
+	}
+
+	outputMsgs <- models.Envelope{Message: message, Rule: rule, Ops: ops}
+}
+
+// runActions runs the actions in order, actions with a 'when' condition are only run when it holds.
+// It returns whether the remaining actions were skipped due to 'stop_on_error',
+// and the last error that wasn't handled by the 'on_error' actions of an action.
+func runActions(actions []models.Action, message *models.Message, outputMsgs chan<- models.Envelope, rule *models.Rule, bot *models.Bot) (bool, error) {
+	var failure error
+
+	for _, action := range actions {
+		run, err := shouldRun(action, message.Vars)
+		if run {
+			err = runAction(action, message, outputMsgs, rule, bot)
+
+			// Handle reaction update
+			updateReaction(action, rule, message.Vars)
+		}
+
+		if err == nil {
+			continue
+		}
+
+		// Handle error
+		log.Error().Msg(err.Error())
+
+		message.Vars["_error"] = err.Error()
+		message.Vars["_error_action"] = action.Name
+
+		if len(action.OnError) > 0 {
+			log.Debug().Msgf("running 'on_error' actions of action %#q", action.Name)
+
+			// failing error handlers fail the rule
+			if _, handlerErr := runActions(action.OnError, message, outputMsgs, rule, bot); handlerErr != nil {
+				failure = handlerErr
+			}
+		} else {
+			failure = err
+		}
+
+		if action.StopOnError {
+			log.Debug().Msgf("skipping remaining actions of rule %#q, action %#q failed", rule.Name, action.Name)
+			return true, failure
+		}
+	}
+
+	return false, failure
+}
+
+// shouldRun evaluates the 'when' condition of an action.
+func shouldRun(action models.Action, vars map[string]string) (bool, error) {
+	if action.When == "" {
+		return true, nil
+	}
+
+	ok, err := text.Evaluate(action.When, vars)
+	if err != nil {
+		return false, fmt.Errorf("invalid 'when' condition for action %#q: %w", action.Name, err)
+	}
+
+	if !ok {
+		log.Debug().Msgf("skipping action %#q, condition %#q is not met", action.Name, action.When)
+	}
+
+	return ok, nil
+}
+
+// runAction routes the action to its handler.
+func runAction(action models.Action, message *models.Message, outputMsgs chan<- models.Envelope, rule *models.Rule, bot *models.Bot) error {
+	var err error
+
+	switch strings.ToLower(action.Type) {
+	// HTTP actions.
+	case "get", "post", "put":
+		log.Debug().Msgf("executing action %#q...", action.Name)
+
+		err = handleHTTP(action, message)
+
+		// failed requests fail the action
+		if status, _ := strconv.Atoi(message.Vars["_raw_http_status"]); err == nil && status >= 400 {
+			err = fmt.Errorf("request made by action %#q returned status %d", action.Name, status)
+		}
+	// Exec (script) actions
+	case "exec":
+		log.Debug().Msgf("executing action %#q...", action.Name)
+		err = handleExec(action, message)
+	// Key/value store actions
+	case "store":
+		log.Debug().Msgf("executing action %#q...", action.Name)
+		err = handleStore(action, message)
+	// Normal message/log actions
+	case "message", "log":
+		log.Debug().Msgf("executing action %#q...", action.Name)
+		// Log actions cannot direct message users by default
+		directive := rule.DirectMessageOnly
+		if action.Type == "log" {
+			directive = false
+		}
+		// Create copy of message so as to not overwrite other message action type messages
+		dcopy := deepcopy.Copy(*message).(models.Message)
+		err = handleMessage(action, outputMsgs, &dcopy, directive, rule.StartMessageThread, bot)
+	// Fallback to error if action type is invalid
+	default:
+		log.Error().Msgf("the rule %#q of type %#q is not a supported action", action.Name, action.Type)
+	}
+
+	return err
 }
 
 // craftResponse handles format_output to make the final message from the bot user-friendly.
@@ -448,13 +614,13 @@ func craftResponse(rule models.Rule, msg models.Message) (string, error) {
 	}
 
 	// Use FormatOutput as source for output and find variables and replace content the variable exists
-	output, err := text.Substitute(rule.FormatOutput, msg.Vars)
+	output, err := substituteTemplate(rule.FormatOutput, msg.Vars)
 
 	// Check if the value contains html/template code, for advanced formatting
 	if strings.Contains(output, "{{") {
 		var i any
 
-		t, err := template.New("output").Funcs(sprig.FuncMap()).Parse(output)
+		t, err := template.New("output").Funcs(templateFuncs(&msg)).Parse(output)
 		if err != nil {
 			return "", err
 		}
@@ -469,7 +635,7 @@ func craftResponse(rule models.Rule, msg models.Message) (string, error) {
 		output = buf.String()
 	}
 
-	return output, err
+	return unescapeTemplate(output), err
 }
 
 // Handle script execution actions.
@@ -491,6 +657,24 @@ func handleExec(action models.Action, msg *models.Message) error {
 	return nil
 }
 
+// Handle key/value store actions.
+func handleStore(action models.Action, msg *models.Message) error {
+	value, err := handlers.StoreExec(action, msg)
+	if err != nil {
+		return fmt.Errorf("error in store action %#q: %w", action.Name, err)
+	}
+
+	// Set the value of the key to make it accessible in rules
+	name := action.Var
+	if name == "" {
+		name = "_store_value"
+	}
+
+	msg.Vars[name] = value
+
+	return nil
+}
+
 // Handle HTTP call actions.
 func handleHTTP(action models.Action, msg *models.Message) error {
 	if action.URL == "" {
@@ -519,16 +703,16 @@ func handleHTTP(action models.Action, msg *models.Message) error {
 		for k, v := range action.ExposeJSONFields {
 			var t *template.Template
 
-			v, err = text.Substitute(v, msg.Vars)
+			v, err = substituteTemplate(v, msg.Vars)
 			if err != nil {
 				return err
 			}
 
 			// Check if the value contains html/template code
 			if strings.Contains(v, "{{") {
-				t, err = template.New(k).Funcs(sprig.FuncMap()).Parse(v)
+				t, err = template.New(k).Funcs(templateFuncs(msg)).Parse(v)
 			} else {
-				t, err = template.New(k).Funcs(sprig.FuncMap()).Parse(fmt.Sprintf(`{{%s}}`, v))
+				t, err = template.New(k).Funcs(templateFuncs(msg)).Parse(fmt.Sprintf(`{{%s}}`, v))
 			}
 
 			if err != nil {
@@ -542,7 +726,7 @@ func handleHTTP(action models.Action, msg *models.Message) error {
 				return err
 			}
 
-			msg.Vars[k] = html.UnescapeString(buf.String())
+			msg.Vars[k] = unescapeTemplate(html.UnescapeString(buf.String()))
 		}
 	}
 
@@ -550,7 +734,7 @@ func handleHTTP(action models.Action, msg *models.Message) error {
 }
 
 // Handle standard message/logging actions.
-func handleMessage(action models.Action, outputMsgs chan<- models.Message, msg *models.Message, direct, startMsgThread bool, hitRule chan<- models.Rule, bot *models.Bot) error {
+func handleMessage(action models.Action, outputMsgs chan<- models.Envelope, msg *models.Message, direct, startMsgThread bool, bot *models.Bot) error {
 	if action.Message == "" {
 		return fmt.Errorf("no message was set")
 	}
@@ -577,7 +761,7 @@ func handleMessage(action models.Action, outputMsgs chan<- models.Message, msg *
 	if direct && len(action.OutputToRooms) > 0 { // direct=true and limit_to_rooms is specified
 		log.Debug().Msgf("'direct_message_only' is set - 'limit_to_rooms' field on the %#q action will be ignored", action.Name)
 	} else if !direct && len(action.OutputToRooms) > 0 { // direct=false and limit_to_rooms is specified
-		msg.OutputToRooms = chat.GetRoomIDs(action.OutputToRooms, bot)
+		msg.OutputToRooms = chat.GetRoomIDs(msg.Remote, action.OutputToRooms, bot)
 
 		if len(msg.OutputToRooms) == 0 {
 			return errors.New("the rooms defined in 'limit_to_rooms' do not exist")
@@ -590,18 +774,14 @@ func handleMessage(action models.Action, outputMsgs chan<- models.Message, msg *
 	// Set message directive
 	msg.DirectMessageOnly = direct
 	// Send out message
-	outputMsgs <- *msg
-
-	hitRule <- models.Rule{}
+	outputMsgs <- models.Send(*msg)
 
 	return nil
 }
 
-// Handle initial emoji reaction when rule is matched.
-func handleReaction(outputMsgs chan<- models.Message, msg *models.Message, hitRule chan<- models.Rule, rule models.Rule) {
-	outputMsgs <- *msg
-
-	hitRule <- rule
+// Handle emoji reactions of the rule on the message that triggered it.
+func handleReaction(outputMsgs chan<- models.Envelope, msg *models.Message, rule models.Rule, ops models.Op) {
+	outputMsgs <- models.Envelope{Message: *msg, Rule: rule, Ops: ops}
 }
 
 // Update emoji reaction when specified.
@@ -646,31 +826,48 @@ func updateReaction(action models.Action, rule *models.Rule, vars map[string]str
 	}
 }
 
-// parseArgumentsFromRegex parses an input string against a regex rule
-// and returns a map of argument names and their value.
-func parseArgumentsFromRegex(re, input string) map[string]string {
-	args := make(map[string]string)
+// escapedDelim stands in for the template delimiter '{{' in substituted variables.
+const escapedDelim = "\uE000"
 
-	// try compliling the rule as regex, ignoring non-regex rules
-	r, err := regexp.Compile(strings.Trim(re, "/"))
-	if err != nil {
-		return args
+// substituteTemplate substitutes the variables in the value, like 'text.Substitute'.
+// Variables may hold input of chat users, their template delimiters are escaped so
+// they can't inject template code, use 'unescapeTemplate' on the rendered output.
+func substituteTemplate(value string, vars map[string]string) (string, error) {
+	escaped := make(map[string]string, len(vars))
+
+	for k, v := range vars {
+		escaped[k] = strings.ReplaceAll(v, "{{", escapedDelim)
 	}
 
-	// get all capture group names.
-	reNames := r.SubexpNames()
+	return text.Substitute(value, escaped)
+}
 
-	// get all capture group values.
-	reValues := r.FindStringSubmatch(input)
+// unescapeTemplate restores the template delimiters escaped by 'substituteTemplate'.
+func unescapeTemplate(value string) string {
+	return strings.ReplaceAll(value, escapedDelim, "{{")
+}
 
-	// assign capture group key/value to args map.
-	for index, name := range reNames {
-		if index == 0 || name == "" {
-			continue
-		}
+// templateFuncs returns the functions available to templates in rules,
+// the store functions scope keys using the given message.
+func templateFuncs(msg *models.Message) template.FuncMap {
+	funcs := sprig.FuncMap()
+	maps.Copy(funcs, store.FuncMap(msg))
 
-		args[name] = reValues[index]
+	return funcs
+}
+
+// parseArgumentsFromRegex parses an input string against a regex rule
+// and returns a map of argument names and their value.
+func (pc *patternCache) parseArgumentsFromRegex(re, input string) map[string]string {
+	// ignore rules without the pattern, ie. 'hear' of a 'respond' rule
+	if re == "" {
+		return map[string]string{}
+	}
+
+	p, err := pc.compile(re)
+	if err != nil {
+		return map[string]string{}
 	}
 
-	return args
+	return p.Captures(input)
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strconv"
 	"testing"
 
@@ -123,6 +124,52 @@ func TestCraftResponse(t *testing.T) {
 			"hi",
 			true,
 		},
+		{
+			"Successful craft response (with store functions)",
+			args{
+				rule: models.Rule{
+					FormatOutput: `{{ storeSet "channel" "${_test_key}" "3" }}{{ storeIncr "channel" "${_test_key}" 2 }}`,
+				},
+				msg: models.Message{
+					ChannelID: "C123",
+					Vars: map[string]string{
+						"_test_key": "craft_response_count",
+					},
+				},
+			},
+			"5",
+			false,
+		},
+		{
+			"Successful craft response (no template code injected by variables)",
+			args{
+				rule: models.Rule{
+					FormatOutput: `you said ${text}`,
+				},
+				msg: models.Message{
+					Vars: map[string]string{
+						"text": `{{ storeSet "bot" "injected" "yes" }}`,
+					},
+				},
+			},
+			`you said {{ storeSet "bot" "injected" "yes" }}`,
+			false,
+		},
+		{
+			"Successful craft response (no template code injected into templates)",
+			args{
+				rule: models.Rule{
+					FormatOutput: `{{ if true }}you said ${text}{{ end }}`,
+				},
+				msg: models.Message{
+					Vars: map[string]string{
+						"text": `{{ storeSet "bot" "injected" "yes" }}`,
+					},
+				},
+			},
+			`you said {{ storeSet "bot" "injected" "yes" }}`,
+			false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -333,11 +380,10 @@ func TestHandleHTTP(t *testing.T) {
 func TestHandleMessage(t *testing.T) {
 	type args struct {
 		action         models.Action
-		outputMsgs     chan<- models.Message
+		outputMsgs     chan<- models.Envelope
 		msg            *models.Message
 		direct         bool
 		startMsgThread bool
-		hitRule        chan<- models.Rule
 		bot            *models.Bot
 	}
 
@@ -364,7 +410,7 @@ func TestHandleMessage(t *testing.T) {
 	}{
 		{
 			"Send non-direct message",
-			args{*testAction, nil, testMsg, false, false, nil, bot},
+			args{*testAction, nil, testMsg, false, false, bot},
 			[]string{},
 			[]string{"flottbot-room1", "flottbot-room2"},
 			"Message from action",
@@ -373,7 +419,7 @@ func TestHandleMessage(t *testing.T) {
 		},
 		{
 			"Send direct message but limit_to_rooms is set",
-			args{*testAction, nil, testMsg, true, false, nil, bot},
+			args{*testAction, nil, testMsg, true, false, bot},
 			[]string{"flottbot-room1", "flottbot-room2"},
 			[]string{},
 			"Message from action",
@@ -382,7 +428,7 @@ func TestHandleMessage(t *testing.T) {
 		},
 		{
 			"Send non-direct message but limit_to_rooms is set",
-			args{*testAction, nil, testMsg, true, false, nil, bot},
+			args{*testAction, nil, testMsg, true, false, bot},
 			[]string{"flottbot-room1", "flottbot-room2"},
 			[]string{},
 			"Message from action",
@@ -391,7 +437,7 @@ func TestHandleMessage(t *testing.T) {
 		},
 		{
 			"Send non-direct message but limit_to_rooms is not set",
-			args{*testAction, nil, testMsg, true, false, nil, bot},
+			args{*testAction, nil, testMsg, true, false, bot},
 			[]string{},
 			[]string{},
 			"Message from action",
@@ -400,7 +446,7 @@ func TestHandleMessage(t *testing.T) {
 		},
 		{
 			"Send non-direct start-thread message",
-			args{*testAction, nil, testMsg, false, true, nil, bot},
+			args{*testAction, nil, testMsg, false, true, bot},
 			[]string{},
 			[]string{},
 			"Message from action",
@@ -409,7 +455,7 @@ func TestHandleMessage(t *testing.T) {
 		},
 		{
 			"Empty action message",
-			args{*testAction, nil, testMsg, false, false, nil, bot},
+			args{*testAction, nil, testMsg, false, false, bot},
 			[]string{},
 			[]string{"flottbot-room1", "flottbot-room2"},
 			"",
@@ -418,7 +464,7 @@ func TestHandleMessage(t *testing.T) {
 		},
 		{
 			"Error on Substitute()",
-			args{*testAction, nil, testMsg, false, false, nil, bot},
+			args{*testAction, nil, testMsg, false, false, bot},
 			[]string{},
 			[]string{"flottbot-room1", "flottbot-room2"},
 			"${NOT_A_REAL_VAR}",
@@ -427,7 +473,7 @@ func TestHandleMessage(t *testing.T) {
 		},
 		{
 			"Rooms in limit_to_rooms don't exist",
-			args{*testAction, nil, testMsg, false, false, nil, bot},
+			args{*testAction, nil, testMsg, false, false, bot},
 			[]string{"test", "test2"},
 			[]string{},
 			"Message",
@@ -438,9 +484,7 @@ func TestHandleMessage(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Set test variables
-			var testOutputMsgs chan models.Message
-
-			var testHitRule chan models.Rule
+			var testOutputMsgs chan models.Envelope
 
 			tt.args.action.LimitToRooms = tt.wantLimitToRooms
 			tt.args.action.Message = tt.wantActionMessage
@@ -448,13 +492,11 @@ func TestHandleMessage(t *testing.T) {
 			tt.args.msg.Output = tt.wantOutputMessage
 
 			if !tt.wantErr { // all happy paths (i.e. no errors) go here
-				testOutputMsgs = make(chan models.Message, 1)
-				testHitRule = make(chan models.Rule, 1)
+				testOutputMsgs = make(chan models.Envelope, 1)
 				tt.args.outputMsgs = testOutputMsgs
-				tt.args.hitRule = testHitRule
 			}
 			// Do test
-			err := handleMessage(tt.args.action, tt.args.outputMsgs, tt.args.msg, tt.args.direct, tt.args.startMsgThread, tt.args.hitRule, tt.args.bot)
+			err := handleMessage(tt.args.action, tt.args.outputMsgs, tt.args.msg, tt.args.direct, tt.args.startMsgThread, tt.args.bot)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("handleMessage() error = %v, wantErr %v", err, tt.wantErr)
 			} else if (err == nil) == tt.wantErr {
@@ -463,8 +505,8 @@ func TestHandleMessage(t *testing.T) {
 
 			if !tt.wantErr { // all happy paths (i.e. no errors) go here // this is actually pretty dangerous - this could cause a blocking wait and a panic... maybe look to test this better
 				resultMsg := <-testOutputMsgs
-				if tt.wantOutputMessage != resultMsg.Output {
-					t.Errorf("handleMessage() wanted message \"%s\", but got \"%s\"", tt.wantOutputMessage, resultMsg.Output)
+				if tt.wantOutputMessage != resultMsg.Message.Output {
+					t.Errorf("handleMessage() wanted message \"%s\", but got \"%s\"", tt.wantOutputMessage, resultMsg.Message.Output)
 				}
 			}
 		})
@@ -473,15 +515,13 @@ func TestHandleMessage(t *testing.T) {
 
 func TestHandleReaction(t *testing.T) {
 	type args struct {
-		outputMsgs chan<- models.Message
+		outputMsgs chan<- models.Envelope
 		msg        *models.Message
-		hitRule    chan<- models.Rule
 		rule       models.Rule
 	}
 
 	// Init test variables
-	testOutputMsgs := make(chan models.Message, 1)
-	testHitRule := make(chan models.Rule, 1)
+	testOutputMsgs := make(chan models.Envelope, 1)
 	testMsg := new(models.Message)
 	testRule := new(models.Rule)
 
@@ -492,7 +532,7 @@ func TestHandleReaction(t *testing.T) {
 		wantRuleName string
 	}{
 		"Test handleReaction()",
-		args{testOutputMsgs, testMsg, testHitRule, *testRule},
+		args{testOutputMsgs, testMsg, *testRule},
 		"test output",
 		"test rule",
 	}
@@ -501,17 +541,20 @@ func TestHandleReaction(t *testing.T) {
 		test.args.msg.Output = test.wantMessage
 		test.args.rule.Name = test.wantRuleName
 		// Do test
-		handleReaction(test.args.outputMsgs, test.args.msg, test.args.hitRule, test.args.rule)
+		handleReaction(test.args.outputMsgs, test.args.msg, test.args.rule, models.OpReact)
+
+		result := <-testOutputMsgs
 
-		resultMsg := <-testOutputMsgs
-		resultRule := <-testHitRule
+		if test.wantMessage != result.Message.Output {
+			t.Errorf("handReaction() wanted message \"%s\", but got \"%s\"", test.wantMessage, result.Message.Output)
+		}
 
-		if test.wantMessage != resultMsg.Output {
-			t.Errorf("handReaction() wanted message \"%s\", but got \"%s\"", test.wantMessage, resultMsg.Output)
+		if result.Has(models.OpSend) || !result.Has(models.OpReact) {
+			t.Errorf("handReaction() wanted only the reaction operation, but got %b", result.Ops)
 		}
 
-		if test.wantRuleName != resultRule.Name {
-			t.Errorf("handReaction() wanted rule %#q, but got %#q", test.wantRuleName, resultRule.Name)
+		if test.wantRuleName != result.Rule.Name {
+			t.Errorf("handReaction() wanted rule %#q, but got %#q", test.wantRuleName, result.Rule.Name)
 		}
 	})
 }
@@ -591,6 +634,10 @@ func Test_getProccessedInputAndHitValue(t *testing.T) {
 		messageReactionRemoved string
 		ruleReactionAdded      string
 		ruleReactionRemoved    string
+		messageBlockAction     string
+		messageViewSubmission  string
+		ruleBlockActions       string
+		ruleViewSubmission     string
 	}
 
 	tests := []struct {
@@ -599,12 +646,15 @@ func Test_getProccessedInputAndHitValue(t *testing.T) {
 		want  string
 		want1 bool
 	}{
-		{"hit", args{"hello foo", "hello", "hello", "", "", "", ""}, "foo", true},
-		{"hit no hear value", args{"hello foo", "hello", "", "", "", "", ""}, "foo", true},
-		{"hit no respond value - drops args", args{"hello foo", "", "hello", "", "", "", ""}, "", true},
-		{"no match", args{"hello foo", "", "", "", "", "", ""}, "", false},
-		{"hit reaction added", args{"", "", "", "hello", "", "hello", ""}, "hello", true},
-		{"hit reaction removed", args{"", "", "", "", "hello", "", "hello"}, "hello", true},
+		{"hit", args{"hello foo", "hello", "hello", "", "", "", "", "", "", "", ""}, "foo", true},
+		{"hit no hear value", args{"hello foo", "hello", "", "", "", "", "", "", "", "", ""}, "foo", true},
+		{"hit no respond value - drops args", args{"hello foo", "", "hello", "", "", "", "", "", "", "", ""}, "", true},
+		{"no match", args{"hello foo", "", "", "", "", "", "", "", "", "", ""}, "", false},
+		{"hit reaction added", args{"", "", "", "hello", "", "hello", "", "", "", "", ""}, "hello", true},
+		{"hit reaction removed", args{"", "", "", "", "hello", "", "hello", "", "", "", ""}, "hello", true},
+		{"hit block action", args{"", "", "", "", "", "", "", "approve", "", "approve|deny", ""}, "approve", true},
+		{"miss block action", args{"", "", "", "", "", "", "", "other", "", "approve|deny", ""}, "", false},
+		{"hit view submission", args{"", "", "", "", "", "", "", "", "request_access", "", "request_access"}, "request_access", true},
 	}
 
 	for _, tt := range tests {
@@ -614,15 +664,19 @@ func Test_getProccessedInputAndHitValue(t *testing.T) {
 				Respond:          tt.args.ruleRespondValue,
 				ReactionsAdded:   tt.args.ruleReactionAdded,
 				ReactionsRemoved: tt.args.ruleReactionRemoved,
+				BlockActions:     tt.args.ruleBlockActions,
+				ViewSubmission:   tt.args.ruleViewSubmission,
 			}
 
 			message := models.Message{
 				Input:           tt.args.messageInput,
 				ReactionAdded:   tt.args.messageReactionAdded,
 				ReactionRemoved: tt.args.messageReactionRemoved,
+				BlockAction:     tt.args.messageBlockAction,
+				ViewSubmission:  tt.args.messageViewSubmission,
 			}
 
-			got, got1 := getProccessedInputAndHitValue(message, rule)
+			got, got1 := newMatcherState(new(models.Bot)).getProccessedInputAndHitValue(message, rule)
 
 			if got != tt.want {
 				t.Errorf("getProccessedInputAndHitValue() got = %v, want %v", got, tt.want)
@@ -670,7 +724,7 @@ func Test_isValidHitChatRule(t *testing.T) {
 	testRuleNeedArg := models.Rule{}
 	testRuleNeedArg.Respond = "stuff"
 	testRuleNeedArg.AllowUsers = []string{"fooUser"}
-	testRuleNeedArg.Args = []string{"arg1", "arg2"}
+	testRuleNeedArg.Args = models.NewArgs("arg1", "arg2")
 	testMessageNeedArg := new(models.Message)
 	needArgVars := make(map[string]string)
 	needArgVars["_user.name"] = "fooUser"
@@ -679,7 +733,7 @@ func Test_isValidHitChatRule(t *testing.T) {
 	testRuleArgs := models.Rule{}
 	testRuleArgs.Respond = "stuff"
 	testRuleArgs.AllowUsers = []string{"fooUser"}
-	testRuleArgs.Args = []string{"arg1", "arg2"}
+	testRuleArgs.Args = models.NewArgs("arg1", "arg2")
 	testMessageArgs := new(models.Message)
 	argsVars := make(map[string]string)
 	argsVars["_user.name"] = "fooUser"
@@ -698,7 +752,7 @@ func Test_isValidHitChatRule(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := isValidHitChatRule(tt.args.message, tt.args.rule, tt.args.processedInput, tt.args.bot); got != tt.want {
+			if got := newMatcherState(new(models.Bot)).isValidHitChatRule(tt.args.message, tt.args.rule, tt.args.processedInput, tt.args.bot); got != tt.want {
 				t.Errorf("isValidHitChatRule() = %v, want %v", got, tt.want)
 			}
 		})
@@ -707,9 +761,8 @@ func Test_isValidHitChatRule(t *testing.T) {
 
 func Test_handleChatServiceRule(t *testing.T) {
 	type args struct {
-		outputMsgs     chan<- models.Message
+		outputMsgs     chan<- models.Envelope
 		message        models.Message
-		hitRule        chan<- models.Rule
 		rule           models.Rule
 		processedInput string
 		hit            bool
@@ -719,28 +772,28 @@ func Test_handleChatServiceRule(t *testing.T) {
 	rule := models.Rule{
 		Name:     "Test Rule",
 		Respond:  "foo",
-		Args:     []string{"arg1", "arg2"},
+		Args:     models.NewArgs("arg1", "arg2"),
 		HelpText: "foo <arg1> <arg2>",
 	}
 
 	ruleOpt := models.Rule{
 		Name:     "Test Rule with optional arg",
 		Respond:  "foo",
-		Args:     []string{"arg1", "arg2?"},
+		Args:     models.NewArgs("arg1", "arg2?"),
 		HelpText: "foo <arg1> <arg2>",
 	}
 
 	ruleVarg := models.Rule{
 		Name:     "Test Rules with varargs",
 		Respond:  "foo",
-		Args:     []string{"arg1", "argv+"},
+		Args:     models.NewArgs("arg1", "argv+"),
 		HelpText: "foo <arg1> <argv...>",
 	}
 
 	ruleHearWithArgs := models.Rule{
 		Name: "Hear rule with Args set",
 		Hear: "/hi/",
-		Args: []string{"arg1", "arg2"},
+		Args: models.NewArgs("arg1", "arg2"),
 	}
 
 	ruleIgnoreThread := models.Rule{
@@ -844,17 +897,15 @@ func Test_handleChatServiceRule(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			testOutput := make(chan models.Message, 1)
-			testHitRule := make(chan models.Rule, 1)
-			tt.args.hitRule = testHitRule
+			testOutput := make(chan models.Envelope, 1)
 			tt.args.outputMsgs = testOutput
 
-			got, got1 := handleChatServiceRule(tt.args.outputMsgs, tt.args.message, tt.args.hitRule, tt.args.rule, tt.args.processedInput, tt.args.hit, tt.args.bot)
+			got, got1 := newMatcherState(new(models.Bot)).handleChatServiceRule(tt.args.outputMsgs, tt.args.message, tt.args.rule, tt.args.processedInput, tt.args.hit, tt.args.bot)
 
 			select {
 			case output := <-testOutput:
-				if tt.expectMsg != output.Output {
-					t.Errorf("Output message didn't match, got = %v, want %v", output.Output, tt.expectMsg)
+				if tt.expectMsg != output.Message.Output {
+					t.Errorf("Output message didn't match, got = %v, want %v", output.Message.Output, tt.expectMsg)
 				}
 
 				if got != tt.want {
@@ -883,11 +934,29 @@ func Test_handleChatServiceRule(t *testing.T) {
 	}
 }
 
+func Test_handleChatServiceRule_missingVariadicArg(t *testing.T) {
+	rule := models.Rule{Name: "say", Respond: "say", Args: models.NewArgs("msg+"), FormatOutput: "${msg}"}
+	message := models.Message{Input: "say", Vars: map[string]string{}, BotMentioned: true}
+	outputMsgs := make(chan models.Envelope, 1)
+
+	bot := new(models.Bot)
+
+	newMatcherState(bot).handleChatServiceRule(outputMsgs, message, rule, "", true, bot)
+
+	select {
+	case env := <-outputMsgs:
+		if want := "you might be missing an argument or two - this is what i'm looking for\n```say <msg...>```"; env.Message.Output != want {
+			t.Errorf("handleChatServiceRule() output = %q, want %q", env.Message.Output, want)
+		}
+	default:
+		t.Errorf("handleChatServiceRule() expected a reply that the argument is missing")
+	}
+}
+
 func Test_handleSchedulerServiceRule(t *testing.T) {
 	type args struct {
-		outputMsgs chan<- models.Message
+		outputMsgs chan<- models.Envelope
 		message    models.Message
-		hitRule    chan<- models.Rule
 		rule       models.Rule
 		bot        *models.Bot
 	}
@@ -899,7 +968,7 @@ func Test_handleSchedulerServiceRule(t *testing.T) {
 		Name:          "TestSchedule",
 		Respond:       "foo",
 		FormatOutput:  "Hello, from Scheduler 1!",
-		Args:          []string{"arg1"},
+		Args:          models.NewArgs("arg1"),
 		Active:        true,
 		OutputToRooms: []string{"test-room1"},
 	}
@@ -920,7 +989,10 @@ func Test_handleSchedulerServiceRule(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, got1 := handleSchedulerServiceRule(tt.args.outputMsgs, tt.args.message, tt.args.hitRule, tt.args.rule, tt.args.bot)
+			// the actions of a hit rule run on a worker, which needs somewhere to send the output
+			tt.args.outputMsgs = make(chan models.Envelope, 1)
+
+			got, got1 := newMatcherState(new(models.Bot)).handleSchedulerServiceRule(tt.args.outputMsgs, tt.args.message, tt.args.rule, tt.args.bot)
 			if got != tt.want {
 				t.Errorf("handleSchedulerServiceRule() got = %v, want %v", got, tt.want)
 			}
@@ -934,9 +1006,8 @@ func Test_handleSchedulerServiceRule(t *testing.T) {
 
 func Test_handleNoMatch(t *testing.T) {
 	type args struct {
-		outputMsgs chan<- models.Message
+		outputMsgs chan<- models.Envelope
 		message    models.Message
-		hitRule    chan<- models.Rule
 		rules      map[string]models.Rule
 		bot        *models.Bot
 	}
@@ -973,17 +1044,15 @@ func Test_handleNoMatch(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			testOutput := make(chan models.Message, 1)
-			testHitRule := make(chan models.Rule, 1)
+			testOutput := make(chan models.Envelope, 1)
 			tt.args.outputMsgs = testOutput
-			tt.args.hitRule = testHitRule
 
-			handleNoMatch(tt.args.outputMsgs, tt.args.message, tt.args.hitRule, tt.args.rules, tt.args.bot)
+			handleNoMatch(tt.args.outputMsgs, tt.args.message, tt.args.rules, tt.args.bot)
 
 			select {
 			case output := <-testOutput:
-				if tt.wantHelpText != output.Output {
-					t.Errorf("handleSchedulerServiceRule() wanted helpText to be = %s, but got %s", tt.wantHelpText, output.Output)
+				if tt.wantHelpText != output.Message.Output {
+					t.Errorf("handleSchedulerServiceRule() wanted helpText to be = %s, but got %s", tt.wantHelpText, output.Message.Output)
 				}
 			// Channel empty
 			default:
@@ -999,9 +1068,8 @@ func Test_handleNoMatch(t *testing.T) {
 func Test_doRuleActions(t *testing.T) {
 	type args struct {
 		message    models.Message
-		outputMsgs chan<- models.Message
+		outputMsgs chan<- models.Envelope
 		rule       models.Rule
-		hitRule    chan<- models.Rule
 		bot        *models.Bot
 	}
 
@@ -1127,170 +1195,105 @@ func Test_doRuleActions(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			testOutput := make(chan models.Message, 1)
-			testHitRule := make(chan models.Rule, 1)
-			tt.args.hitRule = testHitRule
+			testOutput := make(chan models.Envelope, 1)
 			tt.args.outputMsgs = testOutput
 
-			doRuleActions(tt.args.message, tt.args.outputMsgs, tt.args.rule, tt.args.hitRule, tt.args.bot)
+			doRuleActions(tt.args.message, tt.args.outputMsgs, tt.args.rule, tt.args.bot)
 
 			output := <-testOutput
 
-			if output.Output != tt.expectedMessage {
-				t.Errorf("Message expected to be: %s, but got: %s", tt.expectedMessage, output.Output)
+			if output.Message.Output != tt.expectedMessage {
+				t.Errorf("Message expected to be: %s, but got: %s", tt.expectedMessage, output.Message.Output)
 			}
 		})
 	}
 }
 
-func Test_matcherLoop(t *testing.T) {
-	type args struct {
-		message    models.Message
-		outputMsgs chan<- models.Message
-		rules      map[string]models.Rule
-		hitRule    chan<- models.Rule
-		bot        *models.Bot
-	}
-
+func Test_doRuleActions_errorHandling(t *testing.T) {
 	testBot := new(models.Bot)
-	testRules := make(map[string]models.Rule)
-	testRule := models.Rule{}
-	testRules["test"] = testRule
-	testMessage := models.Message{Input: "Hi there!", BotMentioned: true}
 
-	testMessage2 := models.Message{
-		Service:      models.MsgServiceChat,
-		Input:        "foo test",
-		BotMentioned: true,
-		Vars:         make(map[string]string),
-	}
-	testRules2 := make(map[string]models.Rule)
-	testRule2 := models.Rule{
-		Active:        true,
-		Respond:       "foo",
-		Args:          []string{"arg1"},
-		IncludeInHelp: true,
-		HelpText:      "foo arg1",
-		FormatOutput:  "output is foo ${arg1}",
-	}
-	testRules2["test"] = testRule2
-
-	testMsgAttributes3 := make(map[string]string)
-	testMsgAttributes3["from_schedule"] = "test-schedule"
-	testMessage3 := models.Message{
-		Service:      models.MsgServiceScheduler,
-		Input:        "@every 5s",
-		Attributes:   testMsgAttributes3,
-		BotMentioned: true,
-		Vars:         make(map[string]string),
-	}
-	testRules3 := make(map[string]models.Rule)
-	testRule3 := models.Rule{
-		Active:        true,
-		Schedule:      "@every 5s",
-		Name:          "test-schedule",
-		Args:          []string{},
-		IncludeInHelp: true,
-		HelpText:      "foo arg1",
-		FormatOutput:  "Hello, from Scheduler 1!",
-	}
-	testRules3["test"] = testRule3
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	ok := models.Action{Name: "ok", Type: "exec", Cmd: `echo "ok"`}
+	fail := models.Action{Name: "fail", Type: "exec", Cmd: `false`}
+	failHTTP := models.Action{Name: "fail http", Type: "get", URL: ts.URL}
+	notify := models.Action{Name: "notify", Type: "message", Message: "${_error_action} failed"}
+
+	failAndStop := fail
+	failAndStop.StopOnError = true
+
+	failWithHandler := fail
+	failWithHandler.OnError = []models.Action{notify}
+
+	failWithHandlerAndStop := failWithHandler
+	failWithHandlerAndStop.StopOnError = true
+
+	onlyOnSuccess := models.Action{Name: "only on success", Type: "exec", Cmd: `echo "ran"`, When: `${_raw_http_status} == 200`}
+	onlyOnFailure := models.Action{Name: "only on failure", Type: "exec", Cmd: `echo "ran"`, When: `${_raw_http_status} >= 500`}
+	invalidWhen := models.Action{Name: "invalid", Type: "exec", Cmd: `echo "ran"`, When: `${_raw_http_status} ==`}
 
 	tests := []struct {
-		name           string
-		args           args
-		expectedOutput string
+		name      string
+		actions   []models.Action
+		onFailure []models.Action
+		format    string
+		outputs   []string
 	}{
-		{"No Rule Match", args{message: testMessage, rules: testRules, bot: testBot}, "I understand these commands: \n"},
-		{"Chat rule, no actions", args{message: testMessage2, rules: testRules2, bot: testBot}, "output is foo test"},
-		{"Scheduler rule, no actions", args{message: testMessage3, rules: testRules3, bot: testBot}, "Hello, from Scheduler 1!"},
+		{"Condition met", []models.Action{failHTTP, onlyOnFailure}, nil, "${_exec_output}", []string{"ran"}},
+		{"Condition not met", []models.Action{failHTTP, onlyOnSuccess}, nil, "status ${_raw_http_status}", []string{"status 500"}},
+		{"Invalid condition fails the action", []models.Action{ok, invalidWhen}, nil, "${_exec_output}", []string{"ok"}},
+		{"Errors don't stop the actions by default", []models.Action{fail, ok}, nil, "${_exec_output}", []string{"ok"}},
+		{"Stop on error", []models.Action{failAndStop, ok}, nil, "${_exec_output}", []string{"exit status 1"}},
+		{"On error", []models.Action{failWithHandler, ok}, nil, "${_exec_output}", []string{"fail failed", "ok"}},
+		{"On error and stop", []models.Action{failWithHandlerAndStop, ok}, nil, "${_exec_output}", []string{"fail failed"}},
+		{"On failure", []models.Action{fail, ok}, []models.Action{notify}, "${_exec_output}", []string{"fail failed"}},
+		{"On failure after stop", []models.Action{ok, failAndStop, ok}, []models.Action{notify}, "${_exec_output}", []string{"fail failed"}},
+		{"No failure", []models.Action{ok}, []models.Action{notify}, "${_exec_output}", []string{"ok"}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			testOutput := make(chan models.Message, 1)
-			testHitRule := make(chan models.Rule, 1)
+			testOutput := make(chan models.Envelope, 5)
 
-			tt.args.outputMsgs = testOutput
-			tt.args.hitRule = testHitRule
+			rule := models.Rule{Name: "test", Actions: tt.actions, OnFailure: tt.onFailure, FormatOutput: tt.format}
 
-			matcherLoop(tt.args.message, tt.args.outputMsgs, tt.args.rules, tt.args.hitRule, tt.args.bot)
+			message := models.Message{Service: models.MsgServiceChat, Vars: make(map[string]string)}
 
-			output := <-testOutput
-			if output.Output != tt.expectedOutput {
-				t.Errorf("Message expected to be: %s, but got: %s", tt.expectedOutput, output.Output)
+			doRuleActions(message, testOutput, rule, testBot)
+			close(testOutput)
+
+			got := []string{}
+			for msg := range testOutput {
+				got = append(got, msg.Message.Output)
+			}
+
+			if !slices.Equal(got, tt.outputs) {
+				t.Errorf("doRuleActions() outputs = %q, want %q", got, tt.outputs)
 			}
 		})
 	}
 }
 
-func Test_captureGroups(t *testing.T) {
-	type args struct {
-		message    models.Message
-		outputMsgs chan<- models.Message
-		rules      map[string]models.Rule
-		hitRule    chan<- models.Rule
-		bot        *models.Bot
-	}
-
-	testBot := new(models.Bot)
-
-	testMessage1 := models.Message{
-		Service:      models.MsgServiceChat,
-		Input:        "deploy flottbot@v0.0.1 to qa",
-		Attributes:   make(map[string]string),
-		BotMentioned: true,
-		Vars:         make(map[string]string),
-	}
-	testRules1 := make(map[string]models.Rule)
-	testRule1 := models.Rule{
-		Name:          "testmatch",
-		Active:        true,
-		Respond:       "/deploy (?P<application>.*)@(?P<version>.*) to (?P<environment>.*)/",
-		IncludeInHelp: true,
-		HelpText:      "hello <application>@<version> to <environment>",
-		FormatOutput:  "deploying ${application} at version ${version} to environment ${environment}",
-	}
-	testRules1["test"] = testRule1
-
-	testMessage2 := models.Message{
-		Service:      models.MsgServiceChat,
-		Input:        "example ticket XYZ-123 should be heard",
-		BotMentioned: false,
-		Vars:         make(map[string]string),
-	}
-	testRules2 := make(map[string]models.Rule)
-	testRule2 := models.Rule{
-		Name:          "testmatch",
-		Active:        true,
-		Hear:          "/(?P<ticket>[A-Z]+-[0-9]+)/",
-		Args:          []string{},
-		IncludeInHelp: true,
-		HelpText:      "",
-		FormatOutput:  "response with ${ticket} details",
-	}
-	testRules2["test"] = testRule2
-
+func Test_isAnswer(t *testing.T) {
 	tests := []struct {
-		name           string
-		args           args
-		expectedOutput string
+		name    string
+		message models.Message
+		want    bool
 	}{
-		{"Capture groups, respond", args{message: testMessage1, rules: testRules1, bot: testBot}, "deploying flottbot at version v0.0.1 to environment qa"},
-		{"Capture groups, hear", args{message: testMessage2, rules: testRules2, bot: testBot}, "response with XYZ-123 details"},
+		{"Chat message", models.Message{Service: models.MsgServiceChat, Input: "prod"}, true},
+		{"CLI message", models.Message{Service: models.MsgServiceCLI, Input: "prod"}, true},
+		{"Scheduled message", models.Message{Service: models.MsgServiceScheduler, Input: "prod"}, false},
+		{"Empty input", models.Message{Service: models.MsgServiceChat, Input: " "}, false},
+		{"Reaction added", models.Message{Service: models.MsgServiceChat, Input: "prod", ReactionAdded: "thumbsup"}, false},
+		{"Reaction removed", models.Message{Service: models.MsgServiceChat, Input: "prod", ReactionRemoved: "thumbsup"}, false},
+		{"Block action", models.Message{Service: models.MsgServiceChat, Input: "prod", BlockAction: "approve"}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			testOutput := make(chan models.Message, 1)
-			testHitRule := make(chan models.Rule, 1)
-
-			tt.args.outputMsgs = testOutput
-			tt.args.hitRule = testHitRule
-
-			matcherLoop(tt.args.message, tt.args.outputMsgs, tt.args.rules, tt.args.hitRule, tt.args.bot)
-
-			output := <-testOutput
-			if output.Output != tt.expectedOutput {
-				t.Errorf("Message expected to be: %s, but got: %s", tt.expectedOutput, output.Output)
+			if got := isAnswer(tt.message); got != tt.want {
+				t.Errorf("isAnswer() = %v, want %v", got, tt.want)
 			}
 		})
 	}
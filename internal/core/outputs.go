@@ -4,81 +4,92 @@ package core
 
 import (
 	"strings"
+	"sync"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/remote"
 	"github.com/target/flottbot/internal/remote/cli"
-	"github.com/target/flottbot/internal/remote/discord"
-	"github.com/target/flottbot/internal/remote/gchat"
-	"github.com/target/flottbot/internal/remote/mattermost"
-	"github.com/target/flottbot/internal/remote/slack"
-	"github.com/target/flottbot/internal/remote/telegram"
 )
 
 // Outputs determines where messages are output based on fields set in the bot.yml
-// TODO: Refactor to keep remote specifics in remote/.
-func Outputs(outputMsgs <-chan models.Message, hitRule <-chan models.Rule, bot *models.Bot) {
-	for {
-		message := <-outputMsgs
-		rule := <-hitRule
+// and the chat application the message was read from. Each envelope is handled as one unit,
+// so reactions are always applied for the rule that produced the message. The messages are sent by a fixed number
+// of senders, all messages to a channel go to the same sender to keep their order, so a slow
+// chat application only holds up the channels of its sender. Once outputMsgs is closed,
+// Outputs waits for the senders to send what is left and returns.
+func Outputs(outputMsgs <-chan models.Envelope, bot *models.Bot) {
+	chatRemotes := make(map[string]remote.Remote)
+
+	var wg sync.WaitGroup
+
+	senders := make([]chan func(), workerCount(bot))
+	for i := range senders {
+		sends := make(chan func(), QueueSize(bot))
+		senders[i] = sends
+
+		wg.Go(func() {
+			for send := range sends {
+				send()
+			}
+		})
+	}
+
+	for env := range outputMsgs {
+		message := env.Message
 		service := message.Service
 
+		var send func()
+
 		switch service {
 		case models.MsgServiceChat, models.MsgServiceScheduler:
-			chatApp := strings.ToLower(bot.ChatApplication)
-
-			switch chatApp {
-			case models.ChatAppDiscord:
-				if service == models.MsgServiceScheduler {
-					log.Warn().Msg("scheduler does not currently support discord")
-					break
-				}
-
-				remoteDiscord := &discord.Client{Token: bot.DiscordToken}
-				remoteDiscord.Reaction(message, rule, bot)
-				remoteDiscord.Send(message, bot)
-			case models.ChatAppMattermost:
-				remoteMM := &mattermost.Client{
-					Server: bot.MatterMostServer,
-					Token:  bot.MatterMostToken,
-				}
-				if strings.ToLower(bot.MatterMostInsecureProtocol) == "1" {
-					remoteMM.Insecure = true
-				}
-
-				remoteMM.Send(message, bot)
-			case models.ChatAppSlack:
-				// Create Slack client
-				remoteSlack := &slack.Client{
-					ListenerPort:  bot.SlackListenerPort,
-					Token:         bot.SlackToken,
-					AppToken:      bot.SlackAppToken,
-					SigningSecret: bot.SlackSigningSecret,
-				}
-
-				if service == models.MsgServiceChat {
-					remoteSlack.Reaction(message, rule, bot)
-				}
-
-				remoteSlack.Send(message, bot)
-			case models.ChatAppTelegram:
-				remoteTelegram := &telegram.Client{
-					Token: bot.TelegramToken,
-				}
-				remoteTelegram.Send(message, bot)
-			case models.ChatAppGoogleChat:
-				gchat.HandleRemoteOutput(message, bot)
-			default:
+			// reply on the chat application the message came from,
+			// scheduled messages go out on the primary chat application
+			chatApp := strings.ToLower(message.Remote)
+			if chatApp == "" {
+				chatApp = primaryChatApplication(bot)
+			}
+
+			reg, ok := remote.Lookup(chatApp)
+			if !ok {
 				log.Error().Msgf("chat application %#q is not supported", chatApp)
+				break
+			}
+
+			// reuse the client for the chat application across messages
+			r, ok := chatRemotes[chatApp]
+			if !ok {
+				r = reg.New(bot)
+				chatRemotes[chatApp] = r
 			}
+
+			send = func() { reg.SendOutput(r, env, bot) }
 		case models.MsgServiceCLI:
-			remoteCLI := &cli.Client{}
-			remoteCLI.Send(message, bot)
+			if !env.Has(models.OpSend) {
+				break
+			}
+
+			send = func() {
+				remoteCLI := &cli.Client{}
+				remoteCLI.Send(message, bot)
+			}
 		case models.MsgServiceUnknown:
 			log.Error().Msg("found unknown service")
 		default:
 			log.Error().Msg("no service found")
 		}
+
+		if send != nil {
+			senders[shard(channelKey(message, message.Remote), len(senders))] <- send
+		}
+	}
+
+	for _, sends := range senders {
+		close(sends)
 	}
+
+	wg.Wait()
+
+	log.Info().Msg("sent all pending messages")
 }
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/remote"
+)
+
+// recordingRemote records what is sent and which reactions are applied to which message.
+type recordingRemote struct {
+	mu        sync.Mutex
+	sent      map[string]string // input of the message that triggered the rule -> output
+	reactions map[string]string // input of the message that triggered the rule -> reaction
+}
+
+func (r *recordingRemote) Name() string { return "recording" }
+
+func (*recordingRemote) Read(context.Context, chan<- models.Message, *models.RuleSet, *models.Bot) {}
+
+func (r *recordingRemote) Send(message models.Message, _ *models.Bot) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sent[message.Input] = message.Output
+}
+
+func (r *recordingRemote) Reaction(message models.Message, rule models.Rule, _ *models.Bot) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reactions[message.Input] = rule.Reaction
+}
+
+func (r *recordingRemote) counts() (int, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.sent), len(r.reactions)
+}
+
+func Test_Outputs_concurrentRules(t *testing.T) {
+	const hits = 50
+
+	rec := &recordingRemote{sent: map[string]string{}, reactions: map[string]string{}}
+
+	remote.Register(rec.Name(), remote.Registration{New: func(_ *models.Bot) remote.Remote { return rec }})
+	defer remote.Unregister(rec.Name())
+
+	bot := new(models.Bot)
+	rules := map[string]models.Rule{}
+
+	for i := range hits {
+		rules[fmt.Sprintf("rule%d.yml", i)] = models.Rule{
+			Name:         fmt.Sprintf("rule %d", i),
+			Active:       true,
+			Respond:      fmt.Sprintf("cmd%d", i),
+			Reaction:     fmt.Sprintf("reaction%d", i),
+			FormatOutput: fmt.Sprintf("output%d", i),
+		}
+	}
+
+	inputMsgs := make(chan models.Message, hits)
+	outputMsgs := make(chan models.Envelope, hits)
+
+	for i := range hits {
+		message := models.NewMessage()
+		message.Service = models.MsgServiceChat
+		message.Remote = rec.Name()
+		message.ChannelID = fmt.Sprintf("C%d", i)
+		message.Input = fmt.Sprintf("cmd%d", i)
+		message.BotMentioned = true
+
+		inputMsgs <- message
+	}
+
+	close(inputMsgs)
+
+	// the rules run concurrently on the workers, the outputs are closed once all of them are done
+	go Matcher(inputMsgs, outputMsgs, models.NewRuleSet(rules), bot)
+
+	Outputs(outputMsgs, bot)
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if len(rec.sent) != hits || len(rec.reactions) != hits {
+		t.Fatalf("Outputs() sent %d messages and %d reactions, want %d each", len(rec.sent), len(rec.reactions), hits)
+	}
+
+	for input, output := range rec.sent {
+		if want := strings.Replace(input, "cmd", "output", 1); output != want {
+			t.Errorf("Outputs() sent %#q in reply to %#q, want %#q", output, input, want)
+		}
+	}
+
+	for input, reaction := range rec.reactions {
+		if want := strings.Replace(input, "cmd", "reaction", 1); reaction != want {
+			t.Errorf("Outputs() reacted %#q to %#q, want %#q", reaction, input, want)
+		}
+	}
+}
+
+func Test_Outputs_flush(t *testing.T) {
+	rec := &recordingRemote{sent: map[string]string{}, reactions: map[string]string{}}
+
+	remote.Register(rec.Name(), remote.Registration{New: func(_ *models.Bot) remote.Remote { return rec }})
+	defer remote.Unregister(rec.Name())
+
+	outputMsgs := make(chan models.Envelope, 10)
+
+	for i := range 10 {
+		message := models.NewMessage()
+		message.Service = models.MsgServiceChat
+		message.Remote = rec.Name()
+		message.Input = fmt.Sprintf("cmd%d", i)
+		message.Output = fmt.Sprintf("output%d", i)
+
+		outputMsgs <- models.Send(message)
+	}
+
+	close(outputMsgs)
+
+	// returns once all messages are sent
+	Outputs(outputMsgs, new(models.Bot))
+
+	if sent, _ := rec.counts(); sent != 10 {
+		t.Errorf("Outputs() sent %d messages before returning, want 10", sent)
+	}
+}
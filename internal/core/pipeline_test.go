@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/target/flottbot/flottbottest"
+)
+
+func TestMatcher(t *testing.T) {
+	foo := flottbottest.Rule{
+		Active:        true,
+		Respond:       "foo",
+		Args:          []flottbottest.Arg{{Name: "arg1"}},
+		IncludeInHelp: true,
+		HelpText:      "foo arg1",
+		FormatOutput:  "output is foo ${arg1}",
+	}
+	schedule := flottbottest.Rule{
+		Active:       true,
+		Schedule:     "@every 5s",
+		Name:         "test-schedule",
+		FormatOutput: "Hello, from Scheduler 1!",
+	}
+
+	scheduled := flottbottest.NewMessage("@every 5s")
+	scheduled.Service = flottbottest.MsgServiceScheduler
+	scheduled.Attributes["from_schedule"] = "test-schedule"
+
+	tests := []struct {
+		name    string
+		rules   map[string]flottbottest.Rule
+		message flottbottest.Message
+		output  string
+	}{
+		{"No rule match", map[string]flottbottest.Rule{"test": {}}, flottbottest.NewMessage("Hi there!"), "I understand these commands: \n"},
+		{"Chat rule, no actions", map[string]flottbottest.Rule{"test": foo}, flottbottest.NewMessage("foo test"), "output is foo test"},
+		{"Scheduler rule, no actions", map[string]flottbottest.Rule{"test": schedule}, scheduled, "Hello, from Scheduler 1!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := flottbottest.Start(t, nil, tt.rules)
+
+			h.Send(tt.message)
+			h.ExpectOutput(tt.output)
+		})
+	}
+}
+
+func TestMatcher_precedence(t *testing.T) {
+	deploy := flottbottest.Rule{Name: "deploy", Active: true, Respond: "deploy", FormatOutput: "deploying"}
+	catchAll := flottbottest.Rule{Name: "catch all", Active: true, Respond: "/.*/", FormatOutput: "catch all", Priority: -1}
+	audit := flottbottest.Rule{Name: "audit", Active: true, Hear: "/deploy/", FormatOutput: "audited", Priority: 10}
+	auditAll := audit
+	auditAll.ContinueMatching = true
+
+	tests := []struct {
+		name    string
+		rules   map[string]flottbottest.Rule
+		outputs []string
+	}{
+		{"Higher priority wins", map[string]flottbottest.Rule{"a.yml": catchAll, "b.yml": deploy}, []string{"deploying"}},
+		{"Same priority, first file wins", map[string]flottbottest.Rule{"a.yml": deploy, "b.yml": {Name: "other", Active: true, Respond: "deploy", FormatOutput: "other"}}, []string{"deploying"}},
+		{"Stop at first hit", map[string]flottbottest.Rule{"a.yml": deploy, "b.yml": audit}, []string{"audited"}},
+		{"Continue matching", map[string]flottbottest.Rule{"a.yml": deploy, "b.yml": auditAll, "c.yml": catchAll}, []string{"audited", "deploying"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := flottbottest.Start(t, nil, tt.rules)
+
+			h.Say("deploy app")
+
+			// rules run concurrently, their outputs can arrive in any order
+			for _, output := range tt.outputs {
+				h.ExpectOutput(output)
+			}
+
+			h.ExpectNothing(100 * time.Millisecond)
+		})
+	}
+}
+
+func TestMatcher_captureGroups(t *testing.T) {
+	respond := flottbottest.Rule{
+		Name:          "testmatch",
+		Active:        true,
+		Respond:       "/deploy (?P<application>.*)@(?P<version>.*) to (?P<environment>.*)/",
+		IncludeInHelp: true,
+		HelpText:      "hello <application>@<version> to <environment>",
+		FormatOutput:  "deploying ${application} at version ${version} to environment ${environment}",
+	}
+	hear := flottbottest.Rule{
+		Name:          "testmatch",
+		Active:        true,
+		Hear:          "/(?P<ticket>[A-Z]+-[0-9]+)/",
+		IncludeInHelp: true,
+		FormatOutput:  "response with ${ticket} details",
+	}
+
+	heard := flottbottest.NewMessage("example ticket XYZ-123 should be heard")
+	heard.BotMentioned = false
+
+	tests := []struct {
+		name    string
+		rule    flottbottest.Rule
+		message flottbottest.Message
+		output  string
+	}{
+		{"Capture groups, respond", respond, flottbottest.NewMessage("deploy flottbot@v0.0.1 to qa"), "deploying flottbot at version v0.0.1 to environment qa"},
+		{"Capture groups, hear", hear, heard, "response with XYZ-123 details"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := flottbottest.Start(t, nil, map[string]flottbottest.Rule{"test": tt.rule})
+
+			h.Send(tt.message)
+			h.ExpectOutput(tt.output)
+		})
+	}
+}
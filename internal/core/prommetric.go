@@ -23,6 +23,21 @@ var botResponseCollector = prometheus.NewCounterVec(
 	[]string{"rulename"},
 )
 
+var rateLimitCollector = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "flottbot_rateLimitCount",
+		Help: "Total No. of bot rules throttled by rate limits or cooldowns",
+	},
+	[]string{"rulename", "scope"},
+)
+
+var workerQueueDepth = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "flottbot_workerQueueDepth",
+		Help: "No. of hit rules waiting for a worker",
+	},
+)
+
 // Prommetric creates a local Prometheus server to rule metrics.
 func Prommetric(input string, bot *models.Bot) {
 	if bot.Metrics {
@@ -50,7 +65,7 @@ func Prommetric(input string, bot *models.Bot) {
 			promRouter.HandleFunc("/metrics_health", promHealthHandle).Methods("GET")
 
 			// metrics handler
-			prometheus.MustRegister(botResponseCollector)
+			prometheus.MustRegister(botResponseCollector, rateLimitCollector, workerQueueDepth)
 			promRouter.Handle("/metrics", promhttp.Handler())
 
 			// start prometheus server
@@ -68,3 +83,10 @@ func Prommetric(input string, bot *models.Bot) {
 		}
 	}
 }
+
+// PromRateLimit counts a rule that was throttled by a rate limit of the given scope or its cooldown.
+func PromRateLimit(rule, scope string, bot *models.Bot) {
+	if bot.Metrics {
+		rateLimitCollector.With(prometheus.Labels{"rulename": bot.Name + "-" + rule, "scope": scope}).Inc()
+	}
+}
@@ -0,0 +1,236 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/text"
+)
+
+const defaultRateLimitMessage = "you're doing that too often, try again in ${_rate_limit.retry_after}."
+
+// how often buckets that have been refilled completely are dropped.
+var rateLimitPruneInterval = time.Minute
+
+// bucket is a token bucket, it holds up to 'count' tokens and is refilled
+// at 'count' tokens per 'window', each trigger of a rule takes a token.
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  models.RateLimit
+}
+
+// refill adds the tokens earned since the bucket was last used.
+func (b *bucket) refill(now time.Time) {
+	rate := float64(b.limit.Count) / float64(b.limit.Window)
+	b.tokens = min(float64(b.limit.Count), b.tokens+rate*float64(now.Sub(b.last)))
+	b.last = now
+}
+
+// wait returns how long until the bucket has a token again.
+func (b *bucket) wait() time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+
+	return time.Duration((1 - b.tokens) * float64(b.limit.Window) / float64(b.limit.Count))
+}
+
+// rateLimiter keeps track of the rate limits and cooldowns of the rules.
+type rateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	cooldowns map[string]time.Time // when the cooldown of a rule in a channel ends
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		buckets:   make(map[string]*bucket),
+		cooldowns: make(map[string]time.Time),
+		now:       time.Now,
+	}
+}
+
+// rateLimitKey identifies the bucket of a rate limit, ie. the rule for the user who sent the message.
+func rateLimitKey(rule models.Rule, limit models.RateLimit, message models.Message) string {
+	key := []string{rule.Name, limit.Scope, limit.Window.String()}
+
+	switch limit.Scope {
+	case models.RateLimitScopeUser:
+		user := message.Vars["_user.id"]
+		if user == "" {
+			user = message.Vars["_user.name"]
+		}
+
+		key = append(key, message.Remote, user)
+	case models.RateLimitScopeChannel:
+		key = append(key, message.Remote, message.ChannelID)
+	}
+
+	return strings.Join(key, "|")
+}
+
+// cooldownKey identifies the cooldown of a rule in the channel the message was sent in.
+func cooldownKey(rule models.Rule, message models.Message) string {
+	return strings.Join([]string{rule.Name, message.Remote, message.ChannelID}, "|")
+}
+
+// inCooldown tells whether the rule ran in the channel of the message within its cooldown.
+func (rl *rateLimiter) inCooldown(rule models.Rule, message models.Message) bool {
+	if rule.Cooldown <= 0 {
+		return false
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	return rl.now().Before(rl.cooldowns[cooldownKey(rule, message)])
+}
+
+// allow takes a token from each of the rule's rate limits, if all of them have one left.
+// Otherwise it returns the scope of the exceeded limit and how long until it is lifted.
+// On success, the cooldown of the rule starts in the channel of the message.
+func (rl *rateLimiter) allow(rule models.Rule, message models.Message) (bool, string, time.Duration) {
+	if len(rule.RateLimit) == 0 && rule.Cooldown <= 0 {
+		return true, "", 0
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+	rl.prune(now)
+
+	buckets := make([]*bucket, 0, len(rule.RateLimit))
+
+	for _, limit := range rule.RateLimit {
+		key := rateLimitKey(rule, limit, message)
+
+		b, ok := rl.buckets[key]
+		if !ok {
+			b = &bucket{tokens: float64(limit.Count), last: now, limit: limit}
+			rl.buckets[key] = b
+		}
+
+		b.refill(now)
+
+		if wait := b.wait(); wait > 0 {
+			return false, limit.Scope, wait
+		}
+
+		buckets = append(buckets, b)
+	}
+
+	for _, b := range buckets {
+		b.tokens--
+	}
+
+	if rule.Cooldown > 0 {
+		rl.cooldowns[cooldownKey(rule, message)] = now.Add(rule.Cooldown)
+	}
+
+	return true, "", 0
+}
+
+// prune drops the buckets that have been refilled completely and the cooldowns that ended,
+// they are no different from new ones. The caller must hold the lock.
+func (rl *rateLimiter) prune(now time.Time) {
+	if now.Sub(rl.lastPrune) < rateLimitPruneInterval {
+		return
+	}
+
+	rl.lastPrune = now
+
+	for key, b := range rl.buckets {
+		if now.Sub(b.last) >= b.limit.Window {
+			delete(rl.buckets, key)
+		}
+	}
+
+	for key, end := range rl.cooldowns {
+		if now.After(end) {
+			delete(rl.cooldowns, key)
+		}
+	}
+}
+
+// isThrottled checks the cooldown and rate limits of the rule. Rules in cooldown are suppressed silently,
+// users that exceed a rate limit are told when to try again, unless the rule is a 'hear' rule.
+func (rl *rateLimiter) isThrottled(outputMsgs chan<- models.Envelope, message models.Message, rule models.Rule, bot *models.Bot) bool {
+	if rl.inCooldown(rule, message) {
+		log.Debug().Msgf("rule %#q is in cooldown in channel %#q", rule.Name, message.ChannelID)
+		PromRateLimit(rule.Name, "cooldown", bot)
+
+		return true
+	}
+
+	ok, scope, wait := rl.allow(rule, message)
+	if ok {
+		return false
+	}
+
+	log.Info().Msgf("rule %#q exceeded its %s rate limit", rule.Name, scope)
+	PromRateLimit(rule.Name, scope, bot)
+
+	if rule.Hear != "" {
+		return true
+	}
+
+	message.Vars["_rate_limit.scope"] = scope
+	message.Vars["_rate_limit.retry_after"] = wait.Round(time.Second).String()
+
+	msg := rule.RateLimitMessage
+	if msg == "" {
+		msg = defaultRateLimitMessage
+	}
+
+	output, err := text.Substitute(msg, message.Vars)
+	if err != nil {
+		log.Warn().Msgf("unable to substitute variables in 'rate_limit_message' of rule %#q: %v", rule.Name, err)
+	}
+
+	// only the throttled user needs to know, where the remote supports it
+	message.Output = output
+	message.IsEphemeral = true
+
+	outputMsgs <- models.Envelope{Message: message, Rule: rule, Ops: models.OpSend}
+
+	return true
+}
+
+// validateRateLimits checks the rate limits and cooldown of a rule.
+func validateRateLimits(rule models.Rule) error {
+	var errs []error
+
+	for i, limit := range rule.RateLimit {
+		switch limit.Scope {
+		case models.RateLimitScopeUser, models.RateLimitScopeChannel, models.RateLimitScopeGlobal:
+		default:
+			errs = append(errs, fmt.Errorf("rate limit %d has unknown scope %#q, use one of user, channel, global", i+1, limit.Scope))
+		}
+
+		if limit.Count <= 0 {
+			errs = append(errs, fmt.Errorf("rate limit %d needs a 'count' greater than 0", i+1))
+		}
+
+		if limit.Window <= 0 {
+			errs = append(errs, fmt.Errorf("rate limit %d needs a 'window', ie. 1m", i+1))
+		}
+	}
+
+	if rule.Cooldown < 0 {
+		errs = append(errs, errors.New("'cooldown' cannot be negative"))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+// testRateLimiter returns a rate limiter with a clock that only moves when advanced.
+func testRateLimiter() (*rateLimiter, func(time.Duration)) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	rl := newRateLimiter()
+	rl.now = func() time.Time { return now }
+
+	return rl, func(d time.Duration) { now = now.Add(d) }
+}
+
+func testRateLimitMessage(user, channel string) models.Message {
+	return models.Message{ChannelID: channel, Vars: map[string]string{"_user.id": user}}
+}
+
+func Test_rateLimiter_allow(t *testing.T) {
+	rl, advance := testRateLimiter()
+
+	rule := models.Rule{
+		Name: "exec",
+		RateLimit: []models.RateLimit{
+			{Scope: models.RateLimitScopeUser, Count: 2, Window: time.Minute},
+			{Scope: models.RateLimitScopeGlobal, Count: 3, Window: time.Minute},
+		},
+	}
+
+	steps := []struct {
+		name      string
+		advance   time.Duration
+		user      string
+		wantOk    bool
+		wantScope string
+		wantWait  time.Duration
+	}{
+		{"First", 0, "alice", true, "", 0},
+		{"Second", 0, "alice", true, "", 0},
+		{"User limit", 0, "alice", false, models.RateLimitScopeUser, 30 * time.Second},
+		{"Other user", 0, "bob", true, "", 0},
+		{"Global limit", 0, "carol", false, models.RateLimitScopeGlobal, 20 * time.Second},
+		{"Refilled a token", 20 * time.Second, "carol", true, "", 0},
+		{"Refilled tokens are shared", 0, "bob", false, models.RateLimitScopeGlobal, 20 * time.Second},
+		{"Refilled completely", 2 * time.Minute, "alice", true, "", 0},
+	}
+
+	for _, step := range steps {
+		advance(step.advance)
+
+		ok, scope, wait := rl.allow(rule, testRateLimitMessage(step.user, "C1"))
+		if ok != step.wantOk || scope != step.wantScope || wait != step.wantWait {
+			t.Fatalf("%s: allow() = %v, %q, %v, want %v, %q, %v", step.name, ok, scope, wait, step.wantOk, step.wantScope, step.wantWait)
+		}
+	}
+}
+
+func Test_rateLimiter_cooldown(t *testing.T) {
+	rl, advance := testRateLimiter()
+
+	rule := models.Rule{Name: "hear", Hear: "/deploy/", Cooldown: time.Minute}
+
+	if rl.inCooldown(rule, testRateLimitMessage("alice", "C1")) {
+		t.Fatal("inCooldown() expected no cooldown before the rule ran")
+	}
+
+	rl.allow(rule, testRateLimitMessage("alice", "C1"))
+
+	if !rl.inCooldown(rule, testRateLimitMessage("bob", "C1")) {
+		t.Error("inCooldown() expected cooldown in the channel the rule ran in")
+	}
+
+	if rl.inCooldown(rule, testRateLimitMessage("alice", "C2")) {
+		t.Error("inCooldown() expected no cooldown in other channels")
+	}
+
+	advance(time.Minute)
+
+	if rl.inCooldown(rule, testRateLimitMessage("alice", "C1")) {
+		t.Error("inCooldown() expected cooldown to end")
+	}
+}
+
+func Test_isThrottled(t *testing.T) {
+	rule := models.Rule{
+		Name:             "exec",
+		Respond:          "exec",
+		RateLimit:        []models.RateLimit{{Scope: models.RateLimitScopeChannel, Count: 1, Window: time.Hour}},
+		RateLimitMessage: "slow down, the ${_rate_limit.scope} limit resets in ${_rate_limit.retry_after}",
+	}
+
+	tests := []struct {
+		name       string
+		rule       models.Rule
+		wantOutput string
+	}{
+		{"Respond rule", rule, "slow down, the channel limit resets in 1h0m0s"},
+		{"Default message", models.Rule{Name: rule.Name, Respond: rule.Respond, RateLimit: rule.RateLimit}, "you're doing that too often, try again in 1h0m0s."},
+		{"Hear rule is silent", models.Rule{Name: rule.Name, Hear: "exec", RateLimit: rule.RateLimit}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl, _ := testRateLimiter()
+
+			outputMsgs := make(chan models.Envelope, 1)
+			bot := new(models.Bot)
+
+			if rl.isThrottled(outputMsgs, testRateLimitMessage("alice", "C1"), tt.rule, bot) {
+				t.Fatal("isThrottled() expected the first trigger to pass")
+			}
+
+			if !rl.isThrottled(outputMsgs, testRateLimitMessage("bob", "C1"), tt.rule, bot) {
+				t.Fatal("isThrottled() expected the second trigger to be throttled")
+			}
+
+			select {
+			case env := <-outputMsgs:
+				if msg := env.Message; msg.Output != tt.wantOutput || !msg.IsEphemeral {
+					t.Errorf("isThrottled() output = %q (ephemeral %v), want %q", msg.Output, msg.IsEphemeral, tt.wantOutput)
+				}
+			default:
+				if tt.wantOutput != "" {
+					t.Errorf("isThrottled() expected output %q", tt.wantOutput)
+				}
+			}
+		})
+	}
+}
+
+func Test_validateRateLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    models.Rule
+		wantErr bool
+	}{
+		{"Valid", models.Rule{RateLimit: []models.RateLimit{{Scope: "user", Count: 5, Window: time.Minute}}, Cooldown: time.Minute}, false},
+		{"Unknown scope", models.Rule{RateLimit: []models.RateLimit{{Scope: "team", Count: 5, Window: time.Minute}}}, true},
+		{"No count", models.Rule{RateLimit: []models.RateLimit{{Scope: "user", Window: time.Minute}}}, true},
+		{"No window", models.Rule{RateLimit: []models.RateLimit{{Scope: "user", Count: 5}}}, true},
+		{"Negative cooldown", models.Rule{Cooldown: -time.Second}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateRateLimits(tt.rule); (err != nil) != tt.wantErr {
+				t.Errorf("validateRateLimits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
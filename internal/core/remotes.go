@@ -3,18 +3,23 @@
 package core
 
 import (
+	"context"
 	"strings"
+	"sync"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/remote"
 	"github.com/target/flottbot/internal/remote/cli"
-	"github.com/target/flottbot/internal/remote/discord"
-	"github.com/target/flottbot/internal/remote/gchat"
-	"github.com/target/flottbot/internal/remote/mattermost"
 	"github.com/target/flottbot/internal/remote/scheduler"
-	"github.com/target/flottbot/internal/remote/slack"
-	"github.com/target/flottbot/internal/remote/telegram"
+
+	// chat applications register themselves with the remote registry.
+	_ "github.com/target/flottbot/internal/remote/discord"
+	_ "github.com/target/flottbot/internal/remote/gchat"
+	_ "github.com/target/flottbot/internal/remote/mattermost"
+	_ "github.com/target/flottbot/internal/remote/slack"
+	_ "github.com/target/flottbot/internal/remote/telegram"
 )
 
 // Remotes - the purpose of this function is to READ incoming messages from various places, i.e. remotes.
@@ -25,7 +30,9 @@ import (
 //
 //	This remote allows us to read messages from various chat application platforms, e.g. Slack, Discord, etc.
 //	We typically read the messages from these chat applications using their respective APIs.
-//	* Note: right now we only support reading from one chat application at a time.
+//	Chat applications are looked up by name in the remote registry (see '/remote/registry.go').
+//	Each configured chat application is read from concurrently, and every message is tagged
+//	with the chat application it came from so that replies can be sent back to it.
 //
 // Remote 2: CLI
 //
@@ -37,60 +44,27 @@ import (
 //	This remote allows us to read messages being sent internally by a running cronjob
 //	created by a schedule type rule, e.g. see '/config/rules/schedule.yml'.
 //
-// TODO: Refactor to keep remote specific stuff in remote/.
-func Remotes(inputMsgs chan<- models.Message, rules map[string]models.Rule, bot *models.Bot) {
-	// Run a chat application
-	if bot.ChatApplication != "" {
-		chatApp := strings.ToLower(bot.ChatApplication)
-		log.Info().Msgf("running %#q on %#q", bot.Name, chatApp)
-
-		switch chatApp {
-		// Setup remote to use the Discord client to read from Discord
-		case models.ChatAppDiscord:
-			// Create Discord client
-			remoteDiscord := &discord.Client{
-				Token: bot.DiscordToken,
-			}
-			// Read messages from Discord
-			go remoteDiscord.Read(inputMsgs, rules, bot)
-		// Setup remote to use the Slack client to read from Slack
-		case models.ChatAppSlack:
-			// Create Slack client
-			remoteSlack := &slack.Client{
-				Token:         bot.SlackToken,
-				AppToken:      bot.SlackAppToken,
-				SigningSecret: bot.SlackSigningSecret,
-			}
-			// Read messages from Slack
-			go remoteSlack.Read(inputMsgs, rules, bot)
-		case models.ChatAppMattermost:
-			remoteMattermost := &mattermost.Client{
-				Token:    bot.MatterMostToken,
-				Server:   bot.MatterMostServer,
-				Insecure: false,
-			}
-
-			log.Info().Msgf("insecure setting is: %v", bot.MatterMostInsecureProtocol)
+// The remotes read until the context is canceled. Once all of them stopped,
+// 'inputMsgs' is closed to let the Matcher know no more messages will come.
+func Remotes(ctx context.Context, inputMsgs chan<- models.Message, rules *models.RuleSet, bot *models.Bot) {
+	var wg sync.WaitGroup
 
-			if strings.ToLower(bot.MatterMostInsecureProtocol) == "1" {
-				remoteMattermost.Insecure = true
+	defer close(inputMsgs)
+	defer wg.Wait()
 
-				log.Warn().Msg("using insecure protocols http and ws")
-			}
+	// Run the chat applications
+	for _, chatApp := range bot.ChatApplications {
+		chatApp = strings.ToLower(chatApp)
+		log.Info().Msgf("running %#q on %#q", bot.Name, chatApp)
 
-			go remoteMattermost.Read(inputMsgs, rules, bot)
-		// Setup remote to use the Telegram client to read from Telegram
-		case models.ChatAppTelegram:
-			remoteTelegram := &telegram.Client{
-				Token: bot.TelegramToken,
-			}
-			// Read messages from Telegram
-			go remoteTelegram.Read(inputMsgs, rules, bot)
-		case models.ChatAppGoogleChat:
-			gchat.HandleRemoteInput(inputMsgs, rules, bot)
-		default:
+		reg, ok := remote.Lookup(chatApp)
+		if !ok {
 			log.Error().Msgf("chat application %#q is not supported", chatApp)
+			continue
 		}
+
+		// Read messages from the chat application
+		wg.Go(func() { readRemote(ctx, reg.New(bot), chatApp, inputMsgs, rules, bot) })
 	}
 
 	// Run CLI mode
@@ -99,16 +73,65 @@ func Remotes(inputMsgs chan<- models.Message, rules map[string]models.Rule, bot
 
 		remoteCLI := &cli.Client{}
 
-		go remoteCLI.Read(inputMsgs, rules, bot)
+		wg.Go(func() { readRemote(ctx, remoteCLI, "", inputMsgs, rules, bot) })
 	}
 
 	// Run Scheduler
-	// CAUTION: Will not work properly when multiple instances of your bot are deployed (i.e. will get duplicated scheduled output)
+	// When multiple instances of your bot are deployed, set 'scheduler_lock' so that only one of them runs the schedules
 	if bot.Scheduler {
 		log.Info().Msgf("running scheduler for %#q", bot.Name)
 
-		remoteScheduler := &scheduler.Client{}
+		remoteScheduler := &scheduler.Client{BotName: bot.Name}
+
+		wg.Go(func() { readRemote(ctx, remoteScheduler, "", inputMsgs, rules, bot) })
+	}
+}
+
+// readRemote reads messages from the remote until the context is canceled. Messages are passed on
+// to the matcher until the remote stopped reading, tagged with the chat application they came from, if any.
+func readRemote(ctx context.Context, r remote.Remote, chatApp string, inputMsgs chan<- models.Message, rules *models.RuleSet, bot *models.Bot) {
+	remoteMsgs := make(chan models.Message, 1)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		r.Read(ctx, remoteMsgs, rules, bot)
+	}()
+
+	tagMessages(chatApp, remoteMsgs, inputMsgs, done)
+}
+
+// tagMessages passes messages read from a chat application on to the matcher,
+// marking each message with the name of the chat application it was read from.
+// It stops once remoteMsgs or done is closed, after passing on a message that is still waiting.
+func tagMessages(chatApp string, remoteMsgs <-chan models.Message, inputMsgs chan<- models.Message, done <-chan struct{}) {
+	pass := func(message models.Message) {
+		if chatApp != "" {
+			message.Remote = chatApp
+		}
+
+		inputMsgs <- message
+	}
+
+	for {
+		select {
+		case message, ok := <-remoteMsgs:
+			if !ok {
+				return
+			}
 
-		go remoteScheduler.Read(inputMsgs, rules, bot)
+			pass(message)
+		case <-done:
+			select {
+			case message, ok := <-remoteMsgs:
+				if ok {
+					pass(message)
+				}
+			default:
+			}
+
+			return
+		}
 	}
 }
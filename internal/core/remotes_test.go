@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+func Test_tagMessages(t *testing.T) {
+	remoteMsgs := make(chan models.Message, 1)
+	inputMsgs := make(chan models.Message, 1)
+
+	go tagMessages(models.ChatAppMattermost, remoteMsgs, inputMsgs, make(chan struct{}))
+
+	remoteMsgs <- models.NewMessage()
+
+	message := <-inputMsgs
+	if message.Remote != models.ChatAppMattermost {
+		t.Errorf("tagMessages() wanted remote %#q, but got %#q", models.ChatAppMattermost, message.Remote)
+	}
+
+	close(remoteMsgs)
+}
+
+func TestRemotes_shutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	inputMsgs := make(chan models.Message, 1)
+
+	go Remotes(ctx, inputMsgs, models.NewRuleSet(nil), &models.Bot{CLI: true})
+
+	cancel()
+
+	select {
+	case _, ok := <-inputMsgs:
+		if ok {
+			t.Errorf("Remotes() expected no messages")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Remotes() expected to close inputMsgs once the context is canceled")
+	}
+}
+
+func Test_primaryChatApplication(t *testing.T) {
+	tests := []struct {
+		name string
+		bot  *models.Bot
+		want string
+	}{
+		{"No chat application", &models.Bot{}, ""},
+		{"Single chat application", &models.Bot{ChatApplications: []string{"Slack"}}, models.ChatAppSlack},
+		{"Multiple chat applications", &models.Bot{ChatApplications: []string{models.ChatAppMattermost, models.ChatAppSlack}}, models.ChatAppMattermost},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := primaryChatApplication(tt.bot); got != tt.want {
+				t.Errorf("primaryChatApplication() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
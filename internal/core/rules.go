@@ -3,18 +3,32 @@
 package core
 
 import (
+	"cmp"
+	"context"
 	"fmt"
+	"maps"
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 
 	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/remote"
 	"github.com/target/flottbot/internal/text"
 )
 
+// how long to wait for file events to settle before reloading rules,
+// editors and config map updates tend to produce bursts of events.
+var ruleReloadDelay = 500 * time.Millisecond
+
 // Rules - searches the rules directory for any existing .yml rules
 // and proceeds to create Rule objects for each .yml rule,
 // and then finally populates a rules map with said Rule objects.
@@ -23,9 +37,221 @@ func Rules(rules *map[string]models.Rule, bot *models.Bot) {
 	// Check if the rules directory even exists
 	log.Debug().Msg("looking for rules directory...")
 
+	rulesDir, err := getRulesDir()
+	if err != nil {
+		log.Error().Msg(err.Error())
+	}
+
+	// Loop through the rules directory and create a list of rules
+	log.Info().Msg("fetching all rule files...")
+
+	fileList := getRuleFiles(rulesDir)
+
+	// If the rules directory is empty, log a warning and exit
+	if len(fileList) == 0 {
+		log.Warn().Msg("looks like there aren't any rules")
+
+		return
+	}
+
+	// Loop through the list of rules, creating a Rule object
+	// for each rule, then populate the map of Rule objects
+	log.Debug().Msgf("parsing %d rule files...", len(fileList))
+
+	for _, ruleFile := range fileList {
+		rule, err := readRule(ruleFile)
+		if err != nil {
+			log.Error().Msgf("rejected rule file %#q: %v", ruleFile, err)
+
+			continue
+		}
+
+		(*rules)[ruleFile] = rule
+	}
+
+	log.Info().Msgf("configured %#q rules!", bot.Name)
+}
+
+// WatchRules watches the rules directory for changes and swaps
+// the updated rules into the given rule set. Rule files that fail to
+// parse are rejected and the previously loaded version is kept.
+// It stops watching once the context is canceled.
+func WatchRules(ctx context.Context, rules *models.RuleSet, bot *models.Bot) {
+	rulesDir, err := getRulesDir()
+	if err != nil {
+		log.Error().Msgf("unable to watch rules: %v", err)
+		return
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Error().Msgf("unable to create rules watcher: %v", err)
+		return
+	}
+
+	defer watcher.Close()
+
+	if err := watchDirs(watcher, rulesDir); err != nil {
+		log.Error().Msgf("unable to watch rules directory %#q: %v", rulesDir, err)
+		return
+	}
+
+	log.Info().Msgf("watching %#q for rule changes", rulesDir)
+
+	// fire the timer only once events stop coming in
+	reload := time.NewTimer(ruleReloadDelay)
+	reload.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+
+			log.Debug().Msgf("rules watcher: %s", event)
+
+			// pick up newly created sub directories
+			if event.Has(fsnotify.Create) {
+				if f, err := os.Stat(event.Name); err == nil && f.IsDir() {
+					if err := watchDirs(watcher, event.Name); err != nil {
+						log.Error().Msgf("unable to watch directory %#q: %v", event.Name, err)
+					}
+				}
+			}
+
+			reload.Reset(ruleReloadDelay)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+
+			log.Error().Msgf("rules watcher error: %v", err)
+		case <-reload.C:
+			next, changes := reloadRules(rules.Rules(), rulesDir)
+
+			if changes.hasChanges() {
+				rules.Swap(next)
+			}
+
+			log.Info().Msgf("reloaded rules for %#q: %s", bot.Name, changes)
+		}
+	}
+}
+
+// ruleChanges summarizes the differences found when reloading rules.
+type ruleChanges struct {
+	added    []string
+	updated  []string
+	removed  []string
+	rejected []string
+}
+
+func (c ruleChanges) hasChanges() bool {
+	return len(c.added)+len(c.updated)+len(c.removed) > 0
+}
+
+func (c ruleChanges) String() string {
+	return fmt.Sprintf("%d added %v, %d updated %v, %d removed %v, %d rejected %v",
+		len(c.added), c.added,
+		len(c.updated), c.updated,
+		len(c.removed), c.removed,
+		len(c.rejected), c.rejected,
+	)
+}
+
+// reloadRules reads all rule files in the rules directory and returns a new
+// rules map along with a summary of what changed compared to the current rules.
+// Invalid rule files are rejected, keeping the current version if there is one.
+func reloadRules(current map[string]models.Rule, rulesDir string) (map[string]models.Rule, ruleChanges) {
+	next := make(map[string]models.Rule)
+	changes := ruleChanges{}
+
+	for _, ruleFile := range getRuleFiles(rulesDir) {
+		prev, exists := current[ruleFile]
+
+		rule, err := readRule(ruleFile)
+		if err != nil {
+			log.Error().Msgf("rejected rule file %#q: %v", ruleFile, err)
+
+			changes.rejected = append(changes.rejected, ruleFile)
+
+			if exists {
+				next[ruleFile] = prev
+			}
+
+			continue
+		}
+
+		switch {
+		case !exists:
+			changes.added = append(changes.added, rule.Name)
+		case !reflect.DeepEqual(prev, rule):
+			changes.updated = append(changes.updated, rule.Name)
+		}
+
+		next[ruleFile] = rule
+	}
+
+	for ruleFile, rule := range current {
+		if _, ok := next[ruleFile]; !ok {
+			changes.removed = append(changes.removed, rule.Name)
+		}
+	}
+
+	return next, changes
+}
+
+// readRule reads and validates a single rule file.
+func readRule(ruleFile string) (models.Rule, error) {
+	rule, err := decodeRule(ruleFile, nil)
+	if err != nil {
+		return rule, err
+	}
+
+	err = validateRule(&rule)
+	if err != nil {
+		return rule, fmt.Errorf("invalid rule file %#q: %w", ruleFile, err)
+	}
+
+	return rule, nil
+}
+
+// decodeRule reads a rule file without validating it. If given, the metadata
+// collects the keys that were decoded and the keys that don't belong to any field.
+func decodeRule(ruleFile string, md *mapstructure.Metadata) (models.Rule, error) {
+	rule := models.Rule{}
+
+	ruleConf := viper.New()
+	ruleConf.SetConfigFile(ruleFile)
+
+	err := ruleConf.ReadInConfig()
+	if err != nil {
+		return rule, fmt.Errorf("error while reading rule file %#q: %w", ruleFile, err)
+	}
+
+	err = ruleConf.Unmarshal(&rule, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		argDecodeHook(),
+		attachmentDecodeHook(),
+	)), func(c *mapstructure.DecoderConfig) {
+		c.Metadata = md
+	})
+	if err != nil {
+		return rule, fmt.Errorf("error while decoding rule file %#q: %w", ruleFile, err)
+	}
+
+	return rule, nil
+}
+
+// getRulesDir returns the path to the rules directory.
+func getRulesDir() (string, error) {
 	currDir, err := os.Getwd()
 	if err != nil {
-		log.Error().Msg("can't get current working directory")
+		return "", fmt.Errorf("can't get current working directory")
 	}
 
 	// TODO: make customizable
@@ -33,16 +259,18 @@ func Rules(rules *map[string]models.Rule, bot *models.Bot) {
 
 	_, err = os.Stat(rulesDir)
 	if err != nil {
-		log.Error().Msg("config/rules directory not found")
+		return rulesDir, fmt.Errorf("config/rules directory not found")
 	}
 
-	// Loop through the rules directory and create a list of rules
-	log.Info().Msg("fetching all rule files...")
+	return rulesDir, nil
+}
 
+// getRuleFiles returns all files found in the rules directory, except for rule tests.
+func getRuleFiles(rulesDir string) []string {
 	fileList := []string{}
 
-	err = filepath.Walk(rulesDir, func(path string, f os.FileInfo, _ error) error {
-		if f != nil && !f.IsDir() {
+	err := filepath.Walk(rulesDir, func(path string, f os.FileInfo, _ error) error {
+		if f != nil && !f.IsDir() && !isRuleTestFile(path) {
 			fileList = append(fileList, path)
 		}
 
@@ -52,42 +280,70 @@ func Rules(rules *map[string]models.Rule, bot *models.Bot) {
 		log.Error().Msgf("could not parse rules: %v", err)
 	}
 
-	// If the rules directory is empty, log a warning and exit
-	if len(fileList) == 0 {
-		log.Warn().Msg("looks like there aren't any rules")
+	return fileList
+}
 
-		return
+// RuleTestFiles returns the files with tests for the rules, ie. 'hello_test.yml' next to 'hello.yml'.
+func RuleTestFiles() ([]string, error) {
+	rulesDir, err := getRulesDir()
+	if err != nil {
+		return nil, err
 	}
 
-	// Loop through the list of rules, creating a Rule object
-	// for each rule, then populate the map of Rule objects
-	log.Debug().Msgf("parsing %d rule files...", len(fileList))
-
-	for _, ruleFile := range fileList {
-		ruleConf := viper.New()
-		ruleConf.SetConfigFile(ruleFile)
+	fileList := []string{}
 
-		err := ruleConf.ReadInConfig()
-		if err != nil {
-			log.Error().Msgf("error while reading rule file %#q: %v", ruleFile, err)
+	err = filepath.Walk(rulesDir, func(path string, f os.FileInfo, _ error) error {
+		if f != nil && !f.IsDir() && isRuleTestFile(path) {
+			fileList = append(fileList, path)
 		}
 
-		rule := models.Rule{}
+		return nil
+	})
 
-		err = ruleConf.Unmarshal(&rule)
+	return fileList, err
+}
+
+// isRuleTestFile tells whether the file has tests for the rules, rather than a rule.
+func isRuleTestFile(path string) bool {
+	name := filepath.Base(path)
+
+	return strings.HasSuffix(strings.TrimSuffix(name, filepath.Ext(name)), "_test")
+}
+
+// watchDirs adds the given directory and all of its sub directories to the watcher.
+func watchDirs(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.Walk(dir, func(path string, f os.FileInfo, err error) error {
 		if err != nil {
-			log.Error().Msg(err.Error())
+			return err
 		}
 
-		err = validateRule(&rule)
-		if err != nil {
-			log.Error().Msg(err.Error())
+		if f.IsDir() {
+			return watcher.Add(path)
 		}
 
-		(*rules)[ruleFile] = rule
+		return nil
+	})
+}
+
+// sortRules returns the rules in the order they are matched against messages; rules with
+// a higher 'priority' come first, rules with the same priority are ordered by their file path.
+func sortRules(rules map[string]models.Rule) []models.Rule {
+	files := slices.Collect(maps.Keys(rules))
+
+	slices.SortFunc(files, func(a, b string) int {
+		if c := cmp.Compare(rules[b].Priority, rules[a].Priority); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a, b)
+	})
+
+	sorted := make([]models.Rule, 0, len(files))
+	for _, file := range files {
+		sorted = append(sorted, rules[file])
 	}
 
-	log.Info().Msgf("configured %#q rules!", bot.Name)
+	return sorted
 }
 
 // Validate applies any environmental changes.
@@ -101,5 +357,48 @@ func validateRule(r *models.Rule) error {
 		r.OutputToRooms[i] = token
 	}
 
+	if err := substituteDefaults(r); err != nil {
+		return fmt.Errorf("rule %#q: %w", r.Name, err)
+	}
+
+	if err := compileRulePatterns(*r); err != nil {
+		return fmt.Errorf("rule %#q: %w", r.Name, err)
+	}
+
+	if err := validateArgs(r.Args); err != nil {
+		return fmt.Errorf("rule %#q: invalid args: %w", r.Name, err)
+	}
+
+	if err := validateFlags(r.Flags); err != nil {
+		return fmt.Errorf("rule %#q: invalid flags: %w", r.Name, err)
+	}
+
+	// a number is read as nanoseconds, not as the seconds of earlier versions
+	if r.ConversationTimeout < 0 || r.ConversationTimeout > 0 && r.ConversationTimeout < time.Second {
+		return fmt.Errorf("rule %#q: 'conversation_timeout' must be a duration of at least 1s, ie. 2m", r.Name)
+	}
+
+	for i := range r.Steps {
+		if err := r.Steps[i].Compile(); err != nil {
+			return fmt.Errorf("rule %#q: invalid steps: %w", r.Name, err)
+		}
+	}
+
+	if err := validateRateLimits(*r); err != nil {
+		return fmt.Errorf("rule %#q: %w", r.Name, err)
+	}
+
+	if err := validateConcurrency(r.Concurrency); err != nil {
+		return fmt.Errorf("rule %#q: %w", r.Name, err)
+	}
+
+	if err := validateBlockTemplates(r.Remotes.Slack.Blocks); err != nil {
+		return fmt.Errorf("rule %#q: %w", r.Name, err)
+	}
+
+	if err := remote.ValidateRule(*r); err != nil {
+		return fmt.Errorf("rule %#q: %w", r.Name, err)
+	}
+
 	return nil
 }
@@ -0,0 +1,268 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+func writeRuleFile(t *testing.T, dir, name, contents string) string {
+	t.Helper()
+
+	ruleFile := filepath.Join(dir, name)
+
+	err := os.WriteFile(ruleFile, []byte(contents), 0o600)
+	if err != nil {
+		t.Fatalf("unable to write rule file: %v", err)
+	}
+
+	return ruleFile
+}
+
+func TestRules_rejectsInvalid(t *testing.T) {
+	dir := t.TempDir()
+	rulesDir := filepath.Join(dir, "config", "rules")
+
+	if err := os.MkdirAll(rulesDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	writeRuleFile(t, rulesDir, "valid.yml", "name: valid\nrespond: valid\n")
+	writeRuleFile(t, rulesDir, "invalid.yml", "name: invalid\nhear: '/deploy (\\w+/'\n")
+
+	t.Chdir(dir)
+
+	rules := map[string]models.Rule{}
+	Rules(&rules, new(models.Bot))
+
+	if _, ok := rules[filepath.Join(rulesDir, "invalid.yml")]; ok || len(rules) != 1 {
+		t.Errorf("Rules() expected only the valid rule, got %v", slices.Collect(maps.Keys(rules)))
+	}
+}
+
+func Test_reloadRules(t *testing.T) {
+	dir := t.TempDir()
+
+	helloFile := writeRuleFile(t, dir, "hello.yml", "name: hello\nrespond: hello\nactive: true\nformat_output: hi\n")
+	byeFile := writeRuleFile(t, dir, "bye.yml", "name: bye\nrespond: bye\nactive: true\nformat_output: bye\n")
+
+	current, changes := reloadRules(map[string]models.Rule{}, dir)
+	if len(current) != 2 || len(changes.added) != 2 || !changes.hasChanges() {
+		t.Fatalf("reloadRules() expected 2 added rules, got %s", changes)
+	}
+
+	// nothing changed on disk
+	_, changes = reloadRules(current, dir)
+	if changes.hasChanges() {
+		t.Errorf("reloadRules() expected no changes, got %s", changes)
+	}
+
+	// update one rule, break another and add a new one
+	writeRuleFile(t, dir, "hello.yml", "name: hello\nrespond: hello\nactive: true\nformat_output: hello there\n")
+	writeRuleFile(t, dir, "bye.yml", "name: bye\nrespond: [bye\n")
+	newFile := writeRuleFile(t, dir, "new.yml", "name: new\nhear: new\nactive: true\nformat_output: new\n")
+
+	next, changes := reloadRules(current, dir)
+
+	if len(changes.added) != 1 || len(changes.updated) != 1 || len(changes.rejected) != 1 || len(changes.removed) != 0 {
+		t.Fatalf("reloadRules() unexpected changes: %s", changes)
+	}
+
+	if next[helloFile].FormatOutput != "hello there" {
+		t.Errorf("reloadRules() expected updated rule, got %#q", next[helloFile].FormatOutput)
+	}
+
+	if next[byeFile].FormatOutput != "bye" {
+		t.Errorf("reloadRules() expected rejected rule to keep previous version, got %#q", next[byeFile].FormatOutput)
+	}
+
+	if next[newFile].Name != "new" {
+		t.Errorf("reloadRules() expected new rule to be added")
+	}
+
+	// remove a rule
+	err := os.Remove(newFile)
+	if err != nil {
+		t.Fatalf("unable to remove rule file: %v", err)
+	}
+
+	next, changes = reloadRules(next, dir)
+	if len(changes.removed) != 1 {
+		t.Errorf("reloadRules() expected 1 removed rule, got %s", changes)
+	}
+
+	if _, ok := next[newFile]; ok {
+		t.Errorf("reloadRules() expected removed rule to be dropped")
+	}
+
+	// a broken rule file that was never loaded successfully is not added
+	brokenFile := writeRuleFile(t, dir, "broken.yml", "name: [broken\n")
+
+	next, changes = reloadRules(next, dir)
+	if _, ok := next[brokenFile]; ok || len(changes.rejected) != 2 {
+		t.Errorf("reloadRules() expected broken rule to be rejected, got %s", changes)
+	}
+}
+
+func Test_readRule_blocks(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		rule    string
+		wantErr bool
+	}{
+		{"Blocks as list", "name: ok\nrespond: ok\nremotes:\n  slack:\n    blocks:\n      - type: section\n        text:\n          type: mrkdwn\n          text: hi ${_user.name}\n", false},
+		{"Blocks as template", "name: ok\nrespond: ok\nremotes:\n  slack:\n    blocks: '[{{ range $i := until 2 }}{\"type\":\"divider\"}{{ end }}]'\n", false},
+		{"Unknown block type", "name: bad\nrespond: bad\nremotes:\n  slack:\n    blocks:\n      - type: sectoin\n", true},
+		{"Malformed block", "name: bad\nrespond: bad\nremotes:\n  slack:\n    blocks:\n      - type: section\n        text: not an object\n", true},
+		{"Malformed template", "name: bad\nrespond: bad\nremotes:\n  slack:\n    blocks:\n      - type: section\n        text:\n          type: mrkdwn\n          text: '{{ .name '\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ruleFile := writeRuleFile(t, dir, "rule.yml", tt.rule)
+
+			if _, err := readRule(ruleFile); (err != nil) != tt.wantErr {
+				t.Errorf("readRule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_readRule_patterns(t *testing.T) {
+	ruleFile := writeRuleFile(t, t.TempDir(), "rule.yml", "name: bad\nhear: '/deploy (\\w+/'\n")
+
+	_, err := readRule(ruleFile)
+	if err == nil || !strings.Contains(err.Error(), ruleFile) || !strings.Contains(err.Error(), "invalid `hear` pattern") {
+		t.Errorf("readRule() error = %v, want invalid pattern error for %#q", err, ruleFile)
+	}
+}
+
+func Test_readRule_args(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Setenv("FLOTTBOT_TEST_DEFAULT_ENV", "qa")
+
+	ruleFile := writeRuleFile(t, dir, "rule.yml", "name: deploy\nrespond: deploy\nargs:\n  - app\n  - name: env\n    type: enum\n    values: [dev, qa, prod]\n    default: ${FLOTTBOT_TEST_DEFAULT_ENV}\n")
+
+	rule, err := readRule(ruleFile)
+	if err != nil {
+		t.Fatalf("readRule() error = %v", err)
+	}
+
+	want := []models.Arg{{Name: "app"}, {Name: "env", Type: "enum", Values: []string{"dev", "qa", "prod"}, Default: "qa"}}
+	if !reflect.DeepEqual(rule.Args, want) {
+		t.Errorf("readRule() args = %+v, want %+v", rule.Args, want)
+	}
+
+	ruleFile = writeRuleFile(t, dir, "rule.yml", "name: bad\nrespond: bad\nargs:\n  - name: count\n    type: int\n    default: many\n")
+
+	if _, err := readRule(ruleFile); err == nil {
+		t.Error("readRule() expected error for invalid default")
+	}
+}
+
+func Test_readRule_rateLimit(t *testing.T) {
+	ruleFile := writeRuleFile(t, t.TempDir(), "rule.yml", "name: exec\nrespond: exec\nrate_limit:\n  - scope: user\n    count: 5\n    window: 1m\ncooldown: 30s\n")
+
+	rule, err := readRule(ruleFile)
+	if err != nil {
+		t.Fatalf("readRule() error = %v", err)
+	}
+
+	want := []models.RateLimit{{Scope: "user", Count: 5, Window: time.Minute}}
+	if !reflect.DeepEqual(rule.RateLimit, want) || rule.Cooldown != 30*time.Second {
+		t.Errorf("readRule() rate_limit = %+v, cooldown = %v", rule.RateLimit, rule.Cooldown)
+	}
+}
+
+func Test_readRule_steps(t *testing.T) {
+	dir := t.TempDir()
+
+	ruleFile := writeRuleFile(t, dir, "rule.yml", "name: ask\nrespond: ask\nsteps:\n  - prompt: which app?\n    var: app\n    validate: '^\\w+$'\n")
+
+	rule, err := readRule(ruleFile)
+	if err != nil {
+		t.Fatalf("readRule() error = %v", err)
+	}
+
+	if !rule.Steps[0].Accepts("flottbot") || rule.Steps[0].Accepts("flott bot") {
+		t.Errorf("readRule() expected the step to only accept single words")
+	}
+
+	ruleFile = writeRuleFile(t, dir, "rule.yml", "name: bad\nrespond: ask\nsteps:\n  - prompt: which app?\n    var: app\n    validate: '(\\w+'\n")
+
+	if _, err := readRule(ruleFile); err == nil || !strings.Contains(err.Error(), "invalid 'validate' pattern") {
+		t.Errorf("readRule() error = %v, want invalid 'validate' pattern error", err)
+	}
+}
+
+func Test_readRule_conversationTimeout(t *testing.T) {
+	dir := t.TempDir()
+
+	ruleFile := writeRuleFile(t, dir, "rule.yml", "name: ask\nrespond: ask\nconversation_timeout: 2m\n")
+
+	rule, err := readRule(ruleFile)
+	if err != nil || rule.ConversationTimeout != 2*time.Minute {
+		t.Errorf("readRule() conversation_timeout = %v, %v, want %v", rule.ConversationTimeout, err, 2*time.Minute)
+	}
+
+	// seconds, as earlier versions took them
+	ruleFile = writeRuleFile(t, dir, "rule.yml", "name: ask\nrespond: ask\nconversation_timeout: 120\n")
+
+	if _, err := readRule(ruleFile); err == nil {
+		t.Error("readRule() expected error for a conversation_timeout without unit")
+	}
+}
+
+func Test_sortRules(t *testing.T) {
+	rules := map[string]models.Rule{
+		"rules/b.yml":       {Name: "b"},
+		"rules/a.yml":       {Name: "a"},
+		"rules/z/audit.yml": {Name: "audit", Priority: 10},
+		"rules/fallback":    {Name: "fallback", Priority: -1},
+		"rules/c.yml":       {Name: "c", Priority: 10},
+	}
+
+	want := []string{"c", "audit", "a", "b", "fallback"}
+
+	// the order must not depend on the order of the map
+	for range 10 {
+		got := []string{}
+		for _, rule := range sortRules(rules) {
+			got = append(got, rule.Name)
+		}
+
+		if !slices.Equal(got, want) {
+			t.Fatalf("sortRules() = %v, want %v", got, want)
+		}
+	}
+}
+
+func Test_isRuleTestFile(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"config/rules/hello.yml", false},
+		{"config/rules/hello_test.yml", true},
+		{"config/rules/ops/deploy_test.yaml", true},
+		{"config/rules/test.yml", false},
+		{"config/rules/latest.yml", false},
+	}
+
+	for _, tt := range tests {
+		if got := isRuleTestFile(tt.path); got != tt.want {
+			t.Errorf("isRuleTestFile(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/text"
+)
+
+const (
+	// maxSuggestions is the number of commands suggested when no rule matched.
+	maxSuggestions = 3
+	// defaultSuggestionMaxDistance is how many edits away from a command the input may be to suggest it.
+	defaultSuggestionMaxDistance = 2
+)
+
+// suggestion is a command that is close to what the user typed.
+type suggestion struct {
+	command  string
+	distance int
+}
+
+// suggestCommands returns the commands closest to the input of the message, comparing the input
+// with the literal text the 'respond' pattern of each rule starts with, and with the rule names.
+// Only the commands of the rules, those shown in help, within 'suggestion_max_distance' edits
+// are suggested, the closest first.
+func suggestCommands(message models.Message, rules []models.Rule, bot *models.Bot) []string {
+	maxDistance := suggestionMaxDistance(bot)
+	if maxDistance == 0 {
+		return nil
+	}
+
+	input := strings.Fields(message.Input)
+	best := map[string]int{}
+
+	for _, rule := range rules {
+		for _, candidate := range []string{text.LiteralPrefix(rule.Respond), rule.Name} {
+			words := strings.Fields(candidate)
+			if len(words) == 0 || len(words) > len(input) {
+				continue
+			}
+
+			// compare with as many words of the input as the candidate has
+			distance := text.Distance(strings.Join(input[:len(words)], " "), strings.Join(words, " "))
+
+			// short commands are too easily 'close' to anything
+			if distance > maxDistance || distance*2 > utf8.RuneCountInString(candidate) {
+				continue
+			}
+
+			command := helpName(rule)
+			if d, ok := best[command]; !ok || distance < d {
+				best[command] = distance
+			}
+		}
+	}
+
+	suggestions := make([]suggestion, 0, len(best))
+	for command, distance := range best {
+		suggestions = append(suggestions, suggestion{command, distance})
+	}
+
+	slices.SortFunc(suggestions, func(a, b suggestion) int {
+		return cmp.Or(cmp.Compare(a.distance, b.distance), cmp.Compare(a.command, b.command))
+	})
+
+	commands := []string{}
+	for _, s := range suggestions[:min(len(suggestions), maxSuggestions)] {
+		commands = append(commands, fmt.Sprintf("%#q", s.command))
+	}
+
+	return commands
+}
+
+// suggestionMaxDistance returns how many edits away from a command the input may be to suggest it,
+// 0 if suggestions are turned off with a negative 'suggestion_max_distance'.
+func suggestionMaxDistance(bot *models.Bot) int {
+	switch {
+	case bot.SuggestionMaxDistance < 0:
+		return 0
+	case bot.SuggestionMaxDistance == 0:
+		return defaultSuggestionMaxDistance
+	default:
+		return bot.SuggestionMaxDistance
+	}
+}
+
+// didYouMean asks whether the user meant one of the commands.
+func didYouMean(commands []string) string {
+	if len(commands) == 1 {
+		return fmt.Sprintf("did you mean %s?", commands[0])
+	}
+
+	return fmt.Sprintf("did you mean %s or %s?", strings.Join(commands[:len(commands)-1], ", "), commands[len(commands)-1])
+}
@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"testing"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+func Test_handleNoMatch_suggestions(t *testing.T) {
+	rules := map[string]models.Rule{
+		"deploy.yml":  {Name: "deploy", Respond: `/^deploy (\w+)/`, HelpText: "deploy <app>", Active: true, IncludeInHelp: true},
+		"deploys.yml": {Name: "list deploys", Respond: "deploys", HelpText: "deploys", Active: true, IncludeInHelp: true},
+		"restart.yml": {Name: "restart", Respond: "restart", HelpText: "restart <app>", AllowUsers: []string{"admin"}, Active: true, IncludeInHelp: true},
+		"status.yml":  {Name: "show status", Respond: "status", HelpText: "status", Active: true, IncludeInHelp: true},
+		"ls.yml":      {Name: "ls", Respond: "ls", HelpText: "ls", Active: true, IncludeInHelp: true},
+	}
+
+	bot := &models.Bot{SuggestionMaxDistance: 2, ChatApplications: []string{"slack"}}
+
+	tests := []struct {
+		name  string
+		input string
+		bot   *models.Bot
+		want  string
+	}{
+		{"Typo", "delpoy api", bot, "did you mean `deploy` or `deploys`?"},
+		{"Closest first", "deployss", bot, "did you mean `deploys` or `deploy`?"},
+		{"Rule name", "show statsu", bot, "did you mean `status`?"},
+		{"Not allowed to run", "restrat app", bot, "I understand these commands: \n\n • deploy <app>\n • deploys\n • ls\n • status"},
+		{"Short commands", "cd", bot, "I understand these commands: \n\n • deploy <app>\n • deploys\n • ls\n • status"},
+		{"Nothing close", "weather", bot, "I understand these commands: \n\n • deploy <app>\n • deploys\n • ls\n • status"},
+		{"Default distance", "delpoy api", &models.Bot{}, "did you mean `deploy` or `deploys`?"},
+		{"Help disabled", "delpoy api", &models.Bot{DisableNoMatchHelp: true}, ""},
+		{"Help disabled with suggestions", "delpoy api", &models.Bot{DisableNoMatchHelp: true, SuggestionMaxDistance: 2}, "did you mean `deploy` or `deploys`?"},
+		{"Disabled", "delpoy api", &models.Bot{SuggestionMaxDistance: -1}, "I understand these commands: \n\n • deploy <app>\n • deploys\n • ls\n • status"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputMsgs := make(chan models.Envelope, 1)
+
+			message := models.Message{Input: tt.input, BotMentioned: true, Vars: map[string]string{"_user.name": "someone"}}
+			handleNoMatch(outputMsgs, message, rules, tt.bot)
+
+			var got string
+
+			select {
+			case env := <-outputMsgs:
+				got = env.Message.Output
+			default:
+			}
+
+			if got != tt.want {
+				t.Errorf("handleNoMatch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
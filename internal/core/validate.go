@@ -0,0 +1,447 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+
+	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/remote"
+	"github.com/target/flottbot/internal/remote/scheduler"
+	"github.com/target/flottbot/internal/text"
+)
+
+// builtinVars are the variables set for every rule, or by its actions.
+var builtinVars = []string{
+	"_error",
+	"_error_action",
+	"_exec_output",
+	"_exec_status",
+	"_is_thread_message",
+	"_raw_http_output",
+	"_raw_http_status",
+	"_raw_user_input",
+	"_store_value",
+}
+
+// builtinVarPrefixes are the groups of variables set depending on the message,
+// ie. '_user.email' or '_interaction.values.reason'.
+var builtinVarPrefixes = []string{
+	"_channel.",
+	"_command.",
+	"_interaction.",
+	"_rate_limit.",
+	"_reaction.",
+	"_rule.",
+	"_source.",
+	"_thread.",
+	"_user.",
+}
+
+// Problem is a mistake in the configuration of the bot, found by 'Validate'.
+type Problem struct {
+	File    string // bot.yml or the rule file
+	Field   string // path of the field in the file, ie. 'actions[0].type', if the problem is with a field
+	Message string
+	Warning bool // the bot may run fine, ie. variables set in the environment of the bot but not where it is validated
+}
+
+func (p Problem) String() string {
+	message := p.Message
+	if p.Warning {
+		message = "warning: " + message
+	}
+
+	if p.Field == "" {
+		return fmt.Sprintf("%s: %s", p.File, message)
+	}
+
+	return fmt.Sprintf("%s: %s: %s", p.File, p.Field, message)
+}
+
+// Validate checks the bot.yml and all rule files the way the bot loads them, and beyond
+// what the bot checks on startup, ie. for unknown keys and undefined variables. Variables
+// that are neither defined by the rule nor set in the environment are only warned about.
+// Rooms are only checked if the bot.yml lists them in 'slack_channels',
+// otherwise the chat applications look them up once they are connected.
+func Validate() []Problem {
+	bot, problems := validateBot()
+
+	rulesDir, err := getRulesDir()
+	if err != nil {
+		return append(problems, Problem{File: relPath(rulesDir), Message: err.Error()})
+	}
+
+	names := map[string]string{} // rule name -> file
+
+	for _, ruleFile := range getRuleFiles(rulesDir) {
+		rule, ruleProblems := validateRuleFile(ruleFile, bot)
+		problems = append(problems, ruleProblems...)
+
+		if rule.Name == "" {
+			continue
+		}
+
+		if other, ok := names[rule.Name]; ok {
+			problems = append(problems, Problem{
+				File:    relPath(ruleFile),
+				Field:   "name",
+				Message: fmt.Sprintf("rule name %#q is already used by %s", rule.Name, other),
+			})
+
+			continue
+		}
+
+		names[rule.Name] = relPath(ruleFile)
+	}
+
+	return problems
+}
+
+// validateBot checks the bot.yml.
+func validateBot() (*models.Bot, []Problem) {
+	bot := new(models.Bot)
+
+	v, err := models.ReadBotConfig()
+	if err != nil {
+		return bot, []Problem{{File: "bot.yml", Message: err.Error()}}
+	}
+
+	file := relPath(v.ConfigFileUsed())
+	md := mapstructure.Metadata{}
+
+	err = v.Unmarshal(bot, func(c *mapstructure.DecoderConfig) { c.Metadata = &md })
+	if err != nil {
+		return bot, []Problem{{File: file, Message: err.Error()}}
+	}
+
+	problems := checkKeys(file, reflect.ValueOf(bot).Elem(), md)
+
+	if len(bot.ChatApplications) == 0 && !bot.CLI {
+		problems = append(problems, Problem{File: file, Field: "chat_application", Message: "no chat application is set and cli mode is not enabled"})
+	}
+
+	for i, chatApp := range bot.ChatApplications {
+		if _, ok := remote.Lookup(strings.ToLower(chatApp)); !ok {
+			problems = append(problems, Problem{
+				File:    file,
+				Field:   fmt.Sprintf("chat_application[%d]", i),
+				Message: fmt.Sprintf("chat application %#q is not supported, use one of: %s", chatApp, strings.Join(remote.Registered(), ", ")),
+			})
+		}
+	}
+
+	durations := []struct{ field, value string }{
+		{"scheduler_lock_ttl", bot.SchedulerLockTTL},
+		{"shutdown_grace_period", bot.ShutdownGracePeriod},
+	}
+
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+
+		if _, err := time.ParseDuration(d.value); err != nil {
+			problems = append(problems, Problem{File: file, Field: d.field, Message: fmt.Sprintf("invalid duration %#q", d.value)})
+		}
+	}
+
+	return bot, problems
+}
+
+// validateRuleFile checks a rule file, the rule is returned to check it against the other rules.
+func validateRuleFile(ruleFile string, bot *models.Bot) (models.Rule, []Problem) {
+	file := relPath(ruleFile)
+	md := mapstructure.Metadata{}
+
+	rule, err := decodeRule(ruleFile, &md)
+	if err != nil {
+		return rule, []Problem{{File: file, Message: err.Error()}}
+	}
+
+	problems := checkKeys(file, reflect.ValueOf(&rule).Elem(), md)
+
+	add := func(field, format string, a ...any) {
+		problems = append(problems, Problem{File: file, Field: field, Message: fmt.Sprintf(format, a...)})
+	}
+
+	if rule.Respond != "" && rule.Hear != "" {
+		add("hear", "`hear` and `respond` are both set, only `respond` is used")
+	}
+
+	if len(rule.Args) > 0 && rule.Hear != "" {
+		add("args", "`args` only apply to `respond`, `hear` does not take arguments")
+	}
+
+	if rule.Schedule != "" {
+		if err := scheduler.ValidateSchedule(rule.Schedule); err != nil {
+			add("schedule", "invalid cron spec %#q: %v", rule.Schedule, err)
+		}
+	}
+
+	defined := ruleVars(rule)
+
+	walkStrings(reflect.ValueOf(rule), "", func(field, value string) {
+		for _, name := range text.Vars(value) {
+			switch {
+			case defined[name] || isBuiltinVar(name) || os.Getenv(name) != "":
+			case strings.HasPrefix(name, "_"):
+				add(field, "variable %#q is not set by the bot", name)
+			default:
+				// the environment of the bot may differ from where it is validated, ie. in CI
+				problems = append(problems, Problem{
+					File:    file,
+					Field:   field,
+					Message: fmt.Sprintf("variable %#q is not defined by the rule, nor set in the environment", name),
+					Warning: true,
+				})
+			}
+		}
+	})
+
+	for _, unknown := range unknownRooms(rule, bot) {
+		add(unknown.field, "room %#q is not listed in 'slack_channels' of bot.yml", unknown.rooms[0])
+	}
+
+	// the checks the bot runs when loading the rule
+	if err := validateRule(&rule); err != nil {
+		add("", "%v", err)
+	}
+
+	return rule, problems
+}
+
+// checkKeys reports the keys that don't belong to any field, and the required fields,
+// tagged 'binding:"required"', that are missing. Strings and lists also have to be non-empty.
+func checkKeys(file string, v reflect.Value, md mapstructure.Metadata) []Problem {
+	problems := []Problem{}
+
+	for _, key := range slices.Sorted(slices.Values(md.Unused)) {
+		problems = append(problems, Problem{File: file, Field: key, Message: "unknown key"})
+	}
+
+	keys := make(map[string]bool, len(md.Keys))
+	for _, key := range md.Keys {
+		keys[key] = true
+	}
+
+	for _, field := range missingFields(v, "", keys) {
+		problems = append(problems, Problem{File: file, Field: field, Message: "required field is missing"})
+	}
+
+	return problems
+}
+
+// missingFields returns the paths of the required fields of the struct that are missing.
+func missingFields(v reflect.Value, path string, keys map[string]bool) []string {
+	var missing []string
+
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+
+		name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "" {
+			continue
+		}
+
+		fieldPath := joinPath(path, name)
+		value := v.Field(i)
+
+		if field.Tag.Get("binding") == "required" && !isSet(value, keys[fieldPath]) {
+			missing = append(missing, fieldPath)
+
+			continue
+		}
+
+		switch value.Kind() { //nolint:exhaustive // only structs and lists of structs have fields
+		case reflect.Struct:
+			if keys[fieldPath] {
+				missing = append(missing, missingFields(value, fieldPath, keys)...)
+			}
+		case reflect.Slice:
+			if value.Type().Elem().Kind() != reflect.Struct {
+				continue
+			}
+
+			for j := range value.Len() {
+				missing = append(missing, missingFields(value.Index(j), fmt.Sprintf("%s[%d]", fieldPath, j), keys)...)
+			}
+		}
+	}
+
+	return missing
+}
+
+// isSet tells whether a required value is set. Strings and lists have to be non-empty,
+// other values only have to be decoded from the file.
+func isSet(v reflect.Value, decoded bool) bool {
+	switch v.Kind() { //nolint:exhaustive // other kinds can't be empty
+	case reflect.String, reflect.Slice, reflect.Map:
+		return v.Len() > 0
+	default:
+		return decoded
+	}
+}
+
+// walkStrings calls fn for every string in the value, along with the path of its field.
+func walkStrings(v reflect.Value, path string, fn func(field, value string)) {
+	switch v.Kind() { //nolint:exhaustive // other kinds don't hold strings
+	case reflect.String:
+		fn(path, v.String())
+	case reflect.Interface, reflect.Pointer:
+		if !v.IsNil() {
+			walkStrings(v.Elem(), path, fn)
+		}
+	case reflect.Slice:
+		for i := range v.Len() {
+			walkStrings(v.Index(i), fmt.Sprintf("%s[%d]", path, i), fn)
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return strings.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+		})
+
+		for _, key := range keys {
+			walkStrings(v.MapIndex(key), joinPath(path, fmt.Sprint(key.Interface())), fn)
+		}
+	case reflect.Struct:
+		for i := range v.NumField() {
+			name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("mapstructure"), ",")
+			if name != "" {
+				walkStrings(v.Field(i), joinPath(path, name), fn)
+			}
+		}
+	}
+}
+
+// ruleVars returns the variables the rule defines, ie. its arguments or the fields exposed by its actions.
+func ruleVars(rule models.Rule) map[string]bool {
+	vars := map[string]bool{}
+
+	for _, arg := range rule.Args {
+		vars[arg.Name] = true
+	}
+
+	for _, flag := range rule.Flags {
+		vars["flag."+flag.Name] = true
+	}
+
+	for _, step := range rule.Steps {
+		vars[step.Var] = true
+	}
+
+	for _, pattern := range []string{rule.Respond, rule.Hear} {
+		if p, err := text.Compile(pattern); pattern != "" && err == nil {
+			for _, name := range p.CaptureNames() {
+				vars[name] = true
+			}
+		}
+	}
+
+	var addActions func(actions []models.Action)
+
+	addActions = func(actions []models.Action) {
+		for _, action := range actions {
+			if action.Var != "" {
+				vars[action.Var] = true
+			}
+
+			for name := range action.ExposeJSONFields {
+				vars[name] = true
+			}
+
+			addActions(action.OnError)
+		}
+	}
+
+	addActions(rule.Actions)
+	addActions(rule.OnFailure)
+
+	return vars
+}
+
+// isBuiltinVar tells whether the variable is set by the bot.
+func isBuiltinVar(name string) bool {
+	if slices.Contains(builtinVars, name) {
+		return true
+	}
+
+	return slices.ContainsFunc(builtinVarPrefixes, func(prefix string) bool {
+		return strings.HasPrefix(name, prefix)
+	})
+}
+
+// roomsField is a field of a rule that refers to rooms.
+type roomsField struct {
+	field string
+	rooms []string
+}
+
+// ruleRooms returns the rooms the rule refers to, along with the path of their field.
+func ruleRooms(rule models.Rule) []roomsField {
+	rooms := []roomsField{
+		{"output_to_rooms", rule.OutputToRooms},
+		{"limit_to_rooms", rule.LimitToRooms},
+	}
+
+	for i, action := range rule.Actions {
+		rooms = append(rooms,
+			roomsField{fmt.Sprintf("actions[%d].output_to_rooms", i), action.OutputToRooms},
+			roomsField{fmt.Sprintf("actions[%d].limit_to_rooms", i), action.LimitToRooms},
+		)
+	}
+
+	return rooms
+}
+
+// unknownRooms returns the rooms of the rule the bot doesn't know, each along with its field. Rooms are
+// only known if the bot.yml lists them, the chat applications look them up once they're connected.
+func unknownRooms(rule models.Rule, bot *models.Bot) []roomsField {
+	var unknown []roomsField
+
+	if len(bot.Rooms) == 0 {
+		return unknown
+	}
+
+	for _, rooms := range ruleRooms(rule) {
+		for _, room := range rooms.rooms {
+			if _, ok := bot.Rooms[strings.ToLower(room)]; !ok && !strings.Contains(room, "${") {
+				unknown = append(unknown, roomsField{rooms.field, []string{room}})
+			}
+		}
+	}
+
+	return unknown
+}
+
+// joinPath appends the name of a field to the path of its parent.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+// relPath returns the path relative to the working directory, if possible.
+func relPath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+
+	if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+
+	return path
+}
@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+func Test_validateRuleFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     []string // fields with a problem, in order, and whether it is a warning
+	}{
+		{"Valid", "name: deploy\nactive: true\nrespond: deploy\nargs: [app]\nformat_output: deploying ${app} for ${_user.name}\n", nil},
+		{"Unknown key", "name: deploy\nactive: true\nrespond: deploy\nformat_ouput: done\n", []string{"format_ouput"}},
+		{"Missing required fields", "respond: deploy\nactions:\n  - type: exec\n    cmd: deploy.sh\n", []string{"name", "active", "actions[0].name"}},
+		{"Hear and respond", "name: deploy\nactive: true\nrespond: deploy\nhear: deploy\n", []string{"hear"}},
+		{"Args with hear", "name: deploy\nactive: true\nhear: deploy\nargs: [app]\n", []string{"args"}},
+		{"Invalid regex", "name: deploy\nactive: true\nrespond: /deploy (\\w+/\n", []string{""}},
+		{"Invalid cron spec", "name: deploy\nactive: true\nschedule: every day\n", []string{"schedule"}},
+		{"Undefined var", "name: deploy\nactive: true\nrespond: deploy\nactions:\n  - name: call\n    type: http\n    url: http://deploy/${app}\n", []string{"actions[0].url (warning)"}},
+		{"Vars defined by actions", "name: deploy\nactive: true\nrespond: /deploy (?P<app>\\w+)/\nactions:\n  - name: call\n    type: http\n    url: http://deploy/${app}\n    expose_json_fields:\n      id: .id\nformat_output: ${id} ${_raw_http_status}\n", nil},
+		{"Unknown builtin var", "name: deploy\nactive: true\nrespond: deploy\nformat_output: ${_exec_ouput}\n", []string{"format_output"}},
+		{"Unknown room", "name: deploy\nactive: true\nrespond: deploy\noutput_to_rooms: [general, random]\n", []string{"output_to_rooms"}},
+	}
+
+	bot := &models.Bot{Rooms: map[string]string{"general": "C1"}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ruleFile := writeRuleFile(t, t.TempDir(), "rule.yml", tt.contents)
+
+			_, problems := validateRuleFile(ruleFile, bot)
+
+			var got []string
+			for _, problem := range problems {
+				if problem.Warning {
+					got = append(got, problem.Field+" (warning)")
+				} else {
+					got = append(got, problem.Field)
+				}
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("validateRuleFile() problems = %v, want problems with %q", problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	rulesDir := filepath.Join(dir, "config", "rules")
+
+	if err := os.MkdirAll(rulesDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	writeRuleFile(t, filepath.Join(dir, "config"), "bot.yml", "name: flottbot\nchat_application: slakc\n")
+	writeRuleFile(t, rulesDir, "a.yml", "name: deploy\nactive: true\nrespond: deploy\n")
+	writeRuleFile(t, rulesDir, "b.yml", "name: deploy\nactive: true\nrespond: ship\n")
+
+	t.Chdir(dir)
+
+	var got []string
+	for _, problem := range Validate() {
+		got = append(got, problem.File+": "+problem.Field)
+	}
+
+	want := []string{
+		filepath.Join("config", "bot.yml") + ": chat_application[0]",
+		filepath.Join("config", "rules", "b.yml") + ": name",
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("Validate() problems = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,356 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/text"
+)
+
+var (
+	defaultWorkers         = 10
+	defaultWorkerQueueSize = 100
+
+	defaultShutdownGracePeriod = 30 * time.Second
+)
+
+const (
+	defaultBusyMessage        = "i'm a bit busy right now, try again in a moment."
+	defaultConcurrencyMessage = "`${_rule.name}` is already running, try again when it's done."
+)
+
+// ruleJob is a hit rule waiting for its actions to run.
+type ruleJob struct {
+	message    models.Message
+	rule       models.Rule
+	outputMsgs chan<- models.Envelope
+	bot        *models.Bot
+	slot       bool // the request waited for the concurrency of the rule and took over the place of a done one
+}
+
+// ruleSlots limits how many times a rule runs at the same time.
+type ruleSlots struct {
+	pending int       // queued, waiting or running
+	running int       // running
+	waiting []ruleJob // waiting for one of the running ones to be done
+}
+
+// workerPool runs the actions of hit rules with a fixed number of workers, each with a bounded queue.
+// All rules hit in a channel go to the same worker and run one after the other, so replies keep their order.
+// Requests beyond the concurrency of a rule wait aside, they go back to the queue of their channel
+// once a running one is done.
+type workerPool struct {
+	queues   []chan ruleJob
+	start    sync.Once
+	running  sync.WaitGroup
+	inflight sync.WaitGroup // requests that are queued, waiting or running
+
+	mu    sync.Mutex
+	rules map[string]*ruleSlots
+}
+
+func newWorkerPool(workers, queueSize int) *workerPool {
+	p := &workerPool{
+		queues: make([]chan ruleJob, workers),
+		rules:  make(map[string]*ruleSlots),
+	}
+
+	for i := range p.queues {
+		p.queues[i] = make(chan ruleJob, queueSize)
+	}
+
+	return p
+}
+
+// workerCount returns the number of workers that run rules and send replies.
+func workerCount(bot *models.Bot) int {
+	if bot.Workers > 0 {
+		return bot.Workers
+	}
+
+	return defaultWorkers
+}
+
+// QueueSize returns how many messages may wait for each worker.
+func QueueSize(bot *models.Bot) int {
+	if bot.WorkerQueueSize > 0 {
+		return bot.WorkerQueueSize
+	}
+
+	return defaultWorkerQueueSize
+}
+
+// ShutdownGracePeriod returns how long running rules and pending replies may take
+// to finish when the bot shuts down.
+func ShutdownGracePeriod(bot *models.Bot) time.Duration {
+	if bot.ShutdownGracePeriod == "" {
+		return defaultShutdownGracePeriod
+	}
+
+	period, err := time.ParseDuration(bot.ShutdownGracePeriod)
+	if err != nil || period <= 0 {
+		log.Warn().Msgf("invalid shutdown_grace_period %#q, using %s", bot.ShutdownGracePeriod, defaultShutdownGracePeriod)
+
+		return defaultShutdownGracePeriod
+	}
+
+	return period
+}
+
+// channelKey identifies the channel a message was sent in, scheduled messages don't have one.
+func channelKey(message models.Message, fallback string) string {
+	if message.ChannelID == "" {
+		return fallback
+	}
+
+	return message.Remote + "|" + message.ChannelID
+}
+
+// shard picks one of n workers for the key.
+func shard(key string, n int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+
+	return int(h.Sum32() % uint32(n)) //nolint:gosec // n is a small positive number
+}
+
+// runRule queues the actions of the hit rule. The request is turned down if the queue is full
+// or if the rule rejects requests beyond its 'concurrency'.
+func (p *workerPool) runRule(outputMsgs chan<- models.Envelope, message models.Message, rule models.Rule, bot *models.Bot) {
+	p.submit(ruleJob{message: message, rule: rule, outputMsgs: outputMsgs, bot: bot})
+}
+
+func (p *workerPool) submit(j ruleJob) {
+	p.start.Do(func() {
+		for _, queue := range p.queues {
+			p.running.Go(func() { p.work(queue) })
+		}
+	})
+
+	if !p.reserve(j.rule) {
+		log.Info().Msgf("rule %#q is already running %d times, rejecting request", j.rule.Name, j.rule.Concurrency.Max)
+
+		msg := j.rule.Concurrency.Message
+		if msg == "" {
+			msg = defaultConcurrencyMessage
+		}
+
+		vars := maps.Clone(j.message.Vars)
+		if vars == nil {
+			vars = map[string]string{}
+		}
+
+		vars["_rule.name"] = j.rule.Name
+
+		output, err := text.Substitute(msg, vars)
+		if err != nil {
+			log.Warn().Msgf("unable to substitute variables in concurrency message of rule %#q: %v", j.rule.Name, err)
+		}
+
+		turnDown(j, output)
+
+		return
+	}
+
+	p.inflight.Add(1)
+
+	select {
+	case p.queue(j) <- j:
+		workerQueueDepth.Inc()
+	default:
+		log.Warn().Msgf("worker queue is full, rejecting rule %#q", j.rule.Name)
+		p.inflight.Done()
+		p.release(j.rule)
+		turnDown(j, defaultBusyMessage)
+	}
+}
+
+// queue returns the queue of the worker for the channel the rule was hit in.
+func (p *workerPool) queue(j ruleJob) chan<- ruleJob {
+	return p.queues[shard(channelKey(j.message, j.rule.Name), len(p.queues))]
+}
+
+// requeue puts a request that waited for the concurrency of its rule back on the queue of its channel.
+func (p *workerPool) requeue(j ruleJob) {
+	workerQueueDepth.Inc()
+
+	select {
+	case p.queue(j) <- j:
+	default:
+		// the request was accepted already, it waits for room in the queue without holding up the worker
+		go func() { p.queue(j) <- j }()
+	}
+}
+
+// stop waits for the queued and waiting rules to run, then closes the queues and waits for the workers.
+// No rules may be submitted once the pool is stopped.
+func (p *workerPool) stop() {
+	p.start.Do(func() {}) // nothing to wait for if no rule was ever submitted
+
+	// waiting rules go back to the queues once the running ones are done
+	p.inflight.Wait()
+
+	for _, queue := range p.queues {
+		close(queue)
+	}
+
+	p.running.Wait()
+}
+
+// turnDown tells the user the rule won't run.
+func turnDown(j ruleJob, output string) {
+	j.message.Output = output
+	j.message.IsEphemeral = true
+
+	j.outputMsgs <- models.Envelope{Message: j.message, Rule: j.rule, Ops: models.OpSend}
+}
+
+// work runs the queued rules one after the other.
+func (p *workerPool) work(queue <-chan ruleJob) {
+	for j := range queue {
+		workerQueueDepth.Dec()
+		p.execute(j)
+	}
+}
+
+// execute runs the actions of the rule if it is below its concurrency, otherwise the rule
+// waits for a running one to be done, without holding up the worker.
+func (p *workerPool) execute(j ruleJob) {
+	if !p.acquire(j) {
+		return
+	}
+
+	doRuleActions(j.message, j.outputMsgs, j.rule, j.bot)
+
+	p.done(j.rule)
+	p.inflight.Done()
+}
+
+// ruleKey identifies the concurrency limit of a rule.
+func ruleKey(rule models.Rule) string {
+	return rule.Name + "|" + strconv.Itoa(rule.Concurrency.Max)
+}
+
+// reserve counts the request towards the concurrency of the rule,
+// it returns false if the rule rejects requests beyond its concurrency.
+func (p *workerPool) reserve(rule models.Rule) bool {
+	if rule.Concurrency.Max <= 0 {
+		return true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := ruleKey(rule)
+
+	s, ok := p.rules[key]
+	if !ok {
+		s = &ruleSlots{}
+		p.rules[key] = s
+	}
+
+	if rule.Concurrency.OnLimit == models.ConcurrencyReject && s.pending >= rule.Concurrency.Max {
+		return false
+	}
+
+	s.pending++
+
+	return true
+}
+
+// release removes a request that never ran from the concurrency of the rule.
+func (p *workerPool) release(rule models.Rule) {
+	if rule.Concurrency.Max <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.removePending(rule)
+}
+
+// acquire tells whether the rule may run now, otherwise the request waits for a running one to be done.
+func (p *workerPool) acquire(j ruleJob) bool {
+	if j.rule.Concurrency.Max <= 0 || j.slot {
+		return true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s := p.rules[ruleKey(j.rule)]
+	if s.running >= j.rule.Concurrency.Max {
+		s.waiting = append(s.waiting, j)
+		return false
+	}
+
+	s.running++
+
+	return true
+}
+
+// done removes the request that ran from the concurrency of the rule. If another request
+// of the rule is waiting, it takes over the place of the done one and goes back to the queue
+// of its channel, so it runs in order with the other rules hit there.
+func (p *workerPool) done(rule models.Rule) {
+	if rule.Concurrency.Max <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+
+	s := p.rules[ruleKey(rule)]
+	p.removePending(rule)
+
+	if len(s.waiting) == 0 {
+		s.running--
+		p.mu.Unlock()
+
+		return
+	}
+
+	next := s.waiting[0]
+	s.waiting = s.waiting[1:]
+
+	p.mu.Unlock()
+
+	next.slot = true
+	p.requeue(next)
+}
+
+// removePending removes a request from the concurrency of the rule, p.mu must be held.
+func (p *workerPool) removePending(rule models.Rule) {
+	key := ruleKey(rule)
+
+	s := p.rules[key]
+
+	s.pending--
+	if s.pending == 0 {
+		delete(p.rules, key)
+	}
+}
+
+// validateConcurrency checks the concurrency of a rule.
+func validateConcurrency(c models.Concurrency) error {
+	if c.Max < 0 {
+		return errors.New("concurrency 'max' cannot be negative")
+	}
+
+	switch c.OnLimit {
+	case "", models.ConcurrencyQueue, models.ConcurrencyReject:
+		return nil
+	default:
+		return fmt.Errorf("concurrency 'on_limit' %#q is unknown, use 'queue' or 'reject'", c.OnLimit)
+	}
+}
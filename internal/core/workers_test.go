@@ -0,0 +1,259 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+func testRuleJob(channel, output string, rule models.Rule, outputMsgs chan models.Envelope) ruleJob {
+	rule.FormatOutput = output
+
+	return ruleJob{
+		message:    models.Message{ChannelID: channel, Vars: map[string]string{}},
+		rule:       rule,
+		outputMsgs: outputMsgs,
+		bot:        new(models.Bot),
+	}
+}
+
+func Test_workerPool_order(t *testing.T) {
+	p := newWorkerPool(4, 100)
+
+	outputMsgs := make(chan models.Envelope, 100)
+
+	// the first rule in the channel is the slowest, the replies must keep their order anyway
+	slow := models.Rule{Name: "slow", Actions: []models.Action{{Name: "sleep", Type: "exec", Cmd: "sleep 0.2"}}}
+	p.submit(testRuleJob("C1", "1", slow, outputMsgs))
+
+	for i := 2; i <= 5; i++ {
+		p.submit(testRuleJob("C1", fmt.Sprint(i), models.Rule{Name: "fast"}, outputMsgs))
+	}
+
+	got := []string{}
+	for range 5 {
+		got = append(got, (<-outputMsgs).Message.Output)
+	}
+
+	if want := []string{"1", "2", "3", "4", "5"}; !slices.Equal(got, want) {
+		t.Errorf("workerPool replies = %v, want %v", got, want)
+	}
+}
+
+func Test_workerPool_concurrency(t *testing.T) {
+	deploy := models.Rule{
+		Name:        "deploy",
+		Actions:     []models.Action{{Name: "sleep", Type: "exec", Cmd: "sleep 0.2"}},
+		Concurrency: models.Concurrency{Max: 1},
+	}
+
+	t.Run("Queue", func(t *testing.T) {
+		p := newWorkerPool(4, 100)
+
+		outputMsgs := make(chan models.Envelope, 10)
+
+		start := time.Now()
+
+		// different channels, but only one deploy at a time
+		p.submit(testRuleJob("C1", "first", deploy, outputMsgs))
+		p.submit(testRuleJob("C2", "second", deploy, outputMsgs))
+
+		got := []string{(<-outputMsgs).Message.Output, (<-outputMsgs).Message.Output}
+		slices.Sort(got)
+
+		if !slices.Equal(got, []string{"first", "second"}) {
+			t.Errorf("workerPool replies = %v", got)
+		}
+
+		if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+			t.Errorf("workerPool expected the deploys to run one after the other, took %v", elapsed)
+		}
+	})
+
+	t.Run("Queue without holding up the worker", func(t *testing.T) {
+		p := newWorkerPool(2, 100)
+
+		outputMsgs := make(chan models.Envelope, 10)
+
+		// the second deploy waits on the other worker, along with a fast rule
+		other := "C2"
+		for shard(other, 2) == shard("C1", 2) {
+			other += "2"
+		}
+
+		p.submit(testRuleJob("C1", "first", deploy, outputMsgs))
+		time.Sleep(50 * time.Millisecond)
+		p.submit(testRuleJob(other, "second", deploy, outputMsgs))
+		p.submit(testRuleJob(other, "fast", models.Rule{Name: "fast"}, outputMsgs))
+
+		got := []string{}
+		for range 3 {
+			got = append(got, (<-outputMsgs).Message.Output)
+		}
+
+		if want := []string{"fast", "first", "second"}; !slices.Equal(got, want) {
+			t.Errorf("workerPool replies = %v, want %v", got, want)
+		}
+
+		waitForIdle(t, p)
+	})
+
+	t.Run("Queue on the worker of the channel", func(t *testing.T) {
+		p := newWorkerPool(2, 100)
+
+		outputMsgs := make(chan models.Envelope, 10)
+
+		// the second deploy runs on the worker of its own channel, not the one of the first deploy
+		other := "C2"
+		for shard(other, 2) == shard("C1", 2) {
+			other += "2"
+		}
+
+		p.submit(testRuleJob("C1", "first", deploy, outputMsgs))
+		time.Sleep(50 * time.Millisecond)
+		p.submit(testRuleJob(other, "second", deploy, outputMsgs))
+		p.submit(testRuleJob("C1", "fast", models.Rule{Name: "fast"}, outputMsgs))
+
+		got := []string{}
+		for range 3 {
+			got = append(got, (<-outputMsgs).Message.Output)
+		}
+
+		if want := []string{"first", "fast", "second"}; !slices.Equal(got, want) {
+			t.Errorf("workerPool replies = %v, want %v", got, want)
+		}
+
+		waitForIdle(t, p)
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		p := newWorkerPool(4, 100)
+
+		outputMsgs := make(chan models.Envelope, 10)
+
+		reject := deploy
+		reject.Concurrency.OnLimit = models.ConcurrencyReject
+
+		p.submit(testRuleJob("C1", "first", reject, outputMsgs))
+		p.submit(testRuleJob("C2", "second", reject, outputMsgs))
+
+		if got := (<-outputMsgs).Message; got.Output != "`deploy` is already running, try again when it's done." || !got.IsEphemeral {
+			t.Errorf("workerPool expected the second deploy to be rejected, got %q", got.Output)
+		}
+
+		if got := (<-outputMsgs).Message.Output; got != "first" {
+			t.Errorf("workerPool expected the first deploy to run, got %q", got)
+		}
+
+		// the deploy is done, the next one may run
+		waitForIdle(t, p)
+
+		p.submit(testRuleJob("C2", "third", reject, outputMsgs))
+
+		if got := (<-outputMsgs).Message.Output; got != "third" {
+			t.Errorf("workerPool expected the third deploy to run, got %q", got)
+		}
+	})
+}
+
+// waitForIdle waits until no rule with a concurrency limit is queued or running.
+func waitForIdle(t *testing.T, p *workerPool) {
+	t.Helper()
+
+	for range 100 {
+		p.mu.Lock()
+		idle := len(p.rules) == 0
+		p.mu.Unlock()
+
+		if idle {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("workerPool expected to become idle")
+}
+
+func Test_workerPool_full(t *testing.T) {
+	p := newWorkerPool(1, 1)
+
+	outputMsgs := make(chan models.Envelope, 10)
+
+	slow := models.Rule{Name: "slow", Actions: []models.Action{{Name: "sleep", Type: "exec", Cmd: "sleep 0.2"}}}
+
+	// one running, one queued, one too many
+	p.submit(testRuleJob("C1", "1", slow, outputMsgs))
+	time.Sleep(50 * time.Millisecond)
+	p.submit(testRuleJob("C1", "2", slow, outputMsgs))
+	p.submit(testRuleJob("C1", "3", slow, outputMsgs))
+
+	if got := (<-outputMsgs).Message; got.Output != defaultBusyMessage {
+		t.Errorf("workerPool expected a full queue to turn down the rule, got %q", got.Output)
+	}
+}
+
+func Test_workerPool_stop(t *testing.T) {
+	p := newWorkerPool(1, 10)
+
+	outputMsgs := make(chan models.Envelope, 10)
+
+	slow := models.Rule{Name: "slow", Actions: []models.Action{{Name: "sleep", Type: "exec", Cmd: "sleep 0.2"}}}
+
+	// one running, one queued
+	p.submit(testRuleJob("C1", "1", slow, outputMsgs))
+	p.submit(testRuleJob("C1", "2", slow, outputMsgs))
+
+	p.stop()
+
+	if got := len(outputMsgs); got != 2 {
+		t.Errorf("workerPool expected stop to wait for the running and queued rules, got %d replies", got)
+	}
+}
+
+func Test_workerPool_stopWaiting(t *testing.T) {
+	p := newWorkerPool(2, 10)
+
+	outputMsgs := make(chan models.Envelope, 10)
+
+	deploy := models.Rule{
+		Name:        "deploy",
+		Actions:     []models.Action{{Name: "sleep", Type: "exec", Cmd: "sleep 0.2"}},
+		Concurrency: models.Concurrency{Max: 1},
+	}
+
+	// one running, one waiting for it in another channel
+	p.submit(testRuleJob("C1", "1", deploy, outputMsgs))
+	p.submit(testRuleJob("C2", "2", deploy, outputMsgs))
+
+	p.stop()
+
+	if got := len(outputMsgs); got != 2 {
+		t.Errorf("workerPool expected stop to wait for the waiting rules, got %d replies", got)
+	}
+}
+
+func TestShutdownGracePeriod(t *testing.T) {
+	tests := []struct {
+		name   string
+		period string
+		want   time.Duration
+	}{
+		{"Default", "", defaultShutdownGracePeriod},
+		{"Configured", "1m", time.Minute},
+		{"Invalid", "soon", defaultShutdownGracePeriod},
+		{"Negative", "-5s", defaultShutdownGracePeriod},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ShutdownGracePeriod(&models.Bot{ShutdownGracePeriod: tt.period}); got != tt.want {
+				t.Errorf("ShutdownGracePeriod() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
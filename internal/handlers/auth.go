@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+
+	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/text"
+)
+
+// Supported auth types for actions.
+const (
+	AuthTypeBasic  = "basic"
+	AuthTypeBearer = "bearer"
+	AuthTypeOAuth2 = "oauth2"
+)
+
+// maxCachedTokens bounds the number of cached oauth2 tokens.
+const maxCachedTokens = 100
+
+// oauth2Tokens caches the tokens of the oauth2 client credentials flow, keyed by
+// token url, client id and scopes, so tokens are reused until they expire.
+var oauth2Tokens = struct {
+	sync.Mutex
+	tokens map[string]cachedToken
+}{tokens: make(map[string]cachedToken)}
+
+// cachedToken is a token along with a hash of the secret it was issued for,
+// so that rotated secrets get a new token without keeping the secret around.
+type cachedToken struct {
+	secret [sha256.Size]byte
+	token  *oauth2.Token
+}
+
+// addAuth sets the authentication defined on the action on the request.
+func addAuth(req *http.Request, auths []models.Auth, msg *models.Message) error {
+	for _, auth := range auths {
+		switch strings.ToLower(auth.Type) {
+		case AuthTypeBasic:
+			user, err := text.Substitute(auth.User, msg.Vars)
+			if err != nil {
+				return fmt.Errorf("failed substituting variables in basic auth user: %w", err)
+			}
+
+			pass, err := text.Substitute(auth.Pass, msg.Vars)
+			if err != nil {
+				return fmt.Errorf("failed substituting variables in basic auth pass: %w", err)
+			}
+
+			req.SetBasicAuth(user, pass)
+		case AuthTypeBearer:
+			token, err := text.Substitute(auth.Token, msg.Vars)
+			if err != nil {
+				return fmt.Errorf("failed substituting variables in bearer token: %w", err)
+			}
+
+			if token == "" {
+				return errors.New("no token was supplied for bearer auth")
+			}
+
+			req.Header.Set("Authorization", "Bearer "+token)
+		case AuthTypeOAuth2:
+			token, err := getOAuth2Token(req.Context(), auth, msg)
+			if err != nil {
+				return err
+			}
+
+			token.SetAuthHeader(req)
+		default:
+			return fmt.Errorf("auth type %#q is not supported", auth.Type)
+		}
+	}
+
+	return nil
+}
+
+// getOAuth2Token retrieves a token using the client credentials flow,
+// the token is fetched within the context of the request, ie. the timeout of the action.
+func getOAuth2Token(ctx context.Context, auth models.Auth, msg *models.Message) (*oauth2.Token, error) {
+	tokenURL, err := text.Substitute(auth.TokenURL, msg.Vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed substituting variables in oauth2 token url: %w", err)
+	}
+
+	clientID, err := text.Substitute(auth.User, msg.Vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed substituting variables in oauth2 client id: %w", err)
+	}
+
+	clientSecret, err := text.Substitute(auth.Pass, msg.Vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed substituting variables in oauth2 client secret: %w", err)
+	}
+
+	if tokenURL == "" || clientID == "" {
+		return nil, errors.New("oauth2 auth requires 'token_url' and 'user' (client id) to be set")
+	}
+
+	key := strings.Join([]string{tokenURL, clientID, strings.Join(auth.Scopes, " ")}, "|")
+	secret := sha256.Sum256([]byte(clientSecret))
+
+	oauth2Tokens.Lock()
+	cached, ok := oauth2Tokens.tokens[key]
+	oauth2Tokens.Unlock()
+
+	if ok && cached.secret == secret && cached.token.Valid() {
+		return cached.token, nil
+	}
+
+	conf := &clientcredentials.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TokenURL:     tokenURL,
+		Scopes:       auth.Scopes,
+	}
+
+	// tokens are fetched with the transport of 'http' actions
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: liveTransport{}})
+
+	token, err := conf.Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve oauth2 token: %w", err)
+	}
+
+	cacheToken(key, cachedToken{secret: secret, token: token})
+
+	return token, nil
+}
+
+// cacheToken keeps the token for key. Once the cache is full, expired tokens
+// are dropped first, then the token that expires first.
+func cacheToken(key string, cached cachedToken) {
+	oauth2Tokens.Lock()
+	defer oauth2Tokens.Unlock()
+
+	if _, ok := oauth2Tokens.tokens[key]; !ok && len(oauth2Tokens.tokens) >= maxCachedTokens {
+		maps.DeleteFunc(oauth2Tokens.tokens, func(_ string, c cachedToken) bool {
+			return !c.token.Valid()
+		})
+	}
+
+	if _, ok := oauth2Tokens.tokens[key]; !ok && len(oauth2Tokens.tokens) >= maxCachedTokens {
+		var first string
+
+		for k, c := range oauth2Tokens.tokens {
+			if first == "" || c.token.Expiry.Before(oauth2Tokens.tokens[first].token.Expiry) {
+				first = k
+			}
+		}
+
+		delete(oauth2Tokens.tokens, first)
+	}
+
+	oauth2Tokens.tokens[key] = cached
+}
@@ -0,0 +1,194 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+func Test_addAuth(t *testing.T) {
+	var tokenRequests atomic.Int32
+
+	tsToken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok || clientID != "client" || clientSecret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		tokenRequests.Add(1)
+
+		w.Header().Set("Content-Type", "application/json")
+
+		err := json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "oauth-token",
+			"token_type":   "bearer",
+			"expires_in":   3600,
+		})
+		if err != nil {
+			t.Error("unable to send response")
+		}
+	}))
+	defer tsToken.Close()
+
+	t.Setenv("TEST_AUTH_PASS", "s3cr3t")
+	t.Setenv("TEST_AUTH_TOKEN", "t0k3n")
+
+	msg := models.NewMessage()
+	msg.Vars["user"] = "jane"
+
+	tests := []struct {
+		name       string
+		auths      []models.Auth
+		wantHeader string
+		wantErr    bool
+	}{
+		{"No auth", nil, "", false},
+		{"Basic", []models.Auth{{Type: "basic", User: "${user}", Pass: "${TEST_AUTH_PASS}"}}, "Basic amFuZTpzM2NyM3Q=", false},
+		{"Basic with missing var", []models.Auth{{Type: "basic", User: "${user}", Pass: "${TEST_AUTH_MISSING}"}}, "", true},
+		{"Bearer", []models.Auth{{Type: "Bearer", Token: "${TEST_AUTH_TOKEN}"}}, "Bearer t0k3n", false},
+		{"Bearer without token", []models.Auth{{Type: "bearer"}}, "", true},
+		{"OAuth2", []models.Auth{{Type: "oauth2", User: "client", Pass: "secret", TokenURL: tsToken.URL}}, "Bearer oauth-token", false},
+		{"OAuth2 cached token", []models.Auth{{Type: "oauth2", User: "client", Pass: "secret", TokenURL: tsToken.URL}}, "Bearer oauth-token", false},
+		{"OAuth2 bad credentials", []models.Auth{{Type: "oauth2", User: "client", Pass: "wrong", TokenURL: tsToken.URL}}, "", true},
+		{"OAuth2 without token url", []models.Auth{{Type: "oauth2", User: "client", Pass: "secret"}}, "", true},
+		{"Unsupported", []models.Auth{{Type: "digest"}}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://localhost", nil)
+			if err != nil {
+				t.Fatalf("unable to create request: %v", err)
+			}
+
+			err = addAuth(req, tt.auths, &msg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("addAuth() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr && req.Header.Get("Authorization") != tt.wantHeader {
+				t.Errorf("addAuth() Authorization = %v, want %v", req.Header.Get("Authorization"), tt.wantHeader)
+			}
+		})
+	}
+
+	// the token for the valid credentials is only issued once
+	if got := tokenRequests.Load(); got != 1 {
+		t.Errorf("addAuth() expected oauth2 token to be cached, got %d token requests", got)
+	}
+}
+
+func Test_addAuth_transport(t *testing.T) {
+	tsToken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token": "stubbed-token", "token_type": "bearer", "expires_in": 3600}`))
+	}))
+	defer tsToken.Close()
+
+	// the token url isn't reachable, only the transport can answer it
+	SetTransport(redirectTransport{target: tsToken.URL})
+
+	defer SetTransport(http.DefaultTransport)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://localhost", nil)
+	if err != nil {
+		t.Fatalf("unable to create request: %v", err)
+	}
+
+	msg := models.NewMessage()
+	auths := []models.Auth{{Type: "oauth2", User: "client", Pass: "secret", TokenURL: "https://auth.invalid/token"}}
+
+	err = addAuth(req, auths, &msg)
+	if err != nil {
+		t.Fatalf("addAuth() error = %v", err)
+	}
+
+	if got := req.Header.Get("Authorization"); got != "Bearer stubbed-token" {
+		t.Errorf("addAuth() Authorization = %v, want %v", got, "Bearer stubbed-token")
+	}
+}
+
+// redirectTransport sends all requests to the target server.
+type redirectTransport struct {
+	target string
+}
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	target, err := url.Parse(t.target)
+	if err != nil {
+		return nil, err
+	}
+
+	redirected := req.Clone(req.Context())
+	redirected.URL.Scheme = target.Scheme
+	redirected.URL.Host = target.Host
+	redirected.Host = target.Host
+
+	return http.DefaultTransport.RoundTrip(redirected)
+}
+
+func Test_addAuth_timeout(t *testing.T) {
+	release := make(chan struct{})
+
+	tsToken := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		<-release
+	}))
+	defer tsToken.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost", nil)
+	if err != nil {
+		t.Fatalf("unable to create request: %v", err)
+	}
+
+	msg := models.NewMessage()
+	auths := []models.Auth{{Type: "oauth2", User: "client", Pass: "secret", TokenURL: tsToken.URL}}
+
+	err = addAuth(req, auths, &msg)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("addAuth() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func Test_cacheToken(t *testing.T) {
+	t.Cleanup(func() {
+		oauth2Tokens.Lock()
+		defer oauth2Tokens.Unlock()
+
+		clear(oauth2Tokens.tokens)
+	})
+
+	for i := range maxCachedTokens + 10 {
+		expiry := time.Now().Add(time.Duration(i+1) * time.Hour)
+		cacheToken(strconv.Itoa(i), cachedToken{token: &oauth2.Token{AccessToken: "token", Expiry: expiry}})
+	}
+
+	oauth2Tokens.Lock()
+	defer oauth2Tokens.Unlock()
+
+	if got := len(oauth2Tokens.tokens); got != maxCachedTokens {
+		t.Errorf("cacheToken() cached %d tokens, want %d", got, maxCachedTokens)
+	}
+
+	// the tokens that expire first are dropped
+	if _, ok := oauth2Tokens.tokens["0"]; ok {
+		t.Error("cacheToken() expected the token that expires first to be dropped")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -19,6 +20,34 @@ import (
 	"github.com/target/flottbot/internal/text"
 )
 
+var transport = struct {
+	sync.RWMutex
+	rt http.RoundTripper
+}{rt: http.DefaultTransport}
+
+// SetTransport sets the transport of the requests of 'http' actions, ie. to answer them with canned responses.
+func SetTransport(rt http.RoundTripper) {
+	transport.Lock()
+	defer transport.Unlock()
+
+	transport.rt = rt
+}
+
+func currentTransport() http.RoundTripper {
+	transport.RLock()
+	defer transport.RUnlock()
+
+	return transport.rt
+}
+
+// liveTransport sends requests with the transport that is set at the time of the request,
+// for clients that outlive a call to 'SetTransport'.
+type liveTransport struct{}
+
+func (liveTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return currentTransport().RoundTrip(req)
+}
+
 // HTTPReq handles 'http' actions for rules.
 func HTTPReq(args models.Action, msg *models.Message) (*models.HTTPResponse, error) {
 	log.Info().Msgf("executing http request for action %#q", args.Name)
@@ -28,9 +57,11 @@ func HTTPReq(args models.Action, msg *models.Message) (*models.HTTPResponse, err
 		args.Timeout = 10
 	}
 
-	client := &http.Client{
-		Timeout: time.Duration(args.Timeout) * time.Second,
-	}
+	// the timeout covers all attempts
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(args.Timeout)*time.Second)
+	defer cancel()
+
+	client := &http.Client{Transport: currentTransport()}
 
 	// check the URL string from defined action has a variable, try to substitute it
 	url, err := text.Substitute(args.URL, msg.Vars)
@@ -50,7 +81,7 @@ func HTTPReq(args models.Action, msg *models.Message) (*models.HTTPResponse, err
 		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(context.Background(), args.Type, url, payload)
+	req, err := http.NewRequestWithContext(ctx, args.Type, url, payload)
 	if err != nil {
 		log.Error().Msg("failed to create a new http request")
 		return nil, err
@@ -69,18 +100,80 @@ func HTTPReq(args models.Action, msg *models.Message) (*models.HTTPResponse, err
 		req.Header.Add(k, value)
 	}
 
-	resp, err := client.Do(req)
+	// Add authentication to request
+	err = addAuth(req, args.Auth, msg)
 	if err != nil {
-		log.Error().Msg("failed to execute the http request")
+		log.Error().Msg("failed to add authentication to the http request")
 		return nil, err
 	}
 
+	var result *models.HTTPResponse
+
+	for attempt := 1; ; attempt++ {
+		var header http.Header
+
+		result, header, err = doHTTPReq(client, req)
+
+		// the timeout was reached, don't bother retrying
+		if ctx.Err() != nil {
+			break
+		}
+
+		retryable := err != nil || retryableStatus(args.Retry, result.Status)
+		if !retryable || attempt >= maxAttempts(args.Retry) {
+			break
+		}
+
+		// the server knows best when to try again
+		delay := retryAfter(header)
+		if delay == 0 {
+			delay = retryDelay(args.Retry, attempt)
+		}
+
+		log.Warn().Msgf("attempt %d of %d for action %#q failed, retrying in %s", attempt, maxAttempts(args.Retry), args.Name, delay)
+
+		if waitErr := waitForRetry(ctx, delay); waitErr != nil {
+			log.Warn().Msgf("giving up on action %#q: %v", args.Name, waitErr)
+			break
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().Msgf("http request for action %#q completed", args.Name)
+
+	return result, nil
+}
+
+// doHTTPReq makes a single attempt of a request and returns the response and its header.
+func doHTTPReq(client *http.Client, req *http.Request) (*models.HTTPResponse, http.Header, error) {
+	// each attempt needs a fresh copy of the request body
+	attempt := req.Clone(req.Context())
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			log.Error().Msg("failed to copy the body of the http request")
+			return nil, nil, err
+		}
+
+		attempt.Body = body
+	}
+
+	resp, err := client.Do(attempt)
+	if err != nil {
+		log.Error().Msg("failed to execute the http request")
+		return nil, nil, err
+	}
+
 	defer resp.Body.Close()
 
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Error().Msg("failed to read response from http request")
-		return nil, err
+		return nil, nil, err
 	}
 
 	fields := extractFields(bodyBytes)
@@ -91,9 +184,7 @@ func HTTPReq(args models.Action, msg *models.Message) (*models.HTTPResponse, err
 		Data:   fields,
 	}
 
-	log.Info().Msgf("http request for action %#q completed", args.Name)
-
-	return &result, nil
+	return &result, resp.Header, nil
 }
 
 // Depending on the type of request we want to deal with the payload accordingly.
@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+var (
+	defaultRetryDelay    = time.Second
+	defaultRetryMaxDelay = 30 * time.Second
+
+	// status codes that indicate a transient failure of the server
+	defaultRetryStatusCodes = []int{
+		http.StatusRequestTimeout,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+)
+
+// errRetryDeadline is returned when waiting for the next attempt would exceed the action's timeout.
+var errRetryDeadline = errors.New("no time left for another attempt")
+
+// maxAttempts returns how often an action is attempted.
+func maxAttempts(retry models.Retry) int {
+	return max(retry.Attempts, 1)
+}
+
+// retryableStatus checks whether a request that got a response with the given status should be retried.
+func retryableStatus(retry models.Retry, status int) bool {
+	codes := retry.StatusCodes
+	if len(codes) == 0 {
+		codes = defaultRetryStatusCodes
+	}
+
+	return slices.Contains(codes, status)
+}
+
+// retryableExitCode checks whether a script that exited with the given code should be retried.
+func retryableExitCode(retry models.Retry, code int) bool {
+	if len(retry.ExitCodes) == 0 {
+		return code != 0
+	}
+
+	return slices.Contains(retry.ExitCodes, code)
+}
+
+// retryDelay returns how long to wait after the given (failed) attempt;
+// the delay doubles with each attempt and is jittered to avoid retrying in lockstep.
+func retryDelay(retry models.Retry, attempt int) time.Duration {
+	delay := retry.Delay
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+
+	maxDelay := retry.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+
+	delay = min(delay, maxDelay)
+
+	// wait at least half of the delay
+	half := delay / 2
+
+	//nolint:gosec // jitter doesn't need a secure source of randomness
+	return half + rand.N(delay-half+1)
+}
+
+// retryAfter parses the Retry-After header of a response, which is either
+// a number of seconds or a date. It returns 0 if the header isn't set or invalid.
+func retryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+
+	return 0
+}
+
+// waitForRetry waits for the given delay, unless the context
+// is done or its deadline would be reached before the delay is over.
+func waitForRetry(ctx context.Context, delay time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+		return errRetryDeadline
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
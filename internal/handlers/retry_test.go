@@ -0,0 +1,175 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+func Test_retryDelay(t *testing.T) {
+	retry := models.Retry{Delay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{1, 50 * time.Millisecond, 100 * time.Millisecond},
+		{2, 100 * time.Millisecond, 200 * time.Millisecond},
+		{3, 200 * time.Millisecond, 400 * time.Millisecond},
+		{10, 500 * time.Millisecond, time.Second},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			got := retryDelay(retry, tt.attempt)
+			if got < tt.min || got > tt.max {
+				t.Errorf("retryDelay() attempt %d = %s, want between %s and %s", tt.attempt, got, tt.min, tt.max)
+			}
+		}
+	}
+
+	if got := retryDelay(models.Retry{}, 1); got < defaultRetryDelay/2 || got > defaultRetryDelay {
+		t.Errorf("retryDelay() with defaults = %s", got)
+	}
+}
+
+func Test_retryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{"Not set", "", 0, 0},
+		{"Seconds", "3", 3 * time.Second, 3 * time.Second},
+		{"Date", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), 58 * time.Second, time.Minute},
+		{"Date in the past", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0},
+		{"Invalid", "soon", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.value != "" {
+				header.Set("Retry-After", tt.value)
+			}
+
+			if got := retryAfter(header); got < tt.min || got > tt.max {
+				t.Errorf("retryAfter() = %s, want between %s and %s", got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func Test_waitForRetry(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := waitForRetry(ctx, time.Millisecond); err != nil {
+		t.Errorf("waitForRetry() error = %v", err)
+	}
+
+	start := time.Now()
+	if err := waitForRetry(ctx, time.Second); err == nil || time.Since(start) > 10*time.Millisecond {
+		t.Errorf("waitForRetry() expected to give up right away when the delay exceeds the deadline, error = %v", err)
+	}
+}
+
+func TestHTTPReq_retry(t *testing.T) {
+	var calls atomic.Int32
+
+	// fails twice, then succeeds
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if r.ContentLength == 0 {
+				t.Error("expected each attempt to send the body")
+			}
+		}
+
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer ts.Close()
+
+	fast := models.Retry{Attempts: 3, Delay: time.Millisecond}
+
+	tests := []struct {
+		name       string
+		action     models.Action
+		wantStatus int
+		wantCalls  int32
+	}{
+		{"No retries", models.Action{Type: http.MethodGet}, http.StatusBadGateway, 1},
+		{"Recovers", models.Action{Type: http.MethodGet, Retry: fast}, http.StatusOK, 3},
+		{"Recovers with body", models.Action{Type: http.MethodPost, QueryData: map[string]any{"a": "b"}, Retry: fast}, http.StatusOK, 3},
+		{"Runs out of attempts", models.Action{Type: http.MethodGet, Retry: models.Retry{Attempts: 2, Delay: time.Millisecond}}, http.StatusTooManyRequests, 2},
+		{"Status not retryable", models.Action{Type: http.MethodGet, Retry: models.Retry{Attempts: 3, StatusCodes: []int{503}}}, http.StatusBadGateway, 1},
+		{"Delay exceeds timeout", models.Action{Type: http.MethodGet, Timeout: 1, Retry: models.Retry{Attempts: 3, Delay: 10 * time.Second}}, http.StatusBadGateway, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+
+			msg := models.NewMessage()
+
+			tt.action.Name = tt.name
+			tt.action.URL = ts.URL
+
+			got, err := HTTPReq(tt.action, &msg)
+			if err != nil {
+				t.Fatalf("HTTPReq() error = %v", err)
+			}
+
+			if got.Status != tt.wantStatus {
+				t.Errorf("HTTPReq() status = %d, want %d", got.Status, tt.wantStatus)
+			}
+
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("HTTPReq() made %d attempts, want %d", calls.Load(), tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestScriptExec_retry(t *testing.T) {
+	tests := []struct {
+		name       string
+		retry      models.Retry
+		wantOutput string
+		wantErr    bool
+	}{
+		{"No retries", models.Retry{}, "flaking", true},
+		{"Recovers", models.Retry{Attempts: 2, Delay: time.Millisecond}, "recovered", false},
+		{"Exit code not retryable", models.Retry{Attempts: 2, Delay: time.Millisecond, ExitCodes: []int{1, 2}}, "flaking", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := models.NewMessage()
+
+			action := newExecAction("/bin/sh testdata/flaky.sh " + filepath.Join(t.TempDir(), "marker"))
+			action.Retry = tt.retry
+
+			got, err := ScriptExec(action, &msg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ScriptExec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got.Output != tt.wantOutput {
+				t.Errorf("ScriptExec() output = %#q, want %#q", got.Output, tt.wantOutput)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -17,6 +18,30 @@ import (
 	"github.com/target/flottbot/internal/text"
 )
 
+// ScriptRunner makes a single attempt at running the command of an 'exec' action,
+// the command is split into the executable and its arguments.
+type ScriptRunner func(ctx context.Context, action models.Action, bin []string) (*models.ScriptResponse, error)
+
+var scriptRunner = struct {
+	sync.RWMutex
+	run ScriptRunner
+}{run: RunScript}
+
+// SetScriptRunner sets how the commands of 'exec' actions are run, ie. to mock them.
+func SetScriptRunner(run ScriptRunner) {
+	scriptRunner.Lock()
+	defer scriptRunner.Unlock()
+
+	scriptRunner.run = run
+}
+
+func currentScriptRunner() ScriptRunner {
+	scriptRunner.RLock()
+	defer scriptRunner.RUnlock()
+
+	return scriptRunner.run
+}
+
 // ScriptExec handles 'exec' actions; script executions for rules.
 func ScriptExec(args models.Action, msg *models.Message) (*models.ScriptResponse, error) {
 	log.Info().Msgf("executing process for action %#q", args.Name)
@@ -46,6 +71,41 @@ func ScriptExec(args models.Action, msg *models.Message) (*models.ScriptResponse
 	// Parse out all the arguments from the supplied command
 	bin := text.ExecArgTokenizer(cmdProcessed)
 
+	run := currentScriptRunner()
+
+	for attempt := 1; ; attempt++ {
+		result, err = run(ctx, args, bin)
+
+		// the timeout was reached, don't bother retrying
+		if ctx.Err() != nil {
+			break
+		}
+
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || !retryableExitCode(args.Retry, exitErr.ExitCode()) || attempt >= maxAttempts(args.Retry) {
+			break
+		}
+
+		delay := retryDelay(args.Retry, attempt)
+
+		log.Warn().Msgf("attempt %d of %d for action %#q failed, retrying in %s", attempt, maxAttempts(args.Retry), args.Name, delay)
+
+		if waitErr := waitForRetry(ctx, delay); waitErr != nil {
+			log.Warn().Msgf("giving up on action %#q: %v", args.Name, waitErr)
+			break
+		}
+	}
+
+	return result, err
+}
+
+// RunScript makes a single attempt at running the command of the action, it's the default 'ScriptRunner'.
+func RunScript(ctx context.Context, args models.Action, bin []string) (*models.ScriptResponse, error) {
+	// Prep default response
+	result := &models.ScriptResponse{
+		Status: 1, // Default is exit code 1 (error)
+	}
+
 	// prep the command to be executed with context
 	//nolint:gosec // ignore "potential tainted input or cmd arguments" because bot owner controls usage
 	cmd := exec.CommandContext(ctx, bin[0], bin[1:]...)
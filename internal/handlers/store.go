@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/store"
+	"github.com/target/flottbot/internal/text"
+)
+
+// Supported operations of 'store' actions.
+const (
+	StoreOpGet    = "get"
+	StoreOpSet    = "set"
+	StoreOpIncr   = "incr"
+	StoreOpDelete = "delete"
+)
+
+// StoreExec handles 'store' actions; reading and writing values of the key/value store.
+// It returns the value of the key after the operation.
+func StoreExec(args models.Action, msg *models.Message) (string, error) {
+	log.Info().Msgf("executing store %#q for action %#q", args.Op, args.Name)
+
+	key, err := text.Substitute(args.Key, msg.Vars)
+	if err != nil {
+		return "", err
+	}
+
+	key, err = store.ScopedKey(args.Scope, key, msg)
+	if err != nil {
+		return "", err
+	}
+
+	value, err := text.Substitute(args.Value, msg.Vars)
+	if err != nil {
+		return "", err
+	}
+
+	s := store.Default()
+
+	switch strings.ToLower(args.Op) {
+	case StoreOpGet:
+		value, _, err = s.Get(key)
+
+		return value, err
+	case StoreOpSet:
+		return value, s.Set(key, value)
+	case StoreOpIncr:
+		delta := int64(1)
+
+		if value != "" {
+			delta, err = strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return "", fmt.Errorf("the value %#q to increment by is not an integer", value)
+			}
+		}
+
+		n, err := s.Incr(key, delta)
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.FormatInt(n, 10), nil
+	case StoreOpDelete:
+		return "", s.Delete(key)
+	default:
+		return "", fmt.Errorf("store operation %#q is not supported", args.Op)
+	}
+}
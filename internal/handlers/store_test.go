@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"testing"
+
+	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/store"
+)
+
+func TestStoreExec(t *testing.T) {
+	store.SetDefault(store.NewMemory())
+
+	msg := models.NewMessage()
+	msg.ChannelID = "C123"
+	msg.Vars["who"] = "jane"
+
+	tests := []struct {
+		name    string
+		action  models.Action
+		want    string
+		wantErr bool
+	}{
+		{"Get missing", models.Action{Op: "get", Key: "karma-${who}"}, "", false},
+		{"Increment", models.Action{Op: "incr", Key: "karma-${who}"}, "1", false},
+		{"Increment by value", models.Action{Op: "INCR", Key: "karma-${who}", Value: "5"}, "6", false},
+		{"Increment by invalid value", models.Action{Op: "incr", Key: "karma-${who}", Value: "lots"}, "", true},
+		{"Get", models.Action{Op: "get", Key: "karma-jane"}, "6", false},
+		{"Set channel scope", models.Action{Op: "set", Key: "topic", Value: "${who} is on call", Scope: "channel"}, "jane is on call", false},
+		{"Get channel scope", models.Action{Op: "get", Key: "topic", Scope: "channel"}, "jane is on call", false},
+		{"Get other scope", models.Action{Op: "get", Key: "topic"}, "", false},
+		{"Delete", models.Action{Op: "delete", Key: "karma-jane"}, "", false},
+		{"Get deleted", models.Action{Op: "get", Key: "karma-jane"}, "", false},
+		{"Missing var", models.Action{Op: "get", Key: "karma-${whom}"}, "", true},
+		{"Unsupported op", models.Action{Op: "append", Key: "karma"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := StoreExec(tt.action, &msg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("StoreExec() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if got != tt.want {
+				t.Errorf("StoreExec() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
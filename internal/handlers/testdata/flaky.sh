@@ -0,0 +1,11 @@
+#!/usr/bin/env sh
+
+# fails the first time it's run with the given marker file
+if [ -f "$1" ]; then
+  echo "recovered"
+  exit 0
+fi
+
+touch "$1"
+echo "flaking"
+exit 3
@@ -2,6 +2,8 @@
 
 package models
 
+import "time"
+
 // Action defines the structure for Actions used within Rules.
 type Action struct {
 	Name             string            `mapstructure:"name" binding:"required"`
@@ -18,11 +20,38 @@ type Action struct {
 	OutputToRooms    []string          `mapstructure:"output_to_rooms"`
 	Message          string            `mapstructure:"message"`
 	Reaction         string            `mapstructure:"update_reaction" binding:"omitempty"`
+	Op               string            `mapstructure:"op"`
+	Key              string            `mapstructure:"key"`
+	Value            string            `mapstructure:"value"`
+	Scope            string            `mapstructure:"scope"`
+	Var              string            `mapstructure:"var"`
+	When             string            `mapstructure:"when"`
+	OnError          []Action          `mapstructure:"on_error"`
+	StopOnError      bool              `mapstructure:"stop_on_error"`
+	Retry            Retry             `mapstructure:"retry"`
+}
+
+// Retry configures how often 'http' and 'exec' actions are attempted.
+//
+// Retries back off exponentially, starting at 'delay' (default 1s) up to 'max_delay'
+// (default 30s), with jitter. All attempts have to finish within the action's 'timeout'.
+type Retry struct {
+	Attempts    int           `mapstructure:"attempts"`     // including the first attempt, 0 or 1 disables retries
+	Delay       time.Duration `mapstructure:"delay"`        // ie. 500ms, 2s
+	MaxDelay    time.Duration `mapstructure:"max_delay"`    // ie. 10s, 1m
+	StatusCodes []int         `mapstructure:"status_codes"` // http, default 408, 429, 500, 502, 503 and 504
+	ExitCodes   []int         `mapstructure:"exit_codes"`   // exec, default any non-zero exit code
 }
 
 // Auth is a basic Auth data structure.
+//
+// Supported types are 'basic' (user/pass), 'bearer' (token)
+// and 'oauth2' (client credentials flow, user/pass being the client id/secret).
 type Auth struct {
-	Type string `mapstructure:"type"`
-	User string `mapstructure:"user"`
-	Pass string `mapstructure:"pass"`
+	Type     string   `mapstructure:"type"`
+	User     string   `mapstructure:"user"`
+	Pass     string   `mapstructure:"pass"`
+	Token    string   `mapstructure:"token"`
+	TokenURL string   `mapstructure:"token_url"`
+	Scopes   []string `mapstructure:"scopes"`
 }
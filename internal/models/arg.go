@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+import "strings"
+
+// Supported argument types.
+const (
+	ArgTypeString   = "string"
+	ArgTypeInt      = "int"
+	ArgTypeFloat    = "float"
+	ArgTypeBool     = "bool"
+	ArgTypeEnum     = "enum"
+	ArgTypeDuration = "duration"
+	ArgTypeEmail    = "email"
+	ArgTypeUser     = "user"
+	ArgTypeChannel  = "channel"
+)
+
+// Arg is an argument of a 'respond' rule. Arguments are either declared by name,
+// with a trailing '?' for optional and '+' for variadic arguments, or with the fields below.
+type Arg struct {
+	Name        string   `mapstructure:"name" binding:"required"`
+	Type        string   `mapstructure:"type" binding:"omitempty"`        // defaults to 'string'
+	Values      []string `mapstructure:"values" binding:"omitempty"`      // allowed values of an 'enum'
+	Pattern     string   `mapstructure:"pattern" binding:"omitempty"`     // regex the value has to match
+	Default     string   `mapstructure:"default" binding:"omitempty"`     // makes the argument optional
+	Description string   `mapstructure:"description" binding:"omitempty"` // shown along with validation errors
+	Optional    bool     `mapstructure:"optional" binding:"omitempty"`
+	Variadic    bool     `mapstructure:"variadic" binding:"omitempty"` // takes the rest of the input, must be last
+}
+
+// NewArg creates an argument from its declaration by name, ie. 'env?'.
+func NewArg(declaration string) Arg {
+	switch {
+	case strings.HasSuffix(declaration, "?"):
+		return Arg{Name: strings.TrimSuffix(declaration, "?"), Optional: true}
+	case strings.HasSuffix(declaration, "+"):
+		return Arg{Name: strings.TrimSuffix(declaration, "+"), Variadic: true}
+	default:
+		return Arg{Name: declaration}
+	}
+}
+
+// NewArgs creates arguments from their declarations by name.
+func NewArgs(declarations ...string) []Arg {
+	args := make([]Arg, 0, len(declarations))
+	for _, declaration := range declarations {
+		args = append(args, NewArg(declaration))
+	}
+
+	return args
+}
+
+// IsRequired tells whether the user has to supply the argument, variadic arguments take at least one value.
+func (a Arg) IsRequired() bool {
+	return !a.Optional && a.Default == ""
+}
@@ -3,7 +3,10 @@
 package models
 
 import (
+	"maps"
 	"os"
+	"strings"
+	"sync"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -20,6 +23,7 @@ type Bot struct {
 	SlackSigningSecret            string            `mapstructure:"slack_signing_secret"`
 	SlackEventsCallbackPath       string            `mapstructure:"slack_events_callback_path"`
 	SlackInteractionsCallbackPath string            `mapstructure:"slack_interactions_callback_path"`
+	SlackCommandsCallbackPath     string            `mapstructure:"slack_commands_callback_path"`
 	SlackListenerPort             string            `mapstructure:"slack_listener_port"`
 	MatterMostToken               string            `mapstructure:"mattermost_token"`
 	MatterMostServer              string            `mapstructure:"mattermost_server"`
@@ -37,35 +41,51 @@ type Bot struct {
 	CLI                           bool              `mapstructure:"cli,omitempty"`
 	CLIUser                       string            `mapstructure:"cli_user,omitempty"`
 	Scheduler                     bool              `mapstructure:"scheduler,omitempty"`
-	ChatApplication               string            `mapstructure:"chat_application" binding:"required"`
+	SchedulerLock                 string            `mapstructure:"scheduler_lock,omitempty"`
+	SchedulerLockPath             string            `mapstructure:"scheduler_lock_path,omitempty"`
+	SchedulerLockTTL              string            `mapstructure:"scheduler_lock_ttl,omitempty"`
+	ChatApplications              []string          `mapstructure:"chat_application"` // not required in cli mode, 'flottbot validate' reports a bot with neither
 	Debug                         bool              `mapstructure:"debug,omitempty"`
 	Metrics                       bool              `mapstructure:"metrics,omitempty"`
 	CustomHelpText                string            `mapstructure:"custom_help_text,omitempty"`
 	CustomHelpTextPrefix          string            `mapstructure:"custom_help_text_prefix,omitempty"`
 	DisableNoMatchHelp            bool              `mapstructure:"disable_no_match_help,omitempty"`
+	HelpDirectMessageLines        int               `mapstructure:"help_direct_message_lines,omitempty"`
+	SuggestionMaxDistance         int               `mapstructure:"suggestion_max_distance,omitempty"`
 	RespondToBots                 bool              `mapstructure:"respond_to_bots,omitempty"`
+	WatchRules                    bool              `mapstructure:"watch_rules,omitempty"`
+	Store                         string            `mapstructure:"store,omitempty"`
+	StorePath                     string            `mapstructure:"store_path,omitempty"`
+	RedisURL                      string            `mapstructure:"redis_url,omitempty"`
+	Workers                       int               `mapstructure:"workers,omitempty"`
+	WorkerQueueSize               int               `mapstructure:"worker_queue_size,omitempty"`
+	ShutdownGracePeriod           string            `mapstructure:"shutdown_grace_period,omitempty"`
 	// System
 	RunChat      bool
 	RunCLI       bool
 	RunScheduler bool
+
+	// how the chat applications know the bot and their rooms, keyed by chat application
+	mu         sync.RWMutex
+	identities map[string]Identity
+	rooms      map[string]map[string]string
+}
+
+// Identity is who the bot is on a chat application.
+type Identity struct {
+	ID   string
+	Name string
 }
 
 // NewBot creates a new Bot instance.
 func NewBot() *Bot {
-	v := viper.New()
-	bot := new(Bot)
-
-	// set default search locations
-	v.AddConfigPath("./config")
-	v.AddConfigPath(".")
-	v.SetConfigName("bot")
-
-	// read the config
-	err := v.ReadInConfig()
+	v, err := ReadBotConfig()
 	if err != nil {
 		log.Fatal().Msgf("could not read bot config: %s", err)
 	}
 
+	bot := new(Bot)
+
 	// unmarshal the config
 	err = v.Unmarshal(bot)
 	if err != nil {
@@ -84,3 +104,130 @@ func NewBot() *Bot {
 
 	return bot
 }
+
+// ReadBotConfig reads the bot.yml from the config directory, or the current directory.
+func ReadBotConfig() (*viper.Viper, error) {
+	v := viper.New()
+
+	// set default search locations
+	v.AddConfigPath("./config")
+	v.AddConfigPath(".")
+	v.SetConfigName("bot")
+
+	// read the config
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// PrimaryChatApplication returns the first configured chat application,
+// which is used for messages that did not come from a chat application,
+// ie. scheduled messages.
+func (b *Bot) PrimaryChatApplication() string {
+	if len(b.ChatApplications) == 0 {
+		return ""
+	}
+
+	return strings.ToLower(b.ChatApplications[0])
+}
+
+// chatApp returns the key of the chat application, an empty name means the primary chat application.
+func (b *Bot) chatApp(name string) string {
+	if name == "" {
+		return b.PrimaryChatApplication()
+	}
+
+	return strings.ToLower(name)
+}
+
+// SetIdentity records who the bot is on the chat application, once it connected to it.
+func (b *Bot) SetIdentity(chatApp string, identity Identity) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.identities == nil {
+		b.identities = make(map[string]Identity)
+	}
+
+	b.identities[b.chatApp(chatApp)] = identity
+}
+
+// Identity returns who the bot is on the chat application, an empty chat application means
+// the primary one. The 'id' and 'name' of the bot.yml are used for what the chat application didn't set.
+func (b *Bot) Identity(chatApp string) Identity {
+	b.mu.RLock()
+	identity := b.identities[b.chatApp(chatApp)]
+	b.mu.RUnlock()
+
+	if identity.ID == "" {
+		identity.ID = b.ID
+	}
+
+	if identity.Name == "" {
+		identity.Name = b.Name
+	}
+
+	return identity
+}
+
+// AddRooms adds rooms, by name, to the room lookup of the chat application.
+// Each chat application has its own lookup, so rooms with the same name don't mix.
+func (b *Bot) AddRooms(chatApp string, rooms map[string]string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.rooms == nil {
+		b.rooms = make(map[string]map[string]string)
+	}
+
+	key := b.chatApp(chatApp)
+	if b.rooms[key] == nil {
+		b.rooms[key] = make(map[string]string, len(rooms))
+	}
+
+	maps.Copy(b.rooms[key], rooms)
+}
+
+// RoomID looks up the id of a room by name, first among the rooms of the chat application
+// and then among the rooms of the bot.yml. An empty chat application means the primary one.
+func (b *Bot) RoomID(chatApp, name string) (string, bool) {
+	name = strings.ToLower(name)
+
+	b.mu.RLock()
+	id, ok := b.rooms[b.chatApp(chatApp)][name]
+	b.mu.RUnlock()
+
+	if ok && id != "" {
+		return id, true
+	}
+
+	id, ok = b.Rooms[name]
+
+	return id, ok && id != ""
+}
+
+// RoomName looks up the name of a room by id, the same way as 'RoomID'.
+func (b *Bot) RoomName(chatApp, id string) (string, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, rooms := range []map[string]string{b.rooms[b.chatApp(chatApp)], b.Rooms} {
+		for name, roomID := range rooms {
+			if roomID == id {
+				return name, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// HasRooms tells whether any rooms are known for the chat application, including the rooms of the bot.yml.
+func (b *Bot) HasRooms(chatApp string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.rooms[b.chatApp(chatApp)]) > 0 || len(b.Rooms) > 0
+}
@@ -0,0 +1,16 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+// Concurrency limit policies.
+const (
+	ConcurrencyQueue  = "queue"
+	ConcurrencyReject = "reject"
+)
+
+// Concurrency limits how many times a rule may run at the same time, ie. only one deploy at a time.
+type Concurrency struct {
+	Max     int    `mapstructure:"max" binding:"omitempty"`      // 0 means no limit
+	OnLimit string `mapstructure:"on_limit" binding:"omitempty"` // 'queue' (default) or 'reject' extra requests
+	Message string `mapstructure:"message" binding:"omitempty"`  // reply to rejected requests
+}
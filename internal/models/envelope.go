@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+// Op is an operation the outputs carry out for an envelope, ops can be combined, ie. 'OpSend|OpReact'.
+type Op uint8
+
+// Operations of an envelope.
+const (
+	OpSend    Op = 1 << iota // send the output of the message
+	OpReact                  // add the reaction of the rule to the message that triggered it
+	OpUnreact                // remove the previous reaction of the rule from the message that triggered it
+)
+
+// Envelope is a unit of output, the message along with the rule it results from
+// and the operations to carry out. Keeping them together ensures reactions are
+// applied for the rule that produced the message, no matter how many rules run at once.
+type Envelope struct {
+	Message Message
+	Rule    Rule
+	Ops     Op
+}
+
+// Send creates an envelope that only sends the message.
+func Send(message Message) Envelope {
+	return Envelope{Message: message, Ops: OpSend}
+}
+
+// Has tells whether the envelope carries any of the operations.
+func (e Envelope) Has(ops Op) bool {
+	return e.Ops&ops != 0
+}
+
+// Reactions returns the rule with only the reactions the envelope asks for,
+// ready to be passed to the 'Reaction' of a remote.
+func (e Envelope) Reactions() Rule {
+	rule := e.Rule
+
+	if !e.Has(OpReact) {
+		rule.Reaction = ""
+	}
+
+	if !e.Has(OpUnreact) {
+		rule.RemoveReaction = ""
+	}
+
+	return rule
+}
@@ -0,0 +1,23 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+import "strings"
+
+// Flag is a CLI-like flag of a 'respond' rule, ie. '--env prod', '-e prod' or '--dry-run'.
+// Declaring flags enables flag parsing for the rule, the values are available as ${flag.<name>}.
+type Flag struct {
+	Name        string   `mapstructure:"name" binding:"required"`         // used as '--name'
+	Short       string   `mapstructure:"short" binding:"omitempty"`       // single letter, used as '-s'
+	Type        string   `mapstructure:"type" binding:"omitempty"`        // same types as args, defaults to 'string'
+	Values      []string `mapstructure:"values" binding:"omitempty"`      // allowed values of an 'enum'
+	Pattern     string   `mapstructure:"pattern" binding:"omitempty"`     // regex the value has to match
+	Default     string   `mapstructure:"default" binding:"omitempty"`     // value if the flag isn't given
+	Description string   `mapstructure:"description" binding:"omitempty"` // shown along with validation errors
+	Repeated    bool     `mapstructure:"repeated" binding:"omitempty"`    // may be given more than once, values are joined with ','
+}
+
+// IsBool tells whether the flag is a switch that takes no value.
+func (f Flag) IsBool() bool {
+	return strings.EqualFold(f.Type, ArgTypeBool)
+}
@@ -13,12 +13,15 @@ type Message struct {
 	ID                string
 	Type              MessageType
 	Service           MessageService
+	Remote            string
 	ChannelID         string
 	ChannelName       string
 	Input             string
 	Output            string
 	ReactionAdded     string
 	ReactionRemoved   string
+	BlockAction       string
+	ViewSubmission    string
 	Error             string
 	Timestamp         string
 	ThreadID          string
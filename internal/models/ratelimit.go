@@ -0,0 +1,20 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+import "time"
+
+// Rate limit scopes.
+const (
+	RateLimitScopeUser    = "user"
+	RateLimitScopeChannel = "channel"
+	RateLimitScopeGlobal  = "global"
+)
+
+// RateLimit allows a rule to be triggered 'count' times per 'window',
+// by each user, in each channel or overall, depending on the scope.
+type RateLimit struct {
+	Scope  string        `mapstructure:"scope" binding:"required"`
+	Count  int           `mapstructure:"count" binding:"required"`
+	Window time.Duration `mapstructure:"window" binding:"required"`
+}
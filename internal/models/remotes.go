@@ -21,6 +21,9 @@ type Remotes struct {
 // SlackConfig is a support struct that holds Slack specific data.
 type SlackConfig struct {
 	Attachments []slack.Attachment `mapstructure:"attachments"`
+	// Blocks are Block Kit blocks, as found in the Slack Block Kit Builder; either a list
+	// of blocks or a string with the JSON of the blocks. Variables and templates are applied.
+	Blocks any `mapstructure:"blocks"`
 }
 
 // DiscordConfig is a support struct that holds DiscordConfig specific data.
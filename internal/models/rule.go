@@ -2,34 +2,110 @@
 
 package models
 
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
 // Rule is a struct representation of the .yml rules.
+// Fields tagged 'binding:"required"' must be set in the rule file, 'flottbot validate' reports them otherwise.
+// Rules don't need arguments or actions, ie. rules that hear or reply with 'format_output' only,
+// and the flags 'direct_message_only', 'include_in_help' and 'debug' are off unless set.
 type Rule struct {
-	Name               string   `mapstructure:"name" binding:"required"`
-	Respond            string   `mapstructure:"respond" binding:"omitempty"`
-	Hear               string   `mapstructure:"hear" binding:"omitempty"`
-	ReactionsAdded     string   `mapstructure:"reactions_added" binding:"omitempty"`
-	ReactionsRemoved   string   `mapstructure:"reactions_removed" binding:"omitempty"`
-	Schedule           string   `mapstructure:"schedule"`
-	Args               []string `mapstructure:"args" binding:"required"`
-	DirectMessageOnly  bool     `mapstructure:"direct_message_only" binding:"required"`
-	OutputToRooms      []string `mapstructure:"output_to_rooms" binding:"omitempty"`
-	OutputToUsers      []string `mapstructure:"output_to_users" binding:"omitempty"`
-	AllowUsers         []string `mapstructure:"allow_users" binding:"omitempty"`
-	AllowUserIDs       []string `mapstructure:"allow_userids" binding:"omitempty"`
-	AllowUserGroups    []string `mapstructure:"allow_usergroups" binding:"omitempty"`
-	IgnoreUsers        []string `mapstructure:"ignore_users" binding:"omitempty"`
-	IgnoreUserGroups   []string `mapstructure:"ignore_usergroups" binding:"omitempty"`
-	StartMessageThread bool     `mapstructure:"start_message_thread" binding:"omitempty"`
-	IgnoreThreads      bool     `mapstructure:"ignore_threads" binding:"omitempty"`
-	FormatOutput       string   `mapstructure:"format_output"`
-	HelpText           string   `mapstructure:"help_text"`
-	IncludeInHelp      bool     `mapstructure:"include_in_help" binding:"required"`
-	Active             bool     `mapstructure:"active" binding:"required"`
-	Debug              bool     `mapstructure:"debug" binding:"required"`
-	Actions            []Action `mapstructure:"actions" binding:"required"`
-	Remotes            Remotes  `mapstructure:"remotes" binding:"omitempty"`
-	Reaction           string   `mapstructure:"reaction" binding:"omitempty"`
-	LimitToRooms       []string `mapstructure:"limit_to_rooms" binding:"omitempty"`
+	Name                string        `mapstructure:"name" binding:"required"`
+	Respond             string        `mapstructure:"respond" binding:"omitempty"`
+	Hear                string        `mapstructure:"hear" binding:"omitempty"`
+	ReactionsAdded      string        `mapstructure:"reactions_added" binding:"omitempty"`
+	ReactionsRemoved    string        `mapstructure:"reactions_removed" binding:"omitempty"`
+	BlockActions        string        `mapstructure:"block_actions" binding:"omitempty"`
+	ViewSubmission      string        `mapstructure:"view_submission" binding:"omitempty"`
+	Schedule            string        `mapstructure:"schedule"`
+	Args                []Arg         `mapstructure:"args" binding:"omitempty"`
+	Flags               []Flag        `mapstructure:"flags" binding:"omitempty"`
+	DirectMessageOnly   bool          `mapstructure:"direct_message_only" binding:"omitempty"`
+	OutputToRooms       []string      `mapstructure:"output_to_rooms" binding:"omitempty"`
+	OutputToUsers       []string      `mapstructure:"output_to_users" binding:"omitempty"`
+	AllowUsers          []string      `mapstructure:"allow_users" binding:"omitempty"`
+	AllowUserIDs        []string      `mapstructure:"allow_userids" binding:"omitempty"`
+	AllowUserGroups     []string      `mapstructure:"allow_usergroups" binding:"omitempty"`
+	IgnoreUsers         []string      `mapstructure:"ignore_users" binding:"omitempty"`
+	IgnoreUserGroups    []string      `mapstructure:"ignore_usergroups" binding:"omitempty"`
+	StartMessageThread  bool          `mapstructure:"start_message_thread" binding:"omitempty"`
+	IgnoreThreads       bool          `mapstructure:"ignore_threads" binding:"omitempty"`
+	FormatOutput        string        `mapstructure:"format_output"`
+	HelpText            string        `mapstructure:"help_text"`
+	Category            string        `mapstructure:"category" binding:"omitempty"`
+	UsageExamples       []string      `mapstructure:"usage_examples" binding:"omitempty"`
+	IncludeInHelp       bool          `mapstructure:"include_in_help" binding:"omitempty"`
+	Active              bool          `mapstructure:"active" binding:"required"`
+	Debug               bool          `mapstructure:"debug" binding:"omitempty"`
+	Actions             []Action      `mapstructure:"actions" binding:"omitempty"`
+	Remotes             Remotes       `mapstructure:"remotes" binding:"omitempty"`
+	Reaction            string        `mapstructure:"reaction" binding:"omitempty"`
+	LimitToRooms        []string      `mapstructure:"limit_to_rooms" binding:"omitempty"`
+	Steps               []Step        `mapstructure:"steps" binding:"omitempty"`
+	PromptForArgs       bool          `mapstructure:"prompt_for_args" binding:"omitempty"`
+	ConversationTimeout time.Duration `mapstructure:"conversation_timeout" binding:"omitempty"`
+	CancelKeyword       string        `mapstructure:"cancel_keyword" binding:"omitempty"`
+	Priority            int           `mapstructure:"priority" binding:"omitempty"`
+	ContinueMatching    bool          `mapstructure:"continue_matching" binding:"omitempty"`
+	OnFailure           []Action      `mapstructure:"on_failure" binding:"omitempty"`
+
+	// Throttling
+	RateLimit        []RateLimit   `mapstructure:"rate_limit" binding:"omitempty"`
+	RateLimitMessage string        `mapstructure:"rate_limit_message" binding:"omitempty"`
+	Cooldown         time.Duration `mapstructure:"cooldown" binding:"omitempty"` // per channel
+	Concurrency      Concurrency   `mapstructure:"concurrency" binding:"omitempty"`
+
 	// The following fields are not included in rule file
 	RemoveReaction string
 }
+
+// Step is a follow-up question of a conversational rule,
+// the answer of the user is stored as a variable.
+type Step struct {
+	Prompt   string `mapstructure:"prompt" binding:"required"`
+	Var      string `mapstructure:"var" binding:"required"`
+	Validate string `mapstructure:"validate" binding:"omitempty"`
+	Error    string `mapstructure:"error" binding:"omitempty"`
+
+	// the compiled 'validate' pattern, see 'Compile'
+	validate *regexp.Regexp
+}
+
+// Compile compiles the 'validate' pattern of the step, so answers are matched without compiling it again.
+func (s *Step) Compile() error {
+	if s.Validate == "" {
+		return nil
+	}
+
+	re, err := regexp.Compile(s.Validate)
+	if err != nil {
+		return fmt.Errorf("invalid 'validate' pattern %#q for var %#q: %w", s.Validate, s.Var, err)
+	}
+
+	s.validate = re
+
+	return nil
+}
+
+// Accepts tells whether the answer matches the 'validate' pattern of the step, if it has one.
+// Steps that were not compiled, ie. of rules created in code, compile their pattern on each answer.
+func (s Step) Accepts(answer string) bool {
+	if s.Validate == "" {
+		return true
+	}
+
+	re := s.validate
+	if re == nil {
+		var err error
+
+		re, err = regexp.Compile(s.Validate)
+		if err != nil {
+			return false
+		}
+	}
+
+	return re.MatchString(answer)
+}
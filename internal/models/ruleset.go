@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+import "sync"
+
+// RuleSet holds the rules the bot is currently running with, keyed by
+// the file they were loaded from. The whole set is swapped at once when
+// rules are reloaded, so readers never see a partially updated set.
+type RuleSet struct {
+	mu          sync.RWMutex
+	rules       map[string]Rule
+	subscribers []chan struct{}
+}
+
+// NewRuleSet creates a new RuleSet from the given rules.
+func NewRuleSet(rules map[string]Rule) *RuleSet {
+	if rules == nil {
+		rules = make(map[string]Rule)
+	}
+
+	return &RuleSet{rules: rules}
+}
+
+// Rules returns the current rules. The returned map is shared
+// and must not be modified; use Swap to change the rules.
+func (rs *RuleSet) Rules() map[string]Rule {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	return rs.rules
+}
+
+// Swap replaces the current rules and notifies all subscribers.
+func (rs *RuleSet) Swap(rules map[string]Rule) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.rules = rules
+
+	for _, sub := range rs.subscribers {
+		// subscribers only need to know that something changed,
+		// so don't block if a notification is already pending
+		select {
+		case sub <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel that receives a value whenever the rules are swapped.
+func (rs *RuleSet) Subscribe() <-chan struct{} {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	sub := make(chan struct{}, 1)
+	rs.subscribers = append(rs.subscribers, sub)
+
+	return sub
+}
@@ -4,6 +4,7 @@ package cli
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"regexp"
@@ -33,7 +34,7 @@ func (c *Client) Reaction(_ models.Message, _ models.Rule, _ *models.Bot) {
 }
 
 // Read implementation to satisfy remote interface.
-func (c *Client) Read(inputMsgs chan<- models.Message, _ map[string]models.Rule, bot *models.Bot) {
+func (c *Client) Read(ctx context.Context, inputMsgs chan<- models.Message, _ *models.RuleSet, bot *models.Bot) {
 	user := bot.CLIUser
 	if user == "" {
 		user = "Flottbot-CLI-User"
@@ -50,9 +51,36 @@ func (c *Client) Read(inputMsgs chan<- models.Message, _ map[string]models.Rule,
 	fmt.Print("Entering CLI mode. <Ctrl-C> to exit.\n\n")
 	fmt.Print(user + "> ")
 
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
-		req := scanner.Text()
+	// reading from stdin can't be interrupted, so read in the background and stop passing on lines once canceled
+	lines := make(chan string)
+
+	go func() {
+		defer close(lines)
+
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+
+		if err := scanner.Err(); err != nil {
+			log.Error().Msgf("Error reading standard input: %v", err)
+		}
+	}()
+
+	for {
+		var req string
+
+		select {
+		case <-ctx.Done():
+			return
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+
+			req = line
+		}
+
 		if strings.TrimSpace(req) != "" {
 			message := models.NewMessage()
 
@@ -70,10 +98,6 @@ func (c *Client) Read(inputMsgs chan<- models.Message, _ map[string]models.Rule,
 			fmt.Print(user + "> ")
 		}
 	}
-
-	if err := scanner.Err(); err != nil {
-		log.Error().Msgf("Error reading standard input: %v", err)
-	}
 }
 
 // Send implementation to satisfy remote interface.
@@ -28,7 +28,7 @@ func populateMessage(message models.Message, msgType models.MessageType, channel
 	message.ID = id
 
 	if msgType != models.MsgTypeDirect {
-		name, ok := findKey(bot.Rooms, channel)
+		name, ok := bot.RoomName(models.ChatAppDiscord, channel)
 		if !ok {
 			log.Error().Msgf("could not find name of channel %#q", channel)
 		}
@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog/log"
+
+	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/remote"
+	"github.com/target/flottbot/internal/text"
+	"github.com/target/flottbot/internal/validation"
+)
+
+func init() {
+	remote.Register(models.ChatAppDiscord, remote.Registration{
+		New:             newClient,
+		Configure:       Configure,
+		IsMemberOfGroup: isMemberOfGroup,
+		Output:          output,
+	})
+}
+
+// newClient creates a Discord client from the bot configuration.
+func newClient(bot *models.Bot) remote.Remote {
+	return &Client{
+		Token: bot.DiscordToken,
+	}
+}
+
+// Configure applies environmental changes to the Discord specific fields of the bot.
+func Configure(bot *models.Bot) error {
+	// emptyMap for substitute function
+	// (it will only replace from env vars)
+	emptyMap := map[string]string{}
+
+	// Discord bot token
+	token, err := text.Substitute(bot.DiscordToken, emptyMap)
+	if err != nil {
+		return fmt.Errorf("could not set 'discord_token': %w", err)
+	}
+
+	bot.DiscordToken = token
+
+	// Discord Server ID
+	// See https://support.discordapp.com/hc/en-us/articles/206346498-Where-can-I-find-my-User-Server-Message-ID-
+	serverID, err := text.Substitute(bot.DiscordServerID, emptyMap)
+	if err != nil {
+		return fmt.Errorf("could not set 'discord_server_id': %w", err)
+	}
+
+	bot.DiscordServerID = serverID
+
+	if !validation.IsSet(token, serverID) {
+		return errors.New("bot is not configured correctly for discord - check that 'discord_token' and 'discord_server_id' are set")
+	}
+
+	return nil
+}
+
+// output updates reactions and sends the message, scheduled messages are not supported.
+func output(r remote.Remote, env models.Envelope, bot *models.Bot) {
+	if env.Message.Service == models.MsgServiceScheduler {
+		log.Warn().Msg("scheduler does not currently support discord")
+		return
+	}
+
+	remote.React(r, env, bot)
+
+	if env.Has(models.OpSend) {
+		r.Send(env.Message, bot)
+	}
+}
+
+// isMemberOfGroup checks whether the user has any of the given Discord roles.
+func isMemberOfGroup(currentUserID string, userGroups []string, bot *models.Bot) (bool, error) {
+	var usr *discordgo.Member
+
+	dg, err := discordgo.New("Bot " + bot.DiscordToken)
+	if err != nil {
+		return false, err
+	}
+
+	usr, err = dg.GuildMember(bot.DiscordServerID, currentUserID)
+	if err != nil {
+		log.Error().Msgf("error while searching for user - error: %v", err)
+		return false, nil
+	}
+
+	for _, group := range userGroups {
+		for _, uGroup := range usr.Roles {
+			if strings.EqualFold(bot.UserGroups[group], uGroup) {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
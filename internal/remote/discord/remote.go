@@ -3,6 +3,7 @@
 package discord
 
 import (
+	"context"
 	"strconv"
 
 	"github.com/bwmarrin/discordgo"
@@ -74,7 +75,7 @@ func (c *Client) Reaction(message models.Message, rule models.Rule, _ *models.Bo
 }
 
 // Read implementation to satisfy remote interface.
-func (c *Client) Read(inputMsgs chan<- models.Message, _ map[string]models.Rule, bot *models.Bot) {
+func (c *Client) Read(ctx context.Context, inputMsgs chan<- models.Message, _ *models.RuleSet, bot *models.Bot) {
 	dg := c.new()
 	if dg == nil {
 		log.Error().Msg("failed to initialize discord client")
@@ -86,7 +87,13 @@ func (c *Client) Read(inputMsgs chan<- models.Message, _ map[string]models.Rule,
 		log.Error().Msgf("failed to open connection to discord server - error: %v", err)
 		return
 	}
-	// Wait here until CTRL-C or other term signal is received
+
+	defer func() {
+		if err := dg.Close(); err != nil {
+			log.Error().Msgf("failed to close connection to discord server - error: %v", err)
+		}
+	}()
+
 	log.Info().Msgf("discord is now running %#q - press ctrl-c to exit", bot.Name)
 
 	// get information about ourself
@@ -96,8 +103,13 @@ func (c *Client) Read(inputMsgs chan<- models.Message, _ map[string]models.Rule,
 		return
 	}
 
-	bot.Name = botuser.Username
-	bot.ID = botuser.ID
+	// the 'id' of the bot.yml takes precedence over the id discord knows the bot by
+	identity := models.Identity{ID: bot.ID, Name: botuser.Username}
+	if identity.ID == "" {
+		identity.ID = botuser.ID
+	}
+
+	bot.SetIdentity(models.ChatAppDiscord, identity)
 
 	foundGuild := false
 
@@ -150,12 +162,15 @@ func (c *Client) Read(inputMsgs chan<- models.Message, _ map[string]models.Rule,
 		groups[grole.Name] = grole.ID
 	}
 
-	bot.Rooms = rooms
+	bot.AddRooms(models.ChatAppDiscord, rooms)
 	bot.Users = users
 	bot.UserGroups = groups
 
 	// Register a callback for MessageCreate events
 	dg.AddHandler(handleDiscordMessage(bot, inputMsgs))
+
+	// Wait here until the bot shuts down
+	<-ctx.Done()
 }
 
 // Send implementation to satisfy remote interface.
@@ -183,7 +198,7 @@ func handleDiscordMessage(bot *models.Bot, inputMsgs chan<- models.Message) any
 		}
 
 		// ignore messages from self
-		if m.Author.ID == bot.ID {
+		if m.Author.ID == s.State.User.ID {
 			return
 		}
 
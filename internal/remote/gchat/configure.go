@@ -9,7 +9,8 @@ import (
 	"github.com/target/flottbot/internal/text"
 )
 
-func Configure(bot *models.Bot) {
+// Configure applies environmental changes to the Google Chat specific fields of the bot.
+func Configure(bot *models.Bot) error {
 	// emptyMap for substitute function
 	// (it will only replace from env vars)
 	emptyMap := map[string]string{}
@@ -37,4 +38,6 @@ func Configure(bot *models.Bot) {
 	}
 
 	bot.GoogleChatSubscriptionID = subscriptionID
+
+	return nil
 }
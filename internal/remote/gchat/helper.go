@@ -24,32 +24,6 @@ type DomainEvent struct {
 	} `json:"user"`
 }
 
-// HandleOutput handles input messages for this remote.
-func HandleRemoteInput(inputMsgs chan<- models.Message, rules map[string]models.Rule, bot *models.Bot) {
-	c := &Client{
-		Credentials:        bot.GoogleChatCredentials,
-		ProjectID:          bot.GoogleChatProjectID,
-		SubscriptionID:     bot.GoogleChatSubscriptionID,
-		ForceReplyToThread: bot.GoogleChatForceReplyToThread,
-	}
-
-	// Read messages from Google Chat
-	go c.Read(inputMsgs, rules, bot)
-}
-
-// HandleRemoteOutput handles output messages for this remote.
-func HandleRemoteOutput(message models.Message, bot *models.Bot) {
-	c := &Client{
-		Credentials:        bot.GoogleChatCredentials,
-		ProjectID:          bot.GoogleChatProjectID,
-		SubscriptionID:     bot.GoogleChatSubscriptionID,
-		ForceReplyToThread: bot.GoogleChatForceReplyToThread,
-	}
-
-	// Send messages to Google Chat
-	go c.Send(message, bot)
-}
-
 // toMessage converts a PubSub message to Flottbot Message.
 func toMessage(m *pubsub.Message) (models.Message, error) {
 	message := models.NewMessage()
@@ -0,0 +1,33 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package gchat
+
+import (
+	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/remote"
+)
+
+func init() {
+	remote.Register(models.ChatAppGoogleChat, remote.Registration{
+		New:       newClient,
+		Configure: Configure,
+		Output:    output,
+	})
+}
+
+// newClient creates a Google Chat client from the bot configuration.
+func newClient(bot *models.Bot) remote.Remote {
+	return &Client{
+		Credentials:        bot.GoogleChatCredentials,
+		ProjectID:          bot.GoogleChatProjectID,
+		SubscriptionID:     bot.GoogleChatSubscriptionID,
+		ForceReplyToThread: bot.GoogleChatForceReplyToThread,
+	}
+}
+
+// output sends messages to Google Chat without blocking other outputs.
+func output(r remote.Remote, env models.Envelope, bot *models.Bot) {
+	if env.Has(models.OpSend) {
+		go r.Send(env.Message, bot)
+	}
+}
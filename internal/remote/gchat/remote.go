@@ -49,14 +49,13 @@ func (c *Client) Name() string {
 }
 
 // Read messages from Google Chat.
-func (c *Client) Read(inputMsgs chan<- models.Message, _ map[string]models.Rule, _ *models.Bot) {
-	ctx := context.Background()
-
+func (c *Client) Read(ctx context.Context, inputMsgs chan<- models.Message, _ *models.RuleSet, _ *models.Bot) {
 	// init client
 	client := c.new()
 
 	sub := client.Subscriber(c.SubscriptionID)
 
+	// receives until the context is canceled
 	err := sub.Receive(ctx, func(_ context.Context, m *pubsub.Message) {
 		defer m.Ack()
 
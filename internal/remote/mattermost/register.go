@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package mattermost
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/remote"
+	"github.com/target/flottbot/internal/text"
+)
+
+func init() {
+	remote.Register(models.ChatAppMattermost, remote.Registration{
+		New:       newClient,
+		Configure: Configure,
+	})
+}
+
+// newClient creates a Mattermost client from the bot configuration.
+func newClient(bot *models.Bot) remote.Remote {
+	c := &Client{
+		Token:    bot.MatterMostToken,
+		Server:   bot.MatterMostServer,
+		Insecure: false,
+	}
+
+	if strings.ToLower(bot.MatterMostInsecureProtocol) == "1" {
+		c.Insecure = true
+	}
+
+	return c
+}
+
+// Configure applies environmental changes to the Mattermost specific fields of the bot.
+func Configure(bot *models.Bot) error {
+	// emptyMap for substitute function
+	// (it will only replace from env vars)
+	emptyMap := map[string]string{}
+
+	token, err := text.Substitute(bot.MatterMostToken, emptyMap)
+	if err != nil {
+		return fmt.Errorf("could not set 'mattermost_token': %w", err)
+	}
+
+	bot.MatterMostToken = token
+
+	server, err := text.Substitute(bot.MatterMostServer, emptyMap)
+	if err != nil {
+		return fmt.Errorf("could not set 'mattermost_server': %w", err)
+	}
+
+	bot.MatterMostServer = server
+
+	insc, err := text.Substitute(bot.MatterMostInsecureProtocol, emptyMap)
+	if err != nil {
+		log.Info().Msgf("could not retrieve insecure flag: '%v'", err.Error())
+	}
+
+	bot.MatterMostInsecureProtocol = insc
+
+	log.Info().Msgf("insecure setting is: %v", bot.MatterMostInsecureProtocol)
+
+	if strings.ToLower(bot.MatterMostInsecureProtocol) == "1" {
+		log.Warn().Msg("using insecure protocols http and ws")
+	}
+
+	return nil
+}
@@ -53,11 +53,9 @@ func (c *Client) Reaction(_ models.Message, rule models.Rule, _ *models.Bot) {
 	}
 }
 
-func (c *Client) Read(inputMsgs chan<- models.Message, _ map[string]models.Rule, bot *models.Bot) {
+func (c *Client) Read(ctx context.Context, inputMsgs chan<- models.Message, _ *models.RuleSet, bot *models.Bot) {
 	api := c.new()
 
-	ctx := context.Background()
-
 	user, resp, err := api.GetUser(ctx, "me", "")
 	if err != nil {
 		log.Fatal().Msgf("could not login, %s", err)
@@ -88,7 +86,7 @@ func (c *Client) Read(inputMsgs chan<- models.Message, _ map[string]models.Rule,
 			}
 		}
 
-		b.Rooms = rooms
+		b.AddRooms(models.ChatAppMattermost, rooms)
 	}(bot)
 
 	url := "wss://" + c.Server
@@ -155,6 +153,11 @@ func (c *Client) Read(inputMsgs chan<- models.Message, _ map[string]models.Rule,
 			}
 		}
 	}(ctx)
+
+	<-ctx.Done()
+
+	sock.Close()
+	log.Info().Msg("mattermost websocket closed")
 }
 
 func populateMessage(
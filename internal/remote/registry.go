@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+// Registration describes a chat application remote. Each remote package
+// registers itself (see 'Register'), so that the core of the bot can look
+// up remotes by the name used for 'chat_application' in the bot.yml.
+type Registration struct {
+	// New creates a new instance of the remote from the bot configuration.
+	New func(bot *models.Bot) Remote
+
+	// Configure validates the bot configuration for the remote and applies any
+	// environmental changes. An error means the remote can't be run.
+	Configure func(bot *models.Bot) error
+
+	// IsMemberOfGroup checks whether the user is part of any of the user groups.
+	// Optional, remotes without it don't support 'allow_usergroups' and 'ignore_usergroups'.
+	IsMemberOfGroup func(userID string, userGroups []string, bot *models.Bot) (bool, error)
+
+	// Output carries out the operations of an envelope, ie. sends the message and updates the reactions.
+	// Optional, defaults to updating the reactions and sending the message.
+	Output func(r Remote, env models.Envelope, bot *models.Bot)
+
+	// ValidateRule checks the remote specific settings of a rule when it is loaded.
+	// Optional, remotes without it have no settings to check.
+	ValidateRule func(rule models.Rule) error
+}
+
+var registry = struct {
+	sync.RWMutex
+	remotes map[string]Registration
+}{remotes: make(map[string]Registration)}
+
+// Register makes a remote available under the given name,
+// registering a remote with the same name replaces the previous one.
+func Register(name string, reg Registration) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	registry.remotes[strings.ToLower(name)] = reg
+}
+
+// Unregister removes the remote with the given name.
+func Unregister(name string) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	delete(registry.remotes, strings.ToLower(name))
+}
+
+// Lookup returns the remote registered under the given name.
+func Lookup(name string) (Registration, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	reg, ok := registry.remotes[strings.ToLower(name)]
+
+	return reg, ok
+}
+
+// Registered returns the sorted names of all registered remotes.
+func Registered() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	names := make([]string, 0, len(registry.remotes))
+	for name := range registry.remotes {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// ValidateRule checks the rule with every registered remote,
+// remote specific settings are validated even if the remote isn't used.
+func ValidateRule(rule models.Rule) error {
+	var errs []error
+
+	for _, name := range Registered() {
+		reg, _ := Lookup(name)
+		if reg.ValidateRule == nil {
+			continue
+		}
+
+		if err := reg.ValidateRule(rule); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// SendOutput carries out the operations of an envelope using the registered output handler,
+// or by updating the rule's reactions and sending the message if there is none.
+func (reg Registration) SendOutput(r Remote, env models.Envelope, bot *models.Bot) {
+	if reg.Output != nil {
+		reg.Output(r, env, bot)
+		return
+	}
+
+	React(r, env, bot)
+
+	if env.Has(models.OpSend) {
+		r.Send(env.Message, bot)
+	}
+}
+
+// React updates the reactions of the rule on the message that triggered it, if the envelope asks for it.
+func React(r Remote, env models.Envelope, bot *models.Bot) {
+	if env.Has(models.OpReact | models.OpUnreact) {
+		r.Reaction(env.Message, env.Reactions(), bot)
+	}
+}
@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+type fakeRemote struct {
+	reactions int
+	sent      int
+}
+
+func (f *fakeRemote) Name() string { return "fake" }
+
+func (f *fakeRemote) Read(context.Context, chan<- models.Message, *models.RuleSet, *models.Bot) {}
+
+func (f *fakeRemote) Send(_ models.Message, _ *models.Bot) { f.sent++ }
+
+func (f *fakeRemote) Reaction(_ models.Message, _ models.Rule, _ *models.Bot) { f.reactions++ }
+
+func TestRegistry(t *testing.T) {
+	Register("Fake", Registration{New: func(_ *models.Bot) Remote { return &fakeRemote{} }})
+
+	reg, ok := Lookup("fake")
+	if !ok || reg.New == nil {
+		t.Fatalf("Lookup() expected registered remote")
+	}
+
+	if !slices.Contains(Registered(), "fake") {
+		t.Errorf("Registered() = %v, expected to contain %#q", Registered(), "fake")
+	}
+
+	Unregister("FAKE")
+
+	if _, ok := Lookup("fake"); ok {
+		t.Errorf("Lookup() expected remote to be unregistered")
+	}
+}
+
+func TestRegistration_SendOutput(t *testing.T) {
+	tests := []struct {
+		name          string
+		reg           Registration
+		ops           models.Op
+		wantReactions int
+		wantSent      int
+	}{
+		{"Default output", Registration{}, models.OpSend | models.OpReact, 1, 1},
+		{"Default output only send", Registration{}, models.OpSend, 0, 1},
+		{"Default output only reactions", Registration{}, models.OpReact | models.OpUnreact, 1, 0},
+		{"Custom output", Registration{Output: func(r Remote, env models.Envelope, bot *models.Bot) {
+			r.Send(env.Message, bot)
+		}}, models.OpSend | models.OpReact, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &fakeRemote{}
+
+			tt.reg.SendOutput(r, models.Envelope{Message: models.NewMessage(), Ops: tt.ops}, new(models.Bot))
+
+			if r.reactions != tt.wantReactions || r.sent != tt.wantSent {
+				t.Errorf("SendOutput() reactions = %d, sent = %d, want %d, %d", r.reactions, r.sent, tt.wantReactions, tt.wantSent)
+			}
+		})
+	}
+}
@@ -19,7 +19,8 @@ import (
 type Remote interface {
 	Reaction(message models.Message, rule models.Rule, bot *models.Bot)
 
-	Read(inputMsgs chan<- models.Message, rules map[string]models.Rule, bot *models.Bot)
+	// Read reads messages until the context is canceled, then closes the connection and returns.
+	Read(ctx context.Context, inputMsgs chan<- models.Message, rules *models.RuleSet, bot *models.Bot)
 
 	Send(message models.Message, bot *models.Bot)
 
@@ -32,8 +33,8 @@ func Reaction(c context.Context, message models.Message, rule models.Rule, bot *
 }
 
 // Read enables the bot to read messages from a remote.
-func Read(c context.Context, inputMsgs chan<- models.Message, rules map[string]models.Rule, bot *models.Bot) {
-	FromContext(c).Read(inputMsgs, rules, bot)
+func Read(c context.Context, inputMsgs chan<- models.Message, rules *models.RuleSet, bot *models.Bot) {
+	FromContext(c).Read(c, inputMsgs, rules, bot)
 }
 
 // Send enables the bot to send messages to a remote.
@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+var defaultSchedulerLockTTL = 15 * time.Second
+
+// elector keeps track of whether this replica of the bot is the scheduler leader.
+// Only the leader fires scheduled rules; when the leader dies its lease expires
+// and another replica takes over.
+type elector struct {
+	locker Locker
+	key    string
+	holder string
+	ttl    time.Duration
+
+	// the time (in unix nanoseconds) until which this replica holds the lease
+	leaseUntil atomic.Int64
+}
+
+// newElector creates an elector for the lease of the bot with the given name, using
+// the lock backend configured for the bot. Without a lock backend every replica is the leader.
+func newElector(bot *models.Bot, botName string) (*elector, error) {
+	if bot.SchedulerLock == "" {
+		return &elector{}, nil
+	}
+
+	locker, err := newLocker(bot.SchedulerLock, bot)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := defaultSchedulerLockTTL
+
+	if bot.SchedulerLockTTL != "" {
+		ttl, err = time.ParseDuration(bot.SchedulerLockTTL)
+		if err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("invalid 'scheduler_lock_ttl' %#q", bot.SchedulerLockTTL)
+		}
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "flottbot"
+	}
+
+	return &elector{
+		locker: locker,
+		key:    "scheduler-" + botName,
+		holder: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		ttl:    ttl,
+	}, nil
+}
+
+// isLeader checks whether this replica currently holds the lease.
+func (e *elector) isLeader() bool {
+	if e.locker == nil {
+		return true
+	}
+
+	return time.Now().UnixNano() < e.leaseUntil.Load()
+}
+
+// campaign keeps trying to acquire, and once acquired renew, the lease until the context is done.
+func (e *elector) campaign(ctx context.Context) {
+	if e.locker == nil {
+		return
+	}
+
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		e.renew(ctx)
+
+		select {
+		case <-ctx.Done():
+			err := e.locker.Release(context.Background(), e.key, e.holder)
+			if err != nil {
+				log.Error().Msgf("unable to release scheduler lease: %v", err)
+			}
+
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// renew tries to acquire or renew the lease once.
+func (e *elector) renew(ctx context.Context) {
+	wasLeader := e.isLeader()
+	start := time.Now()
+
+	ok, err := e.locker.TryAcquire(ctx, e.key, e.holder, e.ttl)
+	if err != nil {
+		// keep the current lease, it expires on its own if renewing keeps failing
+		log.Error().Msgf("unable to acquire scheduler lease: %v", err)
+		return
+	}
+
+	if !ok {
+		e.leaseUntil.Store(0)
+
+		if wasLeader {
+			log.Warn().Msgf("%#q lost the scheduler lease", e.holder)
+		}
+
+		return
+	}
+
+	// the lease is counted from before the request, so it never outlives the lease of the backend
+	e.leaseUntil.Store(start.Add(e.ttl).UnixNano())
+
+	if !wasLeader {
+		log.Info().Msgf("%#q is now the scheduler leader", e.holder)
+	}
+}
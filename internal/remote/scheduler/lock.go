@@ -0,0 +1,357 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+// Locker is a lock backend the scheduler uses to elect a leader
+// when multiple replicas of the bot are running.
+// Implementations must be safe for concurrent use.
+type Locker interface {
+	// TryAcquire acquires the lease for key on behalf of holder, or renews it
+	// if holder already owns it. It returns false if the lease is held by someone else.
+	TryAcquire(ctx context.Context, key, holder string, ttl time.Duration) (bool, error)
+
+	// Release gives up the lease for key if it is owned by holder.
+	Release(ctx context.Context, key, holder string) error
+}
+
+// LockerFactory creates a Locker from the bot configuration.
+type LockerFactory func(bot *models.Bot) (Locker, error)
+
+var lockers = struct {
+	sync.RWMutex
+	factories map[string]LockerFactory
+}{factories: make(map[string]LockerFactory)}
+
+func init() {
+	RegisterLocker("memory", func(_ *models.Bot) (Locker, error) {
+		return NewMemoryLocker(), nil
+	})
+	RegisterLocker("file", func(bot *models.Bot) (Locker, error) {
+		return NewFileLocker(bot.SchedulerLockPath)
+	})
+	RegisterLocker("redis", func(bot *models.Bot) (Locker, error) {
+		return NewRedisLocker(bot.RedisURL)
+	})
+}
+
+// RegisterLocker makes a lock backend available under the given name,
+// which can then be used for 'scheduler_lock' in the bot.yml.
+// This allows adding backends such as etcd or Kubernetes leases.
+func RegisterLocker(name string, factory LockerFactory) {
+	lockers.Lock()
+	defer lockers.Unlock()
+
+	lockers.factories[strings.ToLower(name)] = factory
+}
+
+// newLocker creates the lock backend registered under the given name.
+func newLocker(name string, bot *models.Bot) (Locker, error) {
+	lockers.RLock()
+	factory, ok := lockers.factories[strings.ToLower(name)]
+	lockers.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("scheduler lock %#q is not supported", name)
+	}
+
+	return factory(bot)
+}
+
+// lease is the state of a lock.
+type lease struct {
+	Holder  string    `json:"holder"`
+	Expires time.Time `json:"expires"`
+}
+
+// available checks whether the lease can be taken by holder at the given time.
+func (l lease) available(holder string, now time.Time) bool {
+	return l.Holder == "" || l.Holder == holder || !now.Before(l.Expires)
+}
+
+// MemoryLocker keeps leases in memory, which only coordinates
+// schedulers within a single process.
+type MemoryLocker struct {
+	mu     sync.Mutex
+	leases map[string]lease
+}
+
+// NewMemoryLocker creates an in-memory lock backend.
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{leases: make(map[string]lease)}
+}
+
+// TryAcquire implementation to satisfy the Locker interface.
+func (m *MemoryLocker) TryAcquire(_ context.Context, key, holder string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if !m.leases[key].available(holder, now) {
+		return false, nil
+	}
+
+	m.leases[key] = lease{Holder: holder, Expires: now.Add(ttl)}
+
+	return true, nil
+}
+
+// Release implementation to satisfy the Locker interface.
+func (m *MemoryLocker) Release(_ context.Context, key, holder string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.leases[key].Holder == holder {
+		delete(m.leases, key)
+	}
+
+	return nil
+}
+
+const (
+	// how long to wait between attempts to get exclusive access to a lease file.
+	fileLockRetryDelay = 10 * time.Millisecond
+	// guard files older than this were left behind by a crashed replica.
+	fileLockStaleAfter = 5 * time.Second
+)
+
+// FileLocker keeps leases in files in a directory, ie. on a volume that is
+// shared by all replicas of the bot.
+type FileLocker struct {
+	dir string
+}
+
+// NewFileLocker creates a file based lock backend that keeps leases in dir.
+func NewFileLocker(dir string) (*FileLocker, error) {
+	if dir == "" {
+		return nil, errors.New("'scheduler_lock_path' must be set to use the file scheduler lock")
+	}
+
+	err := os.MkdirAll(dir, 0o750)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create scheduler lock directory: %w", err)
+	}
+
+	return &FileLocker{dir: dir}, nil
+}
+
+// TryAcquire implementation to satisfy the Locker interface.
+func (f *FileLocker) TryAcquire(ctx context.Context, key, holder string, ttl time.Duration) (bool, error) {
+	unlock, err := f.lock(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	l, err := f.read(key)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	if !l.available(holder, now) {
+		return false, nil
+	}
+
+	err = f.write(key, lease{Holder: holder, Expires: now.Add(ttl)})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Release implementation to satisfy the Locker interface.
+func (f *FileLocker) Release(ctx context.Context, key, holder string) error {
+	unlock, err := f.lock(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	l, err := f.read(key)
+	if err != nil {
+		return err
+	}
+
+	if l.Holder != holder {
+		return nil
+	}
+
+	err = os.Remove(f.path(key))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("unable to remove scheduler lease: %w", err)
+	}
+
+	return nil
+}
+
+// path returns the path of the lease file for key.
+func (f *FileLocker) path(key string) string {
+	return filepath.Join(f.dir, url.PathEscape(key)+".lease")
+}
+
+// lock gets exclusive access to the lease file for key by creating a guard file,
+// which works across processes and platforms. The guard holds a token, so that
+// replicas only ever remove their own guard, or one that was left behind.
+func (f *FileLocker) lock(ctx context.Context, key string) (func(), error) {
+	guard := f.path(key) + ".lock"
+	token := xid.New().String()
+
+	for {
+		err := createGuard(guard, token)
+		if err == nil {
+			return func() { removeGuard(guard, token, token) }, nil
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("unable to lock scheduler lease: %w", err)
+		}
+
+		// take over guard files left behind by replicas that died while holding them
+		if owner, stale := staleGuard(guard); stale && removeGuard(guard, owner, token) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(fileLockRetryDelay):
+		}
+	}
+}
+
+// createGuard creates the guard file holding the token, it fails if the guard exists.
+func createGuard(guard, token string) error {
+	g, err := os.OpenFile(guard, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	_, err = g.WriteString(token)
+	if cerr := g.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		os.Remove(guard)
+	}
+
+	return err
+}
+
+// staleGuard returns the token of the guard file and whether it is old enough to be left behind.
+func staleGuard(guard string) (string, bool) {
+	// the age and the token are read from the same file, even if the guard is replaced in between
+	g, err := os.Open(guard)
+	if err != nil {
+		return "", false
+	}
+	defer g.Close()
+
+	info, err := g.Stat()
+	if err != nil || time.Since(info.ModTime()) <= fileLockStaleAfter {
+		return "", false
+	}
+
+	owner, err := io.ReadAll(g)
+	if err != nil {
+		return "", false
+	}
+
+	return string(owner), true
+}
+
+// removeGuard removes the guard file if it holds the token of owner. The guard is moved aside
+// first, so that only one replica removes it. If it turns out to have been replaced by another
+// replica in the meantime, it is put back.
+func removeGuard(guard, owner, token string) bool {
+	removed := guard + "." + token
+
+	err := os.Rename(guard, removed)
+	if err != nil {
+		return false
+	}
+
+	defer os.Remove(removed)
+
+	b, err := os.ReadFile(removed)
+	if err == nil && string(b) == owner {
+		return true
+	}
+
+	err = os.Link(removed, guard)
+	if err != nil {
+		log.Error().Msgf("unable to put back the scheduler lease guard %#q: %v", guard, err)
+	}
+
+	return false
+}
+
+// read reads the lease for key, a missing lease file means the lease is available.
+func (f *FileLocker) read(key string) (lease, error) {
+	var l lease
+
+	b, err := os.ReadFile(f.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+
+	if err != nil {
+		return l, fmt.Errorf("unable to read scheduler lease: %w", err)
+	}
+
+	err = json.Unmarshal(b, &l)
+	if err != nil {
+		return l, fmt.Errorf("unable to parse scheduler lease: %w", err)
+	}
+
+	return l, nil
+}
+
+// write atomically replaces the lease for key.
+func (f *FileLocker) write(key string, l lease) error {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("unable to encode scheduler lease: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(f.dir, ".lease-*")
+	if err != nil {
+		return fmt.Errorf("unable to write scheduler lease: %w", err)
+	}
+
+	_, err = tmp.Write(b)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), f.path(key))
+	}
+
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("unable to write scheduler lease: %w", err)
+	}
+
+	return nil
+}
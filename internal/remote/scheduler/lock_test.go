@@ -0,0 +1,199 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+func TestLocker(t *testing.T) {
+	fileLocker, err := NewFileLocker(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileLocker() error = %v", err)
+	}
+
+	server := miniredis.RunT(t)
+
+	redisLocker, err := NewRedisLocker("redis://" + server.Addr())
+	if err != nil {
+		t.Fatalf("NewRedisLocker() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		locker Locker
+		wait   func(time.Duration) // lets the time pass for the leases
+	}{
+		{"Memory", NewMemoryLocker(), time.Sleep},
+		{"File", fileLocker, time.Sleep},
+		{"Redis", redisLocker, server.FastForward},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			ttl := 50 * time.Millisecond
+
+			acquire := func(holder string, want bool) {
+				t.Helper()
+
+				got, err := tt.locker.TryAcquire(ctx, "scheduler", holder, ttl)
+				if err != nil {
+					t.Fatalf("TryAcquire() error = %v", err)
+				}
+
+				if got != want {
+					t.Errorf("TryAcquire(%#q) = %v, want %v", holder, got, want)
+				}
+			}
+
+			acquire("a", true)
+			acquire("b", false)
+			// renewing the own lease
+			acquire("a", true)
+
+			// the lease fails over once it expires
+			tt.wait(2 * ttl)
+			acquire("b", true)
+			acquire("a", false)
+
+			// releasing someone else's lease is a no-op
+			err := tt.locker.Release(ctx, "scheduler", "a")
+			if err != nil {
+				t.Fatalf("Release() error = %v", err)
+			}
+
+			acquire("a", false)
+
+			err = tt.locker.Release(ctx, "scheduler", "b")
+			if err != nil {
+				t.Fatalf("Release() error = %v", err)
+			}
+
+			acquire("a", true)
+		})
+	}
+}
+
+func TestFileLocker_guard(t *testing.T) {
+	locker, err := NewFileLocker(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileLocker() error = %v", err)
+	}
+
+	guard := locker.path("scheduler") + ".lock"
+
+	// a replica holds the guard, others wait for it
+	err = createGuard(guard, "other")
+	if err != nil {
+		t.Fatalf("createGuard() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := locker.TryAcquire(ctx, "scheduler", "a", time.Minute); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("TryAcquire() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// a guard is only removed by its owner
+	if removeGuard(guard, "a", "a") {
+		t.Errorf("removeGuard() expected the guard of another replica to be kept")
+	}
+
+	if b, err := os.ReadFile(guard); err != nil || string(b) != "other" {
+		t.Errorf("removeGuard() expected the guard of another replica to be put back, got %q, %v", b, err)
+	}
+
+	// the replica died, its guard is taken over once it is stale
+	old := time.Now().Add(-2 * fileLockStaleAfter)
+
+	err = os.Chtimes(guard, old, old)
+	if err != nil {
+		t.Fatalf("unable to age guard: %v", err)
+	}
+
+	ok, err := locker.TryAcquire(context.Background(), "scheduler", "a", time.Minute)
+	if err != nil || !ok {
+		t.Errorf("TryAcquire() = %v, %v, want the stale guard to be taken over", ok, err)
+	}
+
+	if _, err := os.Stat(guard); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("TryAcquire() expected the guard to be removed, got %v", err)
+	}
+}
+
+func Test_newElector(t *testing.T) {
+	tests := []struct {
+		name       string
+		bot        *models.Bot
+		wantLocker bool
+		wantErr    bool
+	}{
+		{"No lock", &models.Bot{}, false, false},
+		{"Memory lock", &models.Bot{SchedulerLock: "memory"}, true, false},
+		{"File lock", &models.Bot{SchedulerLock: "File", SchedulerLockPath: t.TempDir(), SchedulerLockTTL: "1m"}, true, false},
+		{"File lock without path", &models.Bot{SchedulerLock: "file"}, false, true},
+		{"Redis lock", &models.Bot{SchedulerLock: "redis", RedisURL: "redis://localhost:6379/0"}, true, false},
+		{"Redis lock without url", &models.Bot{SchedulerLock: "redis"}, false, true},
+		{"Invalid ttl", &models.Bot{SchedulerLock: "memory", SchedulerLockTTL: "soon"}, false, true},
+		{"Unsupported lock", &models.Bot{SchedulerLock: "carrier-pigeon"}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newElector(tt.bot, "flottbot")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newElector() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && (got.locker != nil) != tt.wantLocker {
+				t.Errorf("newElector() locker = %v, wantLocker %v", got.locker, tt.wantLocker)
+			}
+
+			if tt.wantLocker && got.key != "scheduler-flottbot" {
+				t.Errorf("newElector() key = %#q, want %#q", got.key, "scheduler-flottbot")
+			}
+		})
+	}
+}
+
+func Test_elector(t *testing.T) {
+	locker := NewMemoryLocker()
+	ttl := 50 * time.Millisecond
+
+	a := &elector{locker: locker, key: "scheduler", holder: "a", ttl: ttl}
+	b := &elector{locker: locker, key: "scheduler", holder: "b", ttl: ttl}
+
+	a.renew(context.Background())
+	b.renew(context.Background())
+
+	if !a.isLeader() || b.isLeader() {
+		t.Fatalf("expected only the first replica to be the leader")
+	}
+
+	// the leader dies, its lease expires and the other replica takes over
+	time.Sleep(2 * ttl)
+
+	if a.isLeader() {
+		t.Errorf("expected an expired lease to end the leadership")
+	}
+
+	b.renew(context.Background())
+	a.renew(context.Background())
+
+	if a.isLeader() || !b.isLeader() {
+		t.Errorf("expected the second replica to take over")
+	}
+
+	// without a lock backend every replica is the leader
+	if !(&elector{}).isLeader() {
+		t.Errorf("expected elector without lock to be the leader")
+	}
+}
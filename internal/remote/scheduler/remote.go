@@ -3,8 +3,9 @@
 package scheduler
 
 import (
+	"context"
 	"fmt"
-	"sync"
+	"time"
 
 	"github.com/robfig/cron/v3"
 	"github.com/rs/zerolog/log"
@@ -13,8 +14,15 @@ import (
 	"github.com/target/flottbot/internal/remote"
 )
 
+// how often to check whether the rooms of the primary chat application were fetched.
+const roomsPollInterval = 100 * time.Millisecond
+
 // Client struct.
-type Client struct{}
+type Client struct {
+	// BotName is the configured name of the bot, captured when the bot starts. Replicas of the bot
+	// compete for the scheduler lease of the name, whatever the chat applications call the bot.
+	BotName string
+}
 
 // validate that Client adheres to remote interface.
 var _ remote.Remote = (*Client)(nil)
@@ -32,16 +40,57 @@ func (c *Client) Reaction(_ models.Message, _ models.Rule, _ *models.Bot) {
 // Read implementation to satisfy remote interface
 // This will read in schedule type rules from the rules map and create cronjobs that will
 // trigger messages to be sent for processing to the Matcher function via 'inputMsgs' channel.
-func (c *Client) Read(inputMsgs chan<- models.Message, rules map[string]models.Rule, bot *models.Bot) {
-	// Wait for bot.Rooms to populate (find a less hacky way to do this)
+// Whenever the rules are reloaded, the existing cronjobs are stopped and recreated.
+// When a 'scheduler_lock' is configured, only the replica holding the lease fires the cronjobs.
+// Once the context is canceled, the cronjobs are stopped after the running ones finish.
+func (c *Client) Read(ctx context.Context, inputMsgs chan<- models.Message, rules *models.RuleSet, bot *models.Bot) {
+	leader, err := newElector(bot, c.BotName)
+	if err != nil {
+		log.Error().Msgf("unable to start scheduler: %v", err)
+		return
+	}
+
+	// the lease is released once the context is canceled
+	go leader.campaign(ctx)
+
+	// Wait for the primary chat application to fetch its rooms, scheduled messages are sent there
+	for !bot.HasRooms("") {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(roomsPollInterval):
+		}
+	}
+
+	log.Info().Msgf("scheduler connected to %#q channels", bot.PrimaryChatApplication())
+
+	updates := rules.Subscribe()
+
 	for {
-		_nil := bot.Rooms[""]
-		if len(bot.Rooms) > 0 {
-			log.Info().Msgf("scheduler connected to %#q channels: %s", bot.ChatApplication, _nil)
-			break
+		jobs := createJobs(inputMsgs, rules.Rules(), bot, leader)
+		if len(jobs) == 0 {
+			log.Warn().Msg("no schedules were added - please check for errors")
+		}
+
+		startJobs(jobs)
+
+		select {
+		case <-updates:
+			log.Info().Msg("rules changed - re-registering schedules")
+
+			stopJobs(jobs)
+		case <-ctx.Done():
+			log.Info().Msg("stopping schedules")
+
+			stopJobs(jobs)
+
+			return
 		}
 	}
+}
 
+// createJobs creates cronjobs for all active schedule type rules.
+func createJobs(inputMsgs chan<- models.Message, rules map[string]models.Rule, bot *models.Bot, leader *elector) []*cron.Cron {
 	var job *cron.Cron
 	// Create a list of cron jobs to execute
 	jobs := []*cron.Cron{}
@@ -53,7 +102,7 @@ func (c *Client) Read(inputMsgs chan<- models.Message, rules map[string]models.R
 			if len(rule.OutputToRooms) == 0 && len(rule.OutputToUsers) == 0 {
 				log.Error().Msg("scheduling rules require the 'output_to_rooms' and/or 'output_to_users' fields to be set")
 				continue
-			} else if len(rule.OutputToRooms) > 0 && len(bot.Rooms) == 0 {
+			} else if len(rule.OutputToRooms) > 0 && !bot.HasRooms("") {
 				log.Error().Msgf("unable to connect scheduler to these rooms: %s", rule.OutputToRooms)
 				continue
 			} else if rule.Respond != "" || rule.Hear != "" {
@@ -64,12 +113,17 @@ func (c *Client) Read(inputMsgs chan<- models.Message, rules map[string]models.R
 			log.Info().Msgf("scheduler is adding rule %#q", rule.Name)
 
 			scheduleName := rule.Name
-			input := fmt.Sprintf("<@%s> ", bot.ID) // send message as self
+			input := fmt.Sprintf("<@%s> ", bot.Identity("").ID) // send message as self
 			outputRooms := rule.OutputToRooms
 			outputUsers := rule.OutputToUsers
 
 			// prepare the job function
 			jobFunc := func() {
+				if !leader.isLeader() {
+					log.Debug().Msgf("skipping scheduler for rule %#q - another replica holds the scheduler lease", scheduleName)
+					return
+				}
+
 				log.Info().Msgf("executing scheduler for rule %#q", scheduleName)
 				// build the message
 				message := models.NewMessage()
@@ -113,12 +167,19 @@ func (c *Client) Read(inputMsgs chan<- models.Message, rules map[string]models.R
 		}
 	}
 
-	if len(jobs) == 0 {
-		log.Warn().Msg("no schedules were added - please check for errors")
-		return
+	return jobs
+}
+
+// ValidateSchedule checks that the schedule is a cron spec the scheduler supports,
+// either standard (5 fields) or quartz (6 fields, starting with the seconds).
+func ValidateSchedule(schedule string) error {
+	if _, err := cron.ParseStandard(schedule); err == nil {
+		return nil
 	}
 
-	processJobs(jobs)
+	_, err := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor).Parse(schedule)
+
+	return err
 }
 
 // Send implementation to satisfy remote interface.
@@ -126,19 +187,16 @@ func (c *Client) Send(_ models.Message, _ *models.Bot) {
 	// not implemented for Scheduler
 }
 
-// Process the Cron jobs.
-func processJobs(jobs []*cron.Cron) {
-	// Create wait group for cron jobs and execute them
-	wg := &sync.WaitGroup{}
-	wg.Add(len(jobs))
-
+// startJobs starts the cronjobs, each job runs in its own goroutine.
+func startJobs(jobs []*cron.Cron) {
 	for _, job := range jobs {
-		go func(c *cron.Cron) {
-			c.Start()
-		}(job)
-		defer job.Stop()
+		job.Start()
 	}
+}
 
-	wg.Wait()
-	log.Warn().Msg("scheduler is closing")
+// stopJobs stops the cronjobs and waits for any running jobs to finish.
+func stopJobs(jobs []*cron.Cron) {
+	for _, job := range jobs {
+		<-job.Stop().Done()
+	}
 }
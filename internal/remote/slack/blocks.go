@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+// maxBlocks is the number of blocks Slack accepts in a single message.
+const maxBlocks = 50
+
+// toBlocks converts the blocks of a rule to Slack blocks. The blocks are either
+// a list, as written in the rule's YAML, or the JSON of the blocks as string,
+// ie. the output of the Block Kit Builder.
+func toBlocks(blocks any) ([]slack.Block, error) {
+	var b []byte
+
+	switch v := blocks.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		b = []byte(strings.TrimSpace(v))
+		if len(b) == 0 {
+			return nil, nil
+		}
+	default:
+		var err error
+
+		b, err = json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode blocks: %w", err)
+		}
+	}
+
+	// the Block Kit Builder wraps the blocks in an object
+	if bytes.HasPrefix(b, []byte("{")) {
+		var payload struct {
+			Blocks json.RawMessage `json:"blocks"`
+		}
+
+		err := json.Unmarshal(b, &payload)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode blocks: %w", err)
+		}
+
+		if payload.Blocks == nil {
+			return nil, errors.New("unable to decode blocks: missing 'blocks' field")
+		}
+
+		b = payload.Blocks
+	}
+
+	var slackBlocks slack.Blocks
+
+	err := json.Unmarshal(b, &slackBlocks)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode blocks: %w", err)
+	}
+
+	return slackBlocks.BlockSet, nil
+}
+
+// validateRule checks the Slack specific settings of a rule when it is loaded,
+// so that malformed blocks are reported before the rule is used.
+func validateRule(rule models.Rule) error {
+	// blocks given as template can only be checked once they're rendered
+	if s, ok := rule.Remotes.Slack.Blocks.(string); ok && (strings.Contains(s, "${") || strings.Contains(s, "{{")) {
+		return nil
+	}
+
+	blocks, err := toBlocks(rule.Remotes.Slack.Blocks)
+	if err != nil {
+		return fmt.Errorf("invalid slack blocks: %w", err)
+	}
+
+	if len(blocks) > maxBlocks {
+		return fmt.Errorf("invalid slack blocks: got %d blocks, slack allows up to %d", len(blocks), maxBlocks)
+	}
+
+	for i, block := range blocks {
+		if _, ok := block.(*slack.UnknownBlock); ok {
+			return fmt.Errorf("invalid slack blocks: block %d has unknown type %#q", i+1, block.BlockType())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package slack
+
+import (
+	"testing"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+func Test_toBlocks(t *testing.T) {
+	tests := []struct {
+		name    string
+		blocks  any
+		want    int
+		wantErr bool
+	}{
+		{"No blocks", nil, 0, false},
+		{"Section and actions", []any{
+			map[string]any{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": "deploy?"}},
+			map[string]any{"type": "actions", "elements": []any{
+				map[string]any{"type": "button", "action_id": "approve", "text": map[string]any{"type": "plain_text", "text": "Approve"}},
+			}},
+		}, 2, false},
+		{"Invalid block", []map[string]any{{"type": "section", "text": "not an object"}}, 0, true},
+		{"JSON list", `[{"type":"divider"},{"type":"header","text":{"type":"plain_text","text":"hi"}}]`, 2, false},
+		{"Block Kit Builder payload", `{"blocks":[{"type":"divider"}]}`, 1, false},
+		{"Object without blocks", `{"type":"divider"}`, 0, true},
+		{"Empty string", " ", 0, false},
+		{"Malformed JSON", `[{"type":"divider"`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := toBlocks(tt.blocks)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("toBlocks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(got) != tt.want {
+				t.Errorf("toBlocks() got %d blocks, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func Test_validateRule(t *testing.T) {
+	tooMany := make([]any, maxBlocks+1)
+	for i := range tooMany {
+		tooMany[i] = map[string]any{"type": "divider"}
+	}
+
+	tests := []struct {
+		name    string
+		blocks  any
+		wantErr bool
+	}{
+		{"No blocks", nil, false},
+		{"Valid blocks", []any{map[string]any{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": "hi ${_user.name}"}}}, false},
+		{"Unknown block type", []any{map[string]any{"type": "sectoin"}}, true},
+		{"Missing block type", []any{map[string]any{"text": "hi"}}, true},
+		{"Malformed block", []any{map[string]any{"type": "section", "text": "not an object"}}, true},
+		{"Too many blocks", tooMany, true},
+		{"Malformed JSON", `[{"type":"divider"`, true},
+		{"Template is checked when rendered", `[{{ range .items }}{"type":"divider"}{{ end }}]`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := models.Rule{Remotes: models.Remotes{Slack: models.SlackConfig{Blocks: tt.blocks}}}
+
+			if err := validateRule(rule); (err != nil) != tt.wantErr {
+				t.Errorf("validateRule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
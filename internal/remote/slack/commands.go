@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/slack-go/slack"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+/*
+=====================================================================
+Slack slash commands (https://api.slack.com/interactivity/slash-commands)
+=====================================================================
+*/
+
+// getCommandsHandler creates and returns the handler for slash commands coming from Slack.
+func getCommandsHandler(api *slack.Client, signingSecret string, inputMsgs chan<- models.Message, bot *models.Bot) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, ok := readVerifiedBody(w, r, signingSecret)
+		if !ok {
+			return
+		}
+
+		// the body was consumed during verification, put it back for parsing the form
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		cmd, err := slack.SlashCommandParse(r)
+		if err != nil {
+			log.Error().Msg("slack: error while parsing slash command")
+			sendHTTPResponse(http.StatusBadRequest, "error while parsing slash command", w)
+
+			return
+		}
+
+		// acknowledge the command, slack expects a response within 3 seconds
+		ack, err := json.Marshal(commandAck(cmd))
+		if err != nil {
+			log.Error().Msg("slack: error while encoding slash command acknowledgment")
+			sendHTTPResponse(http.StatusInternalServerError, "error while encoding slash command acknowledgment", w)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		sendHTTPResponse(http.StatusOK, string(ack), w)
+
+		handleCommand(api, cmd, bot, inputMsgs)
+	}
+}
+
+// commandAck is the ephemeral message that immediately acknowledges the slash command,
+// the output of the rule is sent later on via the command's response url.
+func commandAck(cmd slack.SlashCommand) slack.Msg {
+	return slack.Msg{
+		ResponseType: slack.ResponseTypeEphemeral,
+		Text:         fmt.Sprintf("working on `%s`...", strings.TrimSpace(cmd.Command+" "+cmd.Text)),
+	}
+}
+
+// handleCommand passes the slash command on as a message.
+func handleCommand(api *slack.Client, cmd slack.SlashCommand, bot *models.Bot, inputMsgs chan<- models.Message) {
+	log.Info().Msgf("received slash command: %s", cmd.Command)
+
+	// get information on the user
+	user, err := api.GetUserInfo(cmd.UserID)
+	if err != nil {
+		log.Error().Msgf("did not get slack user info: %s", err.Error())
+
+		user = &slack.User{ID: cmd.UserID, Name: cmd.UserName, TeamID: cmd.TeamID}
+	}
+
+	inputMsgs <- populateCommand(cmd, user, bot)
+}
+
+// populateCommand creates the message for a slash command; the command without the leading slash,
+// followed by its text, is used as input, so that '/deploy my-service' hits a rule that responds to 'deploy'.
+// The details of the command are available as '_command.*' vars.
+func populateCommand(cmd slack.SlashCommand, user *slack.User, bot *models.Bot) models.Message {
+	input := strings.TrimSpace(strings.TrimPrefix(cmd.Command, "/") + " " + cmd.Text)
+
+	msgType, err := getMessageType(cmd.ChannelID)
+	if err != nil {
+		log.Error().Msg(err.Error())
+	}
+
+	// slash commands are always addressed to the bot
+	message := populateMessage(models.NewMessage(), msgType, cmd.ChannelID, input, "", "", "", true, user, bot)
+
+	// use the channel name slack sent along, the bot may not be a member of the channel
+	if message.ChannelName == "" && msgType != models.MsgTypeDirect {
+		message.ChannelName = cmd.ChannelName
+		message.Vars["_channel.name"] = cmd.ChannelName
+	}
+
+	message.Vars["_command.name"] = cmd.Command
+	message.Vars["_command.text"] = cmd.Text
+	message.Vars["_command.trigger_id"] = cmd.TriggerID
+	message.Vars["_command.response_url"] = cmd.ResponseURL
+
+	return message
+}
+
+// sendResponseURLMessage sends a message via the response url of a slash command,
+// this also works in channels the bot is not a member of.
+func sendResponseURLMessage(api *slack.Client, responseURL string, message models.Message) error {
+	responseType := slack.ResponseTypeInChannel
+	if message.IsEphemeral {
+		responseType = slack.ResponseTypeEphemeral
+	}
+
+	return sendMessage(api, false, message.ChannelID, "", message.Output, message.ThreadTimestamp, message.Remotes.Slack,
+		slack.MsgOptionResponseURL(responseURL, responseType))
+}
@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package slack
+
+import (
+	"testing"
+
+	"github.com/slack-go/slack"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+func Test_populateCommand(t *testing.T) {
+	bot := new(models.Bot)
+	bot.Rooms = map[string]string{"general": "C0123ABCD"}
+
+	user := &slack.User{ID: "U0123ABCD", Name: "jane"}
+
+	tests := []struct {
+		name      string
+		cmd       slack.SlashCommand
+		wantInput string
+		wantType  models.MessageType
+		wantVars  map[string]string
+	}{
+		{
+			"Command with args",
+			slack.SlashCommand{Command: "/deploy", Text: "my-service prod", ChannelID: "C0123ABCD", ResponseURL: "https://hooks.slack.com/commands/1"},
+			"deploy my-service prod",
+			models.MsgTypeChannel,
+			map[string]string{
+				"_command.name":         "/deploy",
+				"_command.text":         "my-service prod",
+				"_command.response_url": "https://hooks.slack.com/commands/1",
+				"_channel.name":         "general",
+				"_user.name":            "jane",
+			},
+		},
+		{
+			"Command without args",
+			slack.SlashCommand{Command: "/status", ChannelID: "C0123ABCD"},
+			"status",
+			models.MsgTypeChannel,
+			map[string]string{"_command.name": "/status", "_command.text": ""},
+		},
+		{
+			"Channel the bot is not in",
+			slack.SlashCommand{Command: "/status", ChannelID: "C9999ZZZZ", ChannelName: "random"},
+			"status",
+			models.MsgTypeChannel,
+			map[string]string{"_channel.id": "C9999ZZZZ", "_channel.name": "random"},
+		},
+		{
+			"Direct message",
+			slack.SlashCommand{Command: "/status", ChannelID: "D0123ABCD", ChannelName: "directmessage"},
+			"status",
+			models.MsgTypeDirect,
+			map[string]string{"_channel.id": "D0123ABCD", "_channel.name": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := populateCommand(tt.cmd, user, bot)
+
+			if got.Input != tt.wantInput {
+				t.Errorf("populateCommand() input = %q, want %q", got.Input, tt.wantInput)
+			}
+
+			if got.Type != tt.wantType {
+				t.Errorf("populateCommand() type = %v, want %v", got.Type, tt.wantType)
+			}
+
+			if !got.BotMentioned {
+				t.Error("populateCommand() should be addressed to the bot")
+			}
+
+			for k, v := range tt.wantVars {
+				if got.Vars[k] != v {
+					t.Errorf("populateCommand() var %s = %q, want %q", k, got.Vars[k], v)
+				}
+			}
+		})
+	}
+}
+
+func Test_commandAck(t *testing.T) {
+	got := commandAck(slack.SlashCommand{Command: "/deploy", Text: "my-service"})
+
+	if got.ResponseType != slack.ResponseTypeEphemeral {
+		t.Errorf("commandAck() response type = %q, want %q", got.ResponseType, slack.ResponseTypeEphemeral)
+	}
+
+	if got.Text != "working on `/deploy my-service`..." {
+		t.Errorf("commandAck() text = %q", got.Text)
+	}
+}
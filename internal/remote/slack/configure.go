@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package slack
+
+import (
+	"errors"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/text"
+	"github.com/target/flottbot/internal/validation"
+)
+
+var defaultSlackListenerPort = "3000"
+
+// Configure applies environmental changes to the Slack specific fields of the bot
+// and checks whether the bot is set up for either Socket Mode or the Events API.
+func Configure(bot *models.Bot) error {
+	// emptyMap for substitute function
+	// (it will only replace from env vars)
+	emptyMap := map[string]string{}
+
+	// slack_token
+	token, err := text.Substitute(bot.SlackToken, emptyMap)
+	if err != nil {
+		log.Error().Msgf("could not set 'slack_token': %s", err.Error())
+	}
+
+	bot.SlackToken = token
+
+	// slack_app_token
+	appToken, err := text.Substitute(bot.SlackAppToken, emptyMap)
+	if err != nil {
+		log.Warn().Msgf("could not set 'slack_app_token': %s", err.Error())
+	}
+
+	bot.SlackAppToken = appToken
+
+	// slack_signing_secret
+	signingSecret, err := text.Substitute(bot.SlackSigningSecret, emptyMap)
+	if err != nil {
+		log.Warn().Msgf("could not set 'slack_signing_secret': %s", err.Error())
+	}
+
+	bot.SlackSigningSecret = signingSecret
+
+	// slack_events_callback_path
+	eCallbackPath, err := text.Substitute(bot.SlackEventsCallbackPath, emptyMap)
+	if err != nil {
+		log.Warn().Msgf("could not set 'slack_events_callback_path': %s", err.Error())
+	}
+
+	bot.SlackEventsCallbackPath = eCallbackPath
+
+	// slack_interactions_callback_path
+	iCallbackPath, err := text.Substitute(bot.SlackInteractionsCallbackPath, emptyMap)
+	if err != nil {
+		log.Warn().Msgf("could not set 'slack_interactions_callback_path': %s", err.Error())
+	}
+
+	bot.SlackInteractionsCallbackPath = iCallbackPath
+
+	// slack_commands_callback_path
+	cCallbackPath, err := text.Substitute(bot.SlackCommandsCallbackPath, emptyMap)
+	if err != nil {
+		log.Warn().Msgf("could not set 'slack_commands_callback_path': %s", err.Error())
+	}
+
+	bot.SlackCommandsCallbackPath = cCallbackPath
+
+	// slack_listener_port
+	lPort, err := text.Substitute(bot.SlackListenerPort, emptyMap)
+	if err != nil {
+		log.Warn().Msgf("could not set 'slack_listener_port': %s", err.Error())
+	}
+
+	// set slack http listener port from config file or default
+	if !validation.IsSet(lPort) {
+		log.Warn().Msgf("'slack_listener_port' not set: %#q", lPort)
+		log.Info().Str("defaultSlackListenerPort", defaultSlackListenerPort).Msg("using default slack listener port.")
+		lPort = defaultSlackListenerPort
+	}
+
+	bot.SlackListenerPort = lPort
+
+	// check for valid setup
+	// needs one of the following to be valid
+	// 1. SLACK_TOKEN + SLACK_APP_TOKEN (socket mode)
+	// 2. SLACK_TOKEN + SLACK_SIGNING_SECRET + SLACK_EVENTS_CALLBACK_PATH (events api)
+	isSocketMode := validation.IsSet(token, appToken)
+	isEventsAPI := validation.IsSet(token, signingSecret, eCallbackPath)
+
+	if !isSocketMode && !isEventsAPI {
+		return errors.New("must have either 'slack_token', 'slack_app_token' or 'slack_token', 'slack_signing_secret', and 'slack_events_callback_path' set")
+	}
+
+	return nil
+}
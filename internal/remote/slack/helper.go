@@ -3,7 +3,9 @@
 package slack
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -81,19 +83,19 @@ func handleCallBack(api *slack.Client, event slackevents.EventsAPIInnerEvent, bo
 	switch ev := event.Data.(type) {
 	// handle https://api.slack.com/events/app_mention events
 	case *slackevents.AppMentionEvent:
-		text, mentioned := removeBotMention(ev.Text, bot.ID)
+		text, mentioned := removeBotMention(ev.Text, botUserID(bot))
 		handleMessageEvent(api, bot, ev.Channel, text, ev.User, ev.BotID, ev.TimeStamp, ev.ThreadTimeStamp, mentioned, inputMsgs)
 	// handle message.channels, message.groups, message.im, and message.mpim events (https://api.slack.com/events?query=message)
 	// note: an event that triggers app_mention will also trigger this event, potentially causing double responses
 	case *slackevents.MessageEvent:
-		text, mentioned := removeBotMention(ev.Text, bot.ID)
+		text, mentioned := removeBotMention(ev.Text, botUserID(bot))
 		if !mentioned {
 			handleMessageEvent(api, bot, ev.Channel, text, ev.User, ev.BotID, ev.TimeStamp, ev.ThreadTimeStamp, mentioned, inputMsgs)
 		}
 	case *slackevents.ReactionAddedEvent:
 		senderID := ev.User
 
-		if senderID != "" && bot.ID != senderID {
+		if senderID != "" && botUserID(bot) != senderID {
 			channel := ev.Item.Channel
 
 			// determine the message type
@@ -122,7 +124,7 @@ func handleCallBack(api *slack.Client, event slackevents.EventsAPIInnerEvent, bo
 		}
 	case *slackevents.MemberJoinedChannelEvent:
 		// limit to our bot
-		if ev.User == bot.ID {
+		if ev.User == botUserID(bot) {
 			// options for getting channel info
 			opts := &slack.GetConversationInfoInput{
 				ChannelID:         ev.Channel,
@@ -137,7 +139,7 @@ func handleCallBack(api *slack.Client, event slackevents.EventsAPIInnerEvent, bo
 			}
 
 			// add the room to the lookup
-			bot.Rooms[channel.Name] = channel.ID
+			bot.AddRooms(models.ChatAppSlack, map[string]string{channel.Name: channel.ID})
 			log.Info().Msgf("joined new channel - %s (%s) added to lookup", channel.Name, channel.ID)
 		}
 	default:
@@ -145,49 +147,60 @@ func handleCallBack(api *slack.Client, event slackevents.EventsAPIInnerEvent, bo
 	}
 }
 
-// getEventsAPIEventHandler creates and returns the handler for events coming from the the Slack Events API reader.
-func getEventsAPIEventHandler(api *slack.Client, signingSecret string, inputMsgs chan<- models.Message, bot *models.Bot) func(w http.ResponseWriter, r *http.Request) {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// silently throw away anything that's not a POST
-		if r.Method != http.MethodPost {
-			log.Error().Msg("slack: method not allowed")
-			sendHTTPResponse(http.StatusMethodNotAllowed, "method not allowed", w)
+// readVerifiedBody reads the body of a request coming from Slack and verifies
+// its signature, it sends an error response and returns false if that fails.
+func readVerifiedBody(w http.ResponseWriter, r *http.Request, signingSecret string) ([]byte, bool) {
+	// silently throw away anything that's not a POST
+	if r.Method != http.MethodPost {
+		log.Error().Msg("slack: method not allowed")
+		sendHTTPResponse(http.StatusMethodNotAllowed, "method not allowed", w)
 
-			return
-		}
+		return nil, false
+	}
 
-		// read in the body of the incoming payload
-		body, err := io.ReadAll(r.Body)
-		if err != nil {
-			log.Error().Msg("slack: error reading request body")
-			sendHTTPResponse(http.StatusBadRequest, "error reading request body", w)
+	// read in the body of the incoming payload
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Error().Msg("slack: error reading request body")
+		sendHTTPResponse(http.StatusBadRequest, "error reading request body", w)
 
-			return
-		}
+		return nil, false
+	}
 
-		// create a new secrets verifier with
-		// the request header and signing secret
-		sv, err := slack.NewSecretsVerifier(r.Header, signingSecret)
-		if err != nil {
-			log.Error().Msg("slack: error creating secrets verifier")
-			sendHTTPResponse(http.StatusBadRequest, "error creating secrets verifier", w)
+	// create a new secrets verifier with
+	// the request header and signing secret
+	sv, err := slack.NewSecretsVerifier(r.Header, signingSecret)
+	if err != nil {
+		log.Error().Msg("slack: error creating secrets verifier")
+		sendHTTPResponse(http.StatusBadRequest, "error creating secrets verifier", w)
 
-			return
-		}
+		return nil, false
+	}
 
-		// write the request body's hash
-		if _, err := sv.Write(body); err != nil {
-			log.Error().Msg("slack: error while writing body")
-			sendHTTPResponse(http.StatusInternalServerError, "error while writing body", w)
+	// write the request body's hash
+	if _, err := sv.Write(body); err != nil {
+		log.Error().Msg("slack: error while writing body")
+		sendHTTPResponse(http.StatusInternalServerError, "error while writing body", w)
 
-			return
-		}
+		return nil, false
+	}
+
+	// validate signing secret with computed hash
+	if err := sv.Ensure(); err != nil {
+		log.Error().Msg("slack: request unauthorized")
+		sendHTTPResponse(http.StatusUnauthorized, "request unauthorized", w)
 
-		// validate signing secret with computed hash
-		if err := sv.Ensure(); err != nil {
-			log.Error().Msg("slack: request unauthorized")
-			sendHTTPResponse(http.StatusUnauthorized, "request unauthorized", w)
+		return nil, false
+	}
+
+	return body, true
+}
 
+// getEventsAPIEventHandler creates and returns the handler for events coming from the the Slack Events API reader.
+func getEventsAPIEventHandler(api *slack.Client, signingSecret string, inputMsgs chan<- models.Message, bot *models.Bot) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, ok := readVerifiedBody(w, r, signingSecret)
+		if !ok {
 			return
 		}
 
@@ -381,7 +394,7 @@ func populateMessage(message models.Message, msgType models.MessageType, channel
 
 		// If the message read was not a dm, get the name of the channel it came from
 		if msgType != models.MsgTypeDirect {
-			name, ok := findKey(bot.Rooms, channel)
+			name, ok := bot.RoomName(models.ChatAppSlack, channel)
 			if !ok {
 				log.Error().Msgf("could not find name of channel %#q", channel)
 			}
@@ -454,9 +467,13 @@ func populateReaction(message models.Message, msgType models.MessageType, channe
 	return message
 }
 
+// how long to wait for the requests that are being handled when the events api server stops.
+var eventsAPIShutdownTimeout = 10 * time.Second
+
 // readFromEventsAPI utilizes the Slack API client to read event-based messages.
 // This method of reading is preferred over the RTM method.
-func readFromEventsAPI(api *slack.Client, vToken string, inputMsgs chan<- models.Message, bot *models.Bot) {
+// The server stops once the context is canceled, letting requests that are being handled finish.
+func readFromEventsAPI(ctx context.Context, api *slack.Client, vToken string, inputMsgs chan<- models.Message, bot *models.Bot) {
 	// populate user groups
 	go getUserGroups(api, bot)
 
@@ -469,19 +486,40 @@ func readFromEventsAPI(api *slack.Client, vToken string, inputMsgs chan<- models
 	// Add event handler
 	router.HandleFunc(bot.SlackEventsCallbackPath, getEventsAPIEventHandler(api, vToken, inputMsgs, bot)).Methods("POST")
 
+	// Add interactions handler
+	if bot.SlackInteractionsCallbackPath != "" {
+		router.HandleFunc(bot.SlackInteractionsCallbackPath, getInteractionsHandler(api, vToken, inputMsgs, bot)).Methods("POST")
+	}
+
+	// Add slash commands handler
+	if bot.SlackCommandsCallbackPath != "" {
+		router.HandleFunc(bot.SlackCommandsCallbackPath, getCommandsHandler(api, vToken, inputMsgs, bot)).Methods("POST")
+	}
+
 	// Start listening to Slack events
 	maskedPort := fmt.Sprintf(":%s", bot.SlackListenerPort)
 
+	//nolint:gosec // fix to use server with timeout
+	server := &http.Server{Addr: maskedPort, Handler: router}
+
 	go func() {
-		//nolint:gosec // fix to use server with timeout
-		err := http.ListenAndServe(maskedPort, router)
-		if err != nil {
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Fatal().Msg("failed to run server")
 		}
 	}()
 
 	log.Info().Msgf("slack events api server is listening to %#q on port %#q",
 		bot.SlackEventsCallbackPath, bot.SlackListenerPort)
+
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), eventsAPIShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Error().Msgf("unable to stop slack events api server: %v", err)
+	}
 }
 
 // readFromSocketMode reads messages from Slack's Socket Mode
@@ -489,7 +527,7 @@ func readFromEventsAPI(api *slack.Client, vToken string, inputMsgs chan<- models
 // https://api.slack.com/apis/connections/socket
 //
 //nolint:gocyclo,funlen // needs refactor
-func readFromSocketMode(sm *slack.Client, inputMsgs chan<- models.Message, bot *models.Bot) {
+func readFromSocketMode(ctx context.Context, sm *slack.Client, inputMsgs chan<- models.Message, bot *models.Bot) {
 	// setup the client
 	client := socketmode.New(sm)
 
@@ -521,19 +559,19 @@ func readFromSocketMode(sm *slack.Client, inputMsgs chan<- models.Message, bot *
 					switch ev := innerEvent.Data.(type) {
 					// handle https://api.slack.com/events/app_mention events
 					case *slackevents.AppMentionEvent:
-						text, mentioned := removeBotMention(ev.Text, bot.ID)
+						text, mentioned := removeBotMention(ev.Text, botUserID(bot))
 						handleMessageEvent(sm, bot, ev.Channel, text, ev.User, ev.BotID, ev.TimeStamp, ev.ThreadTimeStamp, mentioned, inputMsgs)
 					// handle message.channels, message.groups, message.im, and message.mpim events (https://api.slack.com/events?query=message)
 					// note: an event that triggers app_mention will also trigger this event, potentially causing double responses
 					case *slackevents.MessageEvent:
-						text, mentioned := removeBotMention(ev.Text, bot.ID)
+						text, mentioned := removeBotMention(ev.Text, botUserID(bot))
 						if !mentioned {
 							handleMessageEvent(sm, bot, ev.Channel, text, ev.User, ev.BotID, ev.TimeStamp, ev.ThreadTimeStamp, mentioned, inputMsgs)
 						}
 					case *slackevents.ReactionAddedEvent:
 						senderID := ev.User
 
-						if senderID != "" && bot.ID != senderID {
+						if senderID != "" && botUserID(bot) != senderID {
 							channel := ev.Item.Channel
 
 							// determine the message type
@@ -563,7 +601,7 @@ func readFromSocketMode(sm *slack.Client, inputMsgs chan<- models.Message, bot *
 					case *slackevents.ReactionRemovedEvent:
 						senderID := ev.User
 
-						if senderID != "" && bot.ID != senderID {
+						if senderID != "" && botUserID(bot) != senderID {
 							channel := ev.Item.Channel
 
 							// determine the message type
@@ -592,7 +630,7 @@ func readFromSocketMode(sm *slack.Client, inputMsgs chan<- models.Message, bot *
 						}
 					case *slackevents.MemberJoinedChannelEvent:
 						// limit to our bot
-						if ev.User == bot.ID {
+						if ev.User == botUserID(bot) {
 							// options for getting channel info
 							opts := &slack.GetConversationInfoInput{
 								ChannelID:         ev.Channel,
@@ -607,13 +645,37 @@ func readFromSocketMode(sm *slack.Client, inputMsgs chan<- models.Message, bot *
 							}
 
 							// add the room to the lookup
-							bot.Rooms[channel.Name] = channel.ID
+							bot.AddRooms(models.ChatAppSlack, map[string]string{channel.Name: channel.ID})
 							log.Info().Msgf("joined new channel - %s (%s) added to lookup", channel.Name, channel.ID)
 						}
 					}
 				default:
 					log.Warn().Msgf("unsupported events api event received: %s", eventsAPIEvent.Type)
 				}
+			case socketmode.EventTypeInteractive:
+				callback, ok := evt.Data.(slack.InteractionCallback)
+				if !ok {
+					log.Error().Msgf("ignored: %+v", evt)
+
+					continue
+				}
+
+				// acknowledge interaction to Slack
+				client.Ack(*evt.Request)
+
+				handleInteraction(sm, callback, bot, inputMsgs)
+			case socketmode.EventTypeSlashCommand:
+				cmd, ok := evt.Data.(slack.SlashCommand)
+				if !ok {
+					log.Error().Msgf("ignored: %+v", evt)
+
+					continue
+				}
+
+				// acknowledge slash command to Slack
+				client.Ack(*evt.Request, commandAck(cmd))
+
+				handleCommand(sm, cmd, bot, inputMsgs)
 			case socketmode.EventTypeConnecting:
 				log.Info().Msg("connecting to slack via socket mode...")
 			case socketmode.EventTypeConnectionError:
@@ -629,8 +691,9 @@ func readFromSocketMode(sm *slack.Client, inputMsgs chan<- models.Message, bot *
 		}
 	}()
 
-	err := client.Run()
-	if err != nil {
+	// runs until the context is canceled, which closes the connection
+	err := client.RunContext(ctx)
+	if err != nil && ctx.Err() == nil {
 		log.Fatal().Msgf("unable to (re)connect to Slack: %v", err)
 	}
 }
@@ -655,7 +718,7 @@ func handleMessageEvent(sm *slack.Client, bot *models.Bot, channel, text, sender
 	}
 
 	// only process message that are not from our bot
-	if senderID != "" && bot.ID != senderID {
+	if senderID != "" && botUserID(bot) != senderID {
 		// determine the message type
 		msgType, err := getMessageType(channel)
 		if err != nil {
@@ -703,12 +766,17 @@ func send(api *slack.Client, message models.Message) {
 
 // sendBackToOriginMessage - sends a message back to where it came from in Slack; this is pretty much a catch-all among the other send functions.
 func sendBackToOriginMessage(api *slack.Client, message models.Message) error {
-	return sendMessage(api, message.IsEphemeral, message.ChannelID, message.Vars["_user.id"], message.Output, message.ThreadTimestamp, message.Remotes.Slack.Attachments)
+	// respond to slash commands via their response url
+	if responseURL := message.Vars["_command.response_url"]; responseURL != "" {
+		return sendResponseURLMessage(api, responseURL, message)
+	}
+
+	return sendMessage(api, message.IsEphemeral, message.ChannelID, message.Vars["_user.id"], message.Output, message.ThreadTimestamp, message.Remotes.Slack)
 }
 
 // sendChannelMessage - sends a message to a Slack channel.
 func sendChannelMessage(api *slack.Client, channel string, message models.Message) error {
-	return sendMessage(api, message.IsEphemeral, channel, message.Vars["_user.id"], message.Output, message.ThreadTimestamp, message.Remotes.Slack.Attachments)
+	return sendMessage(api, message.IsEphemeral, channel, message.Vars["_user.id"], message.Output, message.ThreadTimestamp, message.Remotes.Slack)
 }
 
 // sendDirectMessage - sends a message back to the user who dm'ed your bot.
@@ -722,19 +790,31 @@ func sendDirectMessage(api *slack.Client, userID string, message models.Message)
 		return err
 	}
 
-	return sendMessage(api, message.IsEphemeral, imChannelID.ID, message.Vars["_user.id"], message.Output, message.ThreadTimestamp, message.Remotes.Slack.Attachments)
+	return sendMessage(api, message.IsEphemeral, imChannelID.ID, message.Vars["_user.id"], message.Output, message.ThreadTimestamp, message.Remotes.Slack)
 }
 
 // sendMessage - does the final send to Slack; adds any Slack-specific message parameters to the message to be sent out.
-func sendMessage(api *slack.Client, ephemeral bool, channel, userID, text, threadTimeStamp string, attachments []slack.Attachment) error {
+func sendMessage(api *slack.Client, ephemeral bool, channel, userID, text, threadTimeStamp string, config models.SlackConfig, extra ...slack.MsgOption) error {
 	// prepare the message options
 	opts := []slack.MsgOption{
 		slack.MsgOptionText(text, false),
 		slack.MsgOptionAsUser(true),
-		slack.MsgOptionAttachments(attachments...),
+		slack.MsgOptionAttachments(config.Attachments...),
 		slack.MsgOptionTS(threadTimeStamp),
 	}
 
+	opts = append(opts, extra...)
+
+	// add Block Kit blocks, the text is used as fallback for notifications
+	blocks, err := toBlocks(config.Blocks)
+	if err != nil {
+		return err
+	}
+
+	if len(blocks) > 0 {
+		opts = append(opts, slack.MsgOptionBlocks(blocks...))
+	}
+
 	// send as ephemeral
 	if ephemeral {
 		_, err := api.PostEphemeral(channel, userID, opts...)
@@ -742,7 +822,7 @@ func sendMessage(api *slack.Client, ephemeral bool, channel, userID, text, threa
 	}
 
 	// send as regular post
-	_, _, err := api.PostMessage(channel, opts...)
+	_, _, err = api.PostMessage(channel, opts...)
 
 	return err
 }
@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/slack-go/slack"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+/*
+============================================================================
+Slack interactive components (https://api.slack.com/interactivity/handling)
+============================================================================
+*/
+
+// getInteractionsHandler creates and returns the handler for interaction payloads coming from Slack,
+// ie. when a user clicks a button in a message or submits a modal.
+func getInteractionsHandler(api *slack.Client, signingSecret string, inputMsgs chan<- models.Message, bot *models.Bot) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, ok := readVerifiedBody(w, r, signingSecret)
+		if !ok {
+			return
+		}
+
+		// the payload is sent as form field
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			log.Error().Msg("slack: error while parsing interaction")
+			sendHTTPResponse(http.StatusBadRequest, "error while parsing interaction", w)
+
+			return
+		}
+
+		var callback slack.InteractionCallback
+
+		err = json.Unmarshal([]byte(form.Get("payload")), &callback)
+		if err != nil {
+			log.Error().Msg("slack: error while parsing interaction payload")
+			sendHTTPResponse(http.StatusBadRequest, "error while parsing interaction payload", w)
+
+			return
+		}
+
+		// acknowledge the interaction, an empty response also closes submitted modals
+		w.WriteHeader(http.StatusOK)
+
+		handleInteraction(api, callback, bot, inputMsgs)
+	}
+}
+
+// handleInteraction passes block actions and view submissions on as messages.
+func handleInteraction(api *slack.Client, callback slack.InteractionCallback, bot *models.Bot, inputMsgs chan<- models.Message) {
+	log.Info().Msgf("received interaction: %s", callback.Type)
+
+	switch callback.Type {
+	case slack.InteractionTypeBlockActions, slack.InteractionTypeViewSubmission:
+		// get information on the user
+		user, err := api.GetUserInfo(callback.User.ID)
+		if err != nil {
+			log.Error().Msgf("did not get slack user info: %s", err.Error())
+
+			user = &callback.User
+		}
+
+		for _, message := range populateInteraction(callback, user, bot) {
+			inputMsgs <- message
+		}
+	default:
+		log.Debug().Msgf("unsupported interaction type: %s", callback.Type)
+	}
+}
+
+// populateInteraction creates a message for each block action, or for the submitted view,
+// the details of the interaction are available as '_interaction.*' vars.
+func populateInteraction(callback slack.InteractionCallback, user *slack.User, bot *models.Bot) []models.Message {
+	channel := callback.Channel.ID
+	if channel == "" {
+		channel = callback.Container.ChannelID
+	}
+
+	// views don't belong to a channel, unless the channel was passed along as metadata
+	if channel == "" && callback.Type == slack.InteractionTypeViewSubmission {
+		if _, err := getMessageType(callback.View.PrivateMetadata); err == nil {
+			channel = callback.View.PrivateMetadata
+		}
+	}
+
+	msgType, err := getMessageType(channel)
+	if err != nil {
+		// reply to the user directly if the interaction did not happen in a channel
+		channel = callback.User.ID
+		msgType = models.MsgTypeDirect
+	}
+
+	timestamp := callback.Container.MessageTs
+	if timestamp == "" {
+		timestamp = callback.Message.Timestamp
+	}
+
+	newMessage := func() models.Message {
+		message := populateMessage(models.NewMessage(), msgType, channel, "", timestamp, callback.Container.ThreadTs, "", false, user, bot)
+
+		message.Vars["_interaction.type"] = string(callback.Type)
+		message.Vars["_interaction.trigger_id"] = callback.TriggerID
+		message.Vars["_interaction.response_url"] = callback.ResponseURL
+		message.Vars["_interaction.message_ts"] = timestamp
+		message.Vars["_interaction.user.id"] = callback.User.ID
+		message.Vars["_interaction.user.name"] = callback.User.Name
+
+		if callback.View.State != nil {
+			populateValues(message.Vars, callback.View.State.Values)
+		}
+
+		return message
+	}
+
+	if callback.Type == slack.InteractionTypeViewSubmission {
+		message := newMessage()
+		message.ViewSubmission = callback.View.CallbackID
+		message.Vars["_interaction.callback_id"] = callback.View.CallbackID
+		message.Vars["_interaction.private_metadata"] = callback.View.PrivateMetadata
+
+		return []models.Message{message}
+	}
+
+	messages := []models.Message{}
+
+	for _, action := range callback.ActionCallback.BlockActions {
+		message := newMessage()
+		message.BlockAction = action.ActionID
+		message.Vars["_interaction.action_id"] = action.ActionID
+		message.Vars["_interaction.block_id"] = action.BlockID
+		message.Vars["_interaction.value"] = actionValue(action)
+		message.Vars["_interaction.text"] = action.Text.Text
+
+		if callback.BlockActionState != nil {
+			populateValues(message.Vars, callback.BlockActionState.Values)
+		}
+
+		messages = append(messages, message)
+	}
+
+	return messages
+}
+
+// populateValues makes the values of the inputs available as
+// '_interaction.values.<block_id>.<action_id>' and '_interaction.values.<action_id>' vars.
+func populateValues(vars map[string]string, values map[string]map[string]slack.BlockAction) {
+	for blockID, actions := range values {
+		for actionID, action := range actions {
+			value := actionValue(&action)
+			vars[fmt.Sprintf("_interaction.values.%s.%s", blockID, actionID)] = value
+			vars["_interaction.values."+actionID] = value
+		}
+	}
+}
+
+// actionValue returns the value of a block action, depending on the type of element,
+// multiple selected values are separated by commas.
+func actionValue(action *slack.BlockAction) string {
+	switch {
+	case action.Value != "":
+		return action.Value
+	case action.SelectedOption.Value != "":
+		return action.SelectedOption.Value
+	case len(action.SelectedOptions) > 0:
+		options := make([]string, 0, len(action.SelectedOptions))
+		for _, option := range action.SelectedOptions {
+			options = append(options, option.Value)
+		}
+
+		return strings.Join(options, ",")
+	case action.SelectedUser != "":
+		return action.SelectedUser
+	case len(action.SelectedUsers) > 0:
+		return strings.Join(action.SelectedUsers, ",")
+	case action.SelectedChannel != "":
+		return action.SelectedChannel
+	case len(action.SelectedChannels) > 0:
+		return strings.Join(action.SelectedChannels, ",")
+	case action.SelectedConversation != "":
+		return action.SelectedConversation
+	case len(action.SelectedConversations) > 0:
+		return strings.Join(action.SelectedConversations, ",")
+	case action.SelectedDate != "":
+		return action.SelectedDate
+	case action.SelectedTime != "":
+		return action.SelectedTime
+	case action.SelectedDateTime != 0:
+		return strconv.FormatInt(action.SelectedDateTime, 10)
+	default:
+		return ""
+	}
+}
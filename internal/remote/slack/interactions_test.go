@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package slack
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/slack-go/slack"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+func Test_populateInteraction(t *testing.T) {
+	bot := new(models.Bot)
+	bot.Rooms = map[string]string{"general": "C0123ABCD"}
+
+	user := &slack.User{ID: "U0123ABCD", Name: "jane"}
+
+	parse := func(payload string) slack.InteractionCallback {
+		t.Helper()
+
+		var callback slack.InteractionCallback
+
+		err := json.Unmarshal([]byte(payload), &callback)
+		if err != nil {
+			t.Fatalf("unable to parse payload: %v", err)
+		}
+
+		return callback
+	}
+
+	tests := []struct {
+		name     string
+		payload  string
+		wantType models.MessageType
+		wantVars map[string]string
+	}{
+		{
+			"Button in channel",
+			`{"type":"block_actions","user":{"id":"U0123ABCD","name":"jane"},"channel":{"id":"C0123ABCD"},
+			"container":{"message_ts":"123.456"},
+			"actions":[{"type":"button","action_id":"approve","block_id":"b1","value":"deploy-42","text":{"type":"plain_text","text":"Approve"}}]}`,
+			models.MsgTypeChannel,
+			map[string]string{
+				"_interaction.type":      "block_actions",
+				"_interaction.action_id": "approve",
+				"_interaction.value":     "deploy-42",
+				"_interaction.text":      "Approve",
+				"_channel.name":          "general",
+				"_user.name":             "jane",
+			},
+		},
+		{
+			"Select",
+			`{"type":"block_actions","user":{"id":"U0123ABCD"},"channel":{"id":"C0123ABCD"},
+			"actions":[{"type":"static_select","action_id":"pick","block_id":"b1","selected_option":{"value":"blue"}}]}`,
+			models.MsgTypeChannel,
+			map[string]string{"_interaction.action_id": "pick", "_interaction.value": "blue"},
+		},
+		{
+			"View submission",
+			`{"type":"view_submission","user":{"id":"U0123ABCD"},
+			"view":{"callback_id":"request_access","private_metadata":"C0123ABCD",
+			"state":{"values":{"b1":{"reason":{"type":"plain_text_input","value":"on call"}},"b2":{"who":{"type":"multi_users_select","selected_users":["U1","U2"]}}}}}}`,
+			models.MsgTypeChannel,
+			map[string]string{
+				"_interaction.callback_id":      "request_access",
+				"_interaction.values.b1.reason": "on call",
+				"_interaction.values.reason":    "on call",
+				"_interaction.values.b2.who":    "U1,U2",
+				"_interaction.private_metadata": "C0123ABCD",
+				"_channel.id":                   "C0123ABCD",
+			},
+		},
+		{
+			"View submission without channel",
+			`{"type":"view_submission","user":{"id":"U0123ABCD"},"view":{"callback_id":"request_access"}}`,
+			models.MsgTypeDirect,
+			map[string]string{"_channel.id": "U0123ABCD"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := populateInteraction(parse(tt.payload), user, bot)
+			if len(messages) != 1 {
+				t.Fatalf("populateInteraction() expected 1 message, got %d", len(messages))
+			}
+
+			message := messages[0]
+			if message.Type != tt.wantType {
+				t.Errorf("populateInteraction() type = %v, want %v", message.Type, tt.wantType)
+			}
+
+			if message.BlockAction == "" && message.ViewSubmission == "" {
+				t.Errorf("populateInteraction() expected the interaction to be set")
+			}
+
+			for k, v := range tt.wantVars {
+				if message.Vars[k] != v {
+					t.Errorf("populateInteraction() var %s = %#q, want %#q", k, message.Vars[k], v)
+				}
+			}
+		})
+	}
+}
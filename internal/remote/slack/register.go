@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package slack
+
+import (
+	"slices"
+
+	"github.com/rs/zerolog/log"
+	"github.com/slack-go/slack"
+
+	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/remote"
+)
+
+func init() {
+	remote.Register(models.ChatAppSlack, remote.Registration{
+		New:             newClient,
+		Configure:       Configure,
+		IsMemberOfGroup: isMemberOfGroup,
+		Output:          output,
+		ValidateRule:    validateRule,
+	})
+}
+
+// newClient creates a Slack client from the bot configuration.
+func newClient(bot *models.Bot) remote.Remote {
+	return &Client{
+		ListenerPort:  bot.SlackListenerPort,
+		Token:         bot.SlackToken,
+		AppToken:      bot.SlackAppToken,
+		SigningSecret: bot.SlackSigningSecret,
+	}
+}
+
+// output updates reactions on messages that came from Slack and sends the message.
+func output(r remote.Remote, env models.Envelope, bot *models.Bot) {
+	if env.Message.Service == models.MsgServiceChat {
+		remote.React(r, env, bot)
+	}
+
+	if env.Has(models.OpSend) {
+		r.Send(env.Message, bot)
+	}
+}
+
+// isMemberOfGroup checks whether the user is part of any of the given Slack user groups.
+func isMemberOfGroup(currentUserID string, userGroups []string, bot *models.Bot) (bool, error) {
+	api := slack.New(bot.SlackToken)
+
+	for _, usergroupName := range userGroups {
+		// Get the ID of the group from the usergroups the bot is aware of
+		for knownUserGroupName, knownUserGroupID := range bot.UserGroups {
+			if knownUserGroupName == usergroupName {
+				// Get the members of the group
+				userGroupMembers, err := api.GetUserGroupMembers(knownUserGroupID)
+				if err != nil {
+					log.Error().Msgf("unable to retrieve user group members, %v", err)
+				}
+				// Check if any of the members are the current user
+				if slices.Contains(userGroupMembers, currentUserID) {
+					return true, nil
+				}
+
+				break
+			}
+		}
+	}
+
+	return false, nil
+}
@@ -3,6 +3,7 @@
 package slack
 
 import (
+	"context"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -83,7 +84,7 @@ func (c *Client) Reaction(message models.Message, rule models.Rule, _ *models.Bo
 
 // Read implementation to satisfy remote interface
 // Utilizes the Slack API client to read messages from Slack.
-func (c *Client) Read(inputMsgs chan<- models.Message, _ map[string]models.Rule, bot *models.Bot) {
+func (c *Client) Read(ctx context.Context, inputMsgs chan<- models.Message, _ *models.RuleSet, bot *models.Bot) {
 	// init api client
 	api := c.new()
 
@@ -100,13 +101,14 @@ func (c *Client) Read(inputMsgs chan<- models.Message, _ map[string]models.Rule,
 		start := time.Now()
 
 		// get bot rooms
-		b.Rooms = getRooms(api)
+		rooms := getRooms(api)
+		b.AddRooms(models.ChatAppSlack, rooms)
 
-		log.Info().Msgf("fetched %d rooms in %s", len(b.Rooms), time.Since(start).String())
+		log.Info().Msgf("fetched %d rooms in %s", len(rooms), time.Since(start).String())
 	}(bot)
 
 	// set the bot ID
-	bot.ID = rat.UserID
+	bot.SetIdentity(models.ChatAppSlack, models.Identity{ID: rat.UserID, Name: rat.User})
 
 	if c.AppToken != "" {
 		// handle Socket Mode
@@ -121,11 +123,11 @@ func (c *Client) Read(inputMsgs chan<- models.Message, _ map[string]models.Rule,
 
 		// move the above inside readFromSocketMode below :o
 
-		readFromSocketMode(sm, inputMsgs, bot)
+		readFromSocketMode(ctx, sm, inputMsgs, bot)
 	} else if c.SigningSecret != "" {
 		// handle Events API setup
 		// assuming Events API setup if slack_signing_secret is provided
-		readFromEventsAPI(api, c.SigningSecret, inputMsgs, bot)
+		readFromEventsAPI(ctx, api, c.SigningSecret, inputMsgs, bot)
 	}
 
 	// slack is not configured correctly and cli is set to false
@@ -16,18 +16,9 @@ Utility functions (does not use 'slack-go/slack' package)
 =======================================================
 */
 
-// findKey - find the key value in the map based on its value pair.
-func findKey(m map[string]string, value string) (key string, ok bool) {
-	for k, v := range m {
-		if v == value {
-			key = k
-			ok = true
-
-			return
-		}
-	}
-
-	return
+// botUserID returns the id slack knows the bot by.
+func botUserID(bot *models.Bot) string {
+	return bot.Identity(models.ChatAppSlack).ID
 }
 
 // getMessageType - gets the type of message based on where it came from.
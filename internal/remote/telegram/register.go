@@ -0,0 +1,43 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package telegram
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/target/flottbot/internal/models"
+	"github.com/target/flottbot/internal/remote"
+	"github.com/target/flottbot/internal/text"
+	"github.com/target/flottbot/internal/validation"
+)
+
+func init() {
+	remote.Register(models.ChatAppTelegram, remote.Registration{
+		New:       newClient,
+		Configure: Configure,
+	})
+}
+
+// newClient creates a Telegram client from the bot configuration.
+func newClient(bot *models.Bot) remote.Remote {
+	return &Client{
+		Token: bot.TelegramToken,
+	}
+}
+
+// Configure applies environmental changes to the Telegram specific fields of the bot.
+func Configure(bot *models.Bot) error {
+	token, err := text.Substitute(bot.TelegramToken, map[string]string{})
+	if err != nil {
+		return fmt.Errorf("could not set 'telegram_token': %w", err)
+	}
+
+	if !validation.IsSet(token) {
+		return errors.New("bot is not configured correctly for telegram - check that 'telegram_token' is set")
+	}
+
+	bot.TelegramToken = token
+
+	return nil
+}
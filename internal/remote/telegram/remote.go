@@ -3,6 +3,7 @@
 package telegram
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -49,7 +50,7 @@ func (c *Client) Reaction(_ models.Message, _ models.Rule, _ *models.Bot) {
 }
 
 // Read implementation to satisfy remote interface.
-func (c *Client) Read(inputMsgs chan<- models.Message, _ map[string]models.Rule, bot *models.Bot) {
+func (c *Client) Read(ctx context.Context, inputMsgs chan<- models.Message, _ *models.RuleSet, bot *models.Bot) {
 	telegramAPI := c.new()
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
@@ -60,10 +61,16 @@ func (c *Client) Read(inputMsgs chan<- models.Message, _ map[string]models.Rule,
 		return
 	}
 
-	bot.Name = botuser.UserName
+	bot.SetIdentity(models.ChatAppTelegram, models.Identity{ID: strconv.FormatInt(botuser.ID, 10), Name: botuser.UserName})
 
 	updates := telegramAPI.GetUpdatesChan(u)
 
+	// stopping closes the updates channel, which ends the loop below
+	go func() {
+		<-ctx.Done()
+		telegramAPI.StopReceivingUpdates()
+	}()
+
 	for update := range updates {
 		var m *tgbotapi.Message
 
@@ -90,7 +97,7 @@ func (c *Client) Read(inputMsgs chan<- models.Message, _ map[string]models.Rule,
 			continue
 		}
 
-		msg, mentioned := processMessageText(m.Text, bot.Name)
+		msg, mentioned := processMessageText(m.Text, botuser.UserName)
 
 		// support slash commands
 		if len(m.Command()) > 0 {
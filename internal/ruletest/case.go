@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package ruletest runs declarative tests of rules. The tests are placed next to the
+// rules, ie. 'hello_test.yml' next to 'hello.yml', and push messages through the matcher
+// of the bot, with canned responses for http actions and mocked exec actions.
+package ruletest
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+)
+
+var defaultTimeout = 10 * time.Second
+
+// Case is a test of the rules, a message sent to the bot and what the bot is expected to do with it.
+type Case struct {
+	Name      string            `mapstructure:"name"`
+	Input     string            `mapstructure:"input"`
+	User      string            `mapstructure:"user"`       // name of the user who sent the message
+	UserID    string            `mapstructure:"user_id"`    // defaults to the name of the user
+	Channel   string            `mapstructure:"channel"`    // name of the channel the message was sent in
+	ChannelID string            `mapstructure:"channel_id"` // defaults to the name of the channel
+	Direct    bool              `mapstructure:"direct"`     // sent as direct message to the bot
+	Mention   *bool             `mapstructure:"mention"`    // whether the bot was mentioned, defaults to true
+	Vars      map[string]string `mapstructure:"vars"`
+	HTTP      []HTTPStub        `mapstructure:"http"`
+	Exec      []ExecMock        `mapstructure:"exec"`
+	Timeout   time.Duration     `mapstructure:"timeout"` // for the rule to finish, defaults to 10s
+	Expect    Expect            `mapstructure:"expect"`
+
+	File string // the file the test was read from
+}
+
+// HTTPStub is a canned response to the requests of 'http' actions. Stubs are replayed in order,
+// the last stub for a url answers any further requests.
+type HTTPStub struct {
+	Method  string            `mapstructure:"method"` // any method if empty
+	URL     string            `mapstructure:"url"`    // the query only has to match if the url has one
+	Status  int               `mapstructure:"status"` // defaults to 200
+	Headers map[string]string `mapstructure:"headers"`
+	Body    string            `mapstructure:"body"`
+}
+
+// ExecMock replaces the command of 'exec' actions that start with 'cmd', once the variables
+// are substituted. Commands are never run, a command without a mock fails the test.
+type ExecMock struct {
+	Cmd    string        `mapstructure:"cmd"`
+	Output string        `mapstructure:"output"`
+	Status int           `mapstructure:"status"` // exit code
+	Delay  time.Duration `mapstructure:"delay"`  // how long the command takes
+}
+
+// Expect is what the bot is expected to do. Only the fields that are set are checked,
+// the reply is the last message the bot sent.
+type Expect struct {
+	Rule           string   `mapstructure:"rule"`     // name of the rule that was hit
+	NoMatch        bool     `mapstructure:"no_match"` // no rule was hit
+	Output         *string  `mapstructure:"output"`
+	OutputContains string   `mapstructure:"output_contains"`
+	OutputToRooms  []string `mapstructure:"output_to_rooms"`
+	OutputToUsers  []string `mapstructure:"output_to_users"`
+	Direct         *bool    `mapstructure:"direct"` // the reply was sent as direct message
+	Thread         *bool    `mapstructure:"thread"` // the reply was sent to a thread
+	Reaction       string   `mapstructure:"reaction"`
+}
+
+// ReadFile reads the test cases of a file.
+func ReadFile(file string) ([]Case, error) {
+	conf := viper.New()
+	conf.SetConfigFile(file)
+
+	err := conf.ReadInConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error while reading test file %#q: %w", file, err)
+	}
+
+	tests := struct {
+		Tests []Case `mapstructure:"tests"`
+	}{}
+
+	err = conf.Unmarshal(&tests, viper.DecodeHook(mapstructure.StringToTimeDurationHookFunc()), func(c *mapstructure.DecoderConfig) {
+		c.ErrorUnused = true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error while decoding test file %#q: %w", file, err)
+	}
+
+	var errs []error
+
+	for i := range tests.Tests {
+		tc := &tests.Tests[i]
+		tc.File = file
+
+		if tc.Name == "" {
+			tc.Name = fmt.Sprintf("test %d", i+1)
+		}
+
+		if tc.Input == "" {
+			errs = append(errs, fmt.Errorf("%#q in %#q has no input", tc.Name, file))
+		}
+	}
+
+	return tests.Tests, errors.Join(errs...)
+}
+
+// timeout returns how long the rules may take to handle the message.
+func (tc Case) timeout() time.Duration {
+	if tc.Timeout > 0 {
+		return tc.Timeout
+	}
+
+	return defaultTimeout
+}
@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package ruletest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// WriteReport writes the outcome of the tests, one line per test, followed by the failures and a summary.
+func WriteReport(w io.Writer, results []Result) {
+	failed := 0
+
+	for _, result := range results {
+		status := "PASS"
+		if !result.Passed() {
+			status = "FAIL"
+			failed++
+		}
+
+		fmt.Fprintf(w, "%s  %s: %s (%s)\n", status, result.Case.File, result.Case.Name, result.Duration.Round(time.Millisecond))
+
+		for _, failure := range result.Failures {
+			fmt.Fprintf(w, "      %s\n", failure)
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d tests, %d passed, %d failed\n", len(results), len(results)-failed, failed)
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the outcome of the tests as JUnit XML, with a test suite per test file.
+func WriteJUnit(w io.Writer, results []Result) error {
+	report := junitTestSuites{}
+	suites := map[string]int{} // file -> index of its suite
+	durations := []time.Duration{}
+
+	var total time.Duration
+
+	for _, result := range results {
+		i, ok := suites[result.Case.File]
+		if !ok {
+			i = len(report.Suites)
+			suites[result.Case.File] = i
+			report.Suites = append(report.Suites, junitTestSuite{Name: result.Case.File})
+			durations = append(durations, 0)
+		}
+
+		suite := &report.Suites[i]
+		tc := junitTestCase{
+			Name:      result.Case.Name,
+			ClassName: result.Case.File,
+			Time:      seconds(result.Duration),
+		}
+
+		if !result.Passed() {
+			tc.Failure = &junitFailure{
+				Message: result.Failures[0],
+				Text:    strings.Join(result.Failures, "\n"),
+			}
+			suite.Failures++
+			report.Failures++
+		}
+
+		suite.Cases = append(suite.Cases, tc)
+		suite.Tests++
+		report.Tests++
+		durations[i] += result.Duration
+		total += result.Duration
+	}
+
+	for i, d := range durations {
+		report.Suites[i].Time = seconds(d)
+	}
+
+	report.Time = seconds(total)
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	err = enc.Encode(report)
+	if err != nil {
+		return fmt.Errorf("could not write junit report: %w", err)
+	}
+
+	_, err = io.WriteString(w, "\n")
+
+	return err
+}
+
+// seconds formats a duration the way JUnit reports do.
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package ruletest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     int // number of tests
+		wantErr  string
+	}{
+		{"Tests", "tests:\n  - name: greets\n    input: hello\n    timeout: 2s\n    expect:\n      output: hi\n  - input: bye\n", 2, ""},
+		{"No input", "tests:\n  - name: greets\n", 1, "`greets` in"},
+		{"Unknown key", "tests:\n  - input: hello\n    expect:\n      ouput: hi\n", 0, "ouput"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "hello_test.yml")
+			if err := os.WriteFile(file, []byte(tt.contents), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ReadFile(file)
+			if (err != nil) != (tt.wantErr != "") || err != nil && !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ReadFile() error = %v, want error with %q", err, tt.wantErr)
+			}
+
+			if len(got) != tt.want {
+				t.Fatalf("ReadFile() returned %d tests, want %d", len(got), tt.want)
+			}
+
+			if tt.name == "Tests" && (got[1].Name != "test 2" || got[0].timeout() != 2*time.Second || got[1].timeout() != defaultTimeout) {
+				t.Errorf("ReadFile() = %+v, want defaults for the name and timeout", got)
+			}
+		})
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	results := []Result{
+		{Case: Case{Name: "greets", File: "rules/hello_test.yml"}, Duration: time.Second},
+		{Case: Case{Name: "says bye", File: "rules/hello_test.yml"}, Failures: []string{"expected output", "expected a reply"}},
+		{Case: Case{Name: "cats", File: "rules/cats_test.yml"}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+
+	var got junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("WriteJUnit() wrote invalid XML: %v", err)
+	}
+
+	if got.Tests != 3 || got.Failures != 1 || len(got.Suites) != 2 {
+		t.Fatalf("WriteJUnit() = %d tests, %d failures in %d suites, want 3 tests, 1 failure in 2 suites", got.Tests, got.Failures, len(got.Suites))
+	}
+
+	suite := got.Suites[0]
+	if suite.Name != "rules/hello_test.yml" || suite.Tests != 2 || suite.Time != "1.000" {
+		t.Errorf("WriteJUnit() first suite = %+v, want 2 tests of rules/hello_test.yml taking 1.000s", suite)
+	}
+
+	failure := suite.Cases[1].Failure
+	if failure == nil || failure.Message != "expected output" || failure.Text != "expected output\nexpected a reply" {
+		t.Errorf("WriteJUnit() failure = %+v, want the failures of 'says bye'", failure)
+	}
+}
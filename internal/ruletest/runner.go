@@ -0,0 +1,245 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package ruletest
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/target/flottbot/flottbottest"
+	"github.com/target/flottbot/internal/handlers"
+	"github.com/target/flottbot/internal/models"
+)
+
+// Result is the outcome of a test.
+type Result struct {
+	Case     Case
+	Failures []string
+	Duration time.Duration
+}
+
+// Passed tells whether the bot did what the test expected.
+func (r Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// Run runs the tests against the rules, one after the other. For each test the bot runs in memory,
+// reading the message of the test from a fake chat application and sending its replies back to it.
+// Requests of 'http' actions are answered by a stub server with the canned responses of the test.
+func Run(tests []Case, rules map[string]models.Rule, bot *models.Bot) []Result {
+	stubs := &httpStubs{}
+	server := httptest.NewServer(stubs)
+
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+
+	handlers.SetTransport(stubTransport{server: serverURL})
+	defer handlers.SetTransport(http.DefaultTransport)
+
+	mocks := &execMocks{}
+
+	handlers.SetScriptRunner(mocks.run)
+	defer handlers.SetScriptRunner(handlers.RunScript)
+
+	rooms := testRooms(tests, rules)
+	results := make([]Result, 0, len(tests))
+
+	for _, tc := range tests {
+		stubs.reset(tc.HTTP)
+		mocks.reset(tc.Exec)
+
+		results = append(results, runCase(tc, rules, rooms, bot, stubs, mocks))
+	}
+
+	return results
+}
+
+// runCase sends the message of the test to the bot and checks the replies once the rules are done.
+func runCase(tc Case, rules map[string]models.Rule, rooms map[string]string, bot *models.Bot, stubs *httpStubs, mocks *execMocks) Result {
+	start := time.Now()
+	deadline := start.Add(tc.timeout())
+
+	inst := flottbottest.Run(bot, rules)
+
+	// replies go to the fake chat application, rooms are known by their name
+	inst.Bot.AddRooms(inst.Remote.Name(), rooms)
+
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	// stopping the bot once it read the message lets the rules finish and their replies be sent
+	err := inst.Remote.Inject(ctx, tc.message())
+	if stopErr := inst.Stop(time.Until(deadline)); err == nil {
+		err = stopErr
+	}
+
+	result := Result{Case: tc}
+
+	if err != nil {
+		result.Failures = []string{fmt.Sprintf("the rules did not finish within %s", tc.timeout())}
+	} else {
+		result.Failures = check(tc.Expect, inst.Remote.Envelopes())
+	}
+
+	for _, req := range stubs.unanswered() {
+		result.Failures = append(result.Failures, fmt.Sprintf("no canned response for request %s", req))
+	}
+
+	for _, cmd := range mocks.unmocked() {
+		result.Failures = append(result.Failures, fmt.Sprintf("no mock for command %s", cmd))
+	}
+
+	result.Duration = time.Since(start)
+
+	return result
+}
+
+// message creates the message of the test, as a chat application would.
+func (tc Case) message() models.Message {
+	message := models.NewMessage()
+	message.Service = models.MsgServiceChat
+	message.Input = tc.Input
+	message.Timestamp = strconv.FormatInt(message.StartTime, 10)
+	message.BotMentioned = tc.Mention == nil || *tc.Mention
+
+	message.Type = models.MsgTypeChannel
+	if tc.Direct {
+		message.Type = models.MsgTypeDirect
+	}
+
+	message.ChannelName = tc.Channel
+	message.ChannelID = cmp.Or(tc.ChannelID, tc.Channel)
+
+	message.Vars["_user.name"] = tc.User
+	message.Vars["_user.id"] = cmp.Or(tc.UserID, tc.User)
+	message.Vars["_channel.name"] = message.ChannelName
+	message.Vars["_channel.id"] = message.ChannelID
+
+	for k, v := range tc.Vars {
+		message.Vars[k] = v
+	}
+
+	return message
+}
+
+// check compares the replies of the bot with what the test expects.
+func check(expect Expect, envelopes []models.Envelope) []string {
+	var (
+		failures []string
+		hit      string
+		reply    *models.Message
+		reaction string
+	)
+
+	for _, env := range envelopes {
+		if hit == "" {
+			hit = env.Rule.Name
+		}
+
+		if env.Has(models.OpSend) {
+			reply = &env.Message
+		}
+
+		if env.Has(models.OpReact) {
+			reaction = env.Rule.Reaction
+		}
+	}
+
+	if expect.NoMatch && hit != "" {
+		failures = append(failures, fmt.Sprintf("expected no rule to be hit, but %#q was", hit))
+	}
+
+	if expect.Rule != "" && hit != expect.Rule {
+		failures = append(failures, fmt.Sprintf("expected rule %#q to be hit, but got %#q", expect.Rule, hit))
+	}
+
+	if expect.Reaction != "" && reaction != expect.Reaction {
+		failures = append(failures, fmt.Sprintf("expected reaction %#q, but got %#q", expect.Reaction, reaction))
+	}
+
+	if reply == nil {
+		if expectsReply(expect) {
+			failures = append(failures, "expected a reply, but the bot didn't send any")
+		}
+
+		return failures
+	}
+
+	return append(failures, checkReply(expect, *reply)...)
+}
+
+// expectsReply tells whether the test checks the reply of the bot.
+func expectsReply(expect Expect) bool {
+	return expect.Output != nil || expect.OutputContains != "" || expect.OutputToRooms != nil ||
+		expect.OutputToUsers != nil || expect.Direct != nil || expect.Thread != nil
+}
+
+// checkReply compares the last message the bot sent with what the test expects.
+func checkReply(expect Expect, reply models.Message) []string {
+	var failures []string
+
+	if expect.Output != nil && reply.Output != *expect.Output {
+		failures = append(failures, fmt.Sprintf("expected output %q, but got %q", *expect.Output, reply.Output))
+	}
+
+	if expect.OutputContains != "" && !strings.Contains(reply.Output, expect.OutputContains) {
+		failures = append(failures, fmt.Sprintf("expected output to contain %q, but got %q", expect.OutputContains, reply.Output))
+	}
+
+	if expect.OutputToRooms != nil && !slices.Equal(reply.OutputToRooms, expect.OutputToRooms) {
+		failures = append(failures, fmt.Sprintf("expected output to rooms %v, but got %v", expect.OutputToRooms, reply.OutputToRooms))
+	}
+
+	if expect.OutputToUsers != nil && !slices.Equal(reply.OutputToUsers, expect.OutputToUsers) {
+		failures = append(failures, fmt.Sprintf("expected output to users %v, but got %v", expect.OutputToUsers, reply.OutputToUsers))
+	}
+
+	direct := reply.DirectMessageOnly || reply.Type == models.MsgTypeDirect
+	if expect.Direct != nil && direct != *expect.Direct {
+		failures = append(failures, fmt.Sprintf("expected direct message to be %t, but got %t", *expect.Direct, direct))
+	}
+
+	thread := reply.ThreadTimestamp != ""
+	if expect.Thread != nil && thread != *expect.Thread {
+		failures = append(failures, fmt.Sprintf("expected reply in thread to be %t, but got %t", *expect.Thread, thread))
+	}
+
+	return failures
+}
+
+// testRooms returns the rooms of the rules and the tests, known by their name.
+func testRooms(tests []Case, rules map[string]models.Rule) map[string]string {
+	rooms := map[string]string{}
+
+	add := func(names []string) {
+		for _, name := range names {
+			rooms[strings.ToLower(name)] = name
+		}
+	}
+
+	for _, rule := range rules {
+		add(rule.OutputToRooms)
+
+		for _, action := range rule.Actions {
+			add(action.OutputToRooms)
+			add(action.LimitToRooms)
+		}
+	}
+
+	for _, tc := range tests {
+		if tc.Channel != "" {
+			add([]string{cmp.Or(tc.ChannelID, tc.Channel)})
+		}
+	}
+
+	return rooms
+}
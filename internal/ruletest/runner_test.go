@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package ruletest
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestRun(t *testing.T) {
+	rules := map[string]models.Rule{
+		"hello.yml": {
+			Name:               "hello",
+			Active:             true,
+			Respond:            "hello",
+			FormatOutput:       "what's up, ${_user.name}?",
+			StartMessageThread: true,
+			Reaction:           "wave",
+		},
+		"cats.yml": {
+			Name:    "cats",
+			Active:  true,
+			Respond: "cats",
+			Actions: []models.Action{
+				{Name: "fact", Type: "GET", URL: "https://catfact.ninja/fact", ExposeJSONFields: map[string]string{"fact": ".fact"}},
+			},
+			FormatOutput:  "${fact}",
+			OutputToRooms: []string{"cats"},
+		},
+		"deploy.yml": {
+			Name:    "deploy",
+			Active:  true,
+			Respond: "deploy",
+			Args:    []models.Arg{{Name: "app"}},
+			Actions: []models.Action{
+				{Name: "deploy", Type: "exec", Cmd: "deploy.sh ${app}"},
+			},
+			FormatOutput:      "${_exec_output}",
+			DirectMessageOnly: true,
+		},
+	}
+
+	catFact := []HTTPStub{{Method: "GET", URL: "https://catfact.ninja/fact", Body: `{"fact": "cats sleep 16 hours a day"}`}}
+
+	tests := []struct {
+		name     string
+		tc       Case
+		failures []string // substrings of the failures, in order
+	}{
+		{"Reply", Case{Input: "hello", User: "alice", Channel: "general", Expect: Expect{Rule: "hello", Output: ptr("what's up, alice?"), Thread: ptr(true), Reaction: "wave"}}, nil},
+		{"Wrong output", Case{Input: "hello", User: "alice", Expect: Expect{Output: ptr("hi, alice")}}, []string{"expected output"}},
+		{"Not mentioned", Case{Input: "hello", Mention: ptr(false), Expect: Expect{NoMatch: true}}, nil},
+		{"Expected a match", Case{Input: "hello", Mention: ptr(false), Expect: Expect{Rule: "hello", Output: ptr("bye")}}, []string{"expected rule", "expected a reply"}},
+		{"HTTP stub", Case{Input: "cats", HTTP: catFact, Expect: Expect{Rule: "cats", Output: ptr("cats sleep 16 hours a day"), OutputToRooms: []string{"cats"}}}, nil},
+		{"Missing HTTP stub", Case{Input: "cats", Expect: Expect{Rule: "cats"}}, []string{"no canned response for request GET https://catfact.ninja/fact"}},
+		{"Exec mock", Case{Input: "deploy api", Exec: []ExecMock{{Cmd: "deploy.sh api", Output: "deployed api"}}, Expect: Expect{Output: ptr("deployed api"), Direct: ptr(true)}}, nil},
+		{"Missing exec mock", Case{Input: "deploy api", Expect: Expect{Rule: "deploy"}}, []string{"no mock for command deploy.sh api"}},
+	}
+
+	cases := make([]Case, 0, len(tests))
+	for _, tt := range tests {
+		tt.tc.Name = tt.name
+		cases = append(cases, tt.tc)
+	}
+
+	results := Run(cases, rules, &models.Bot{Name: "flottbot"})
+
+	if len(results) != len(tests) {
+		t.Fatalf("Run() returned %d results, want %d", len(results), len(tests))
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := results[i].Failures
+
+			if len(got) != len(tt.failures) {
+				t.Fatalf("Run() failures = %q, want failures with %q", got, tt.failures)
+			}
+
+			for j, want := range tt.failures {
+				if !strings.Contains(got[j], want) {
+					t.Errorf("Run() failures = %q, want failures with %q", got, tt.failures)
+				}
+			}
+		})
+	}
+}
+
+func TestRun_Timeout(t *testing.T) {
+	rules := map[string]models.Rule{
+		"slow.yml": {
+			Name:    "slow",
+			Active:  true,
+			Respond: "slow",
+			Actions: []models.Action{{Name: "sleep", Type: "exec", Cmd: "sleep 1"}},
+		},
+	}
+
+	slow := Case{Name: "slow", Input: "slow", Exec: []ExecMock{{Cmd: "sleep", Delay: time.Second}}, Timeout: 50 * time.Millisecond}
+
+	results := Run([]Case{slow}, rules, &models.Bot{})
+
+	want := []string{"the rules did not finish within 50ms"}
+	if !slices.Equal(results[0].Failures, want) {
+		t.Errorf("Run() failures = %q, want %q", results[0].Failures, want)
+	}
+}
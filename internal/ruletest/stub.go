@@ -0,0 +1,198 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package ruletest
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+// header that carries the url a request was made to, once it is sent to the stub server.
+const stubURLHeader = "X-Flottbot-Test-Url"
+
+// httpStubs answers the requests of 'http' actions with the canned responses of a test.
+type httpStubs struct {
+	mu         sync.Mutex
+	stubs      []HTTPStub
+	used       []bool
+	unexpected []string // requests without a canned response
+}
+
+// reset replaces the canned responses with the ones of the next test.
+func (s *httpStubs) reset(stubs []HTTPStub) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stubs = stubs
+	s.used = make([]bool, len(stubs))
+	s.unexpected = nil
+}
+
+// unanswered returns the requests that didn't have a canned response.
+func (s *httpStubs) unanswered() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.unexpected)
+}
+
+func (s *httpStubs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	target, err := url.Parse(r.Header.Get(stubURLHeader))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stub, ok := s.match(r.Method, target)
+	if !ok {
+		http.Error(w, "no canned response", http.StatusNotFound)
+		return
+	}
+
+	for k, v := range stub.Headers {
+		w.Header().Set(k, v)
+	}
+
+	status := stub.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(stub.Body))
+}
+
+// match returns the first unused stub for the request, or the last one that was used.
+func (s *httpStubs) match(method string, target *url.URL) (HTTPStub, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	last := -1
+
+	for i, stub := range s.stubs {
+		if !stubMatches(stub, method, target) {
+			continue
+		}
+
+		if !s.used[i] {
+			s.used[i] = true
+
+			return stub, true
+		}
+
+		last = i
+	}
+
+	if last < 0 {
+		s.unexpected = append(s.unexpected, method+" "+target.String())
+
+		return HTTPStub{}, false
+	}
+
+	return s.stubs[last], true
+}
+
+// stubMatches tells whether the stub answers the request.
+func stubMatches(stub HTTPStub, method string, target *url.URL) bool {
+	if stub.Method != "" && !strings.EqualFold(stub.Method, method) {
+		return false
+	}
+
+	want, err := url.Parse(stub.URL)
+	if err != nil || want.Host != target.Host || strings.TrimSuffix(want.Path, "/") != strings.TrimSuffix(target.Path, "/") {
+		return false
+	}
+
+	if want.RawQuery == "" {
+		return true
+	}
+
+	return maps.EqualFunc(want.Query(), target.Query(), slices.Equal)
+}
+
+// stubTransport sends all requests to the stub server, along with the url they were made to.
+type stubTransport struct {
+	server *url.URL
+}
+
+func (t stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	stubbed := req.Clone(req.Context())
+	stubbed.Header.Set(stubURLHeader, req.URL.String())
+	stubbed.URL.Scheme = t.server.Scheme
+	stubbed.URL.Host = t.server.Host
+	stubbed.Host = t.server.Host
+
+	return http.DefaultTransport.RoundTrip(stubbed)
+}
+
+// execMocks replaces the commands of 'exec' actions with the mocks of a test.
+type execMocks struct {
+	mu         sync.Mutex
+	mocks      []ExecMock
+	unexpected []string // commands without a mock
+}
+
+// reset replaces the mocks with the ones of the next test.
+func (m *execMocks) reset(mocks []ExecMock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.mocks = mocks
+	m.unexpected = nil
+}
+
+// unmocked returns the commands that didn't have a mock.
+func (m *execMocks) unmocked() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Clone(m.unexpected)
+}
+
+// run returns the output of the mock for the command, commands without a mock are never run.
+func (m *execMocks) run(ctx context.Context, _ models.Action, bin []string) (*models.ScriptResponse, error) {
+	cmd := strings.Join(bin, " ")
+
+	mock, ok := m.match(cmd)
+	if !ok {
+		return &models.ScriptResponse{Status: 1}, fmt.Errorf("no mock for command %#q", cmd)
+	}
+
+	select {
+	case <-time.After(mock.Delay):
+	case <-ctx.Done():
+		return &models.ScriptResponse{Status: 1}, ctx.Err()
+	}
+
+	result := &models.ScriptResponse{Status: mock.Status, Output: mock.Output}
+	if mock.Status != 0 {
+		return result, fmt.Errorf("exit status %d", mock.Status)
+	}
+
+	return result, nil
+}
+
+// match returns the first mock for the command.
+func (m *execMocks) match(cmd string) (ExecMock, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, mock := range m.mocks {
+		if strings.HasPrefix(cmd, mock.Cmd) {
+			return mock, true
+		}
+	}
+
+	m.unexpected = append(m.unexpected, cmd)
+
+	return ExecMock{}, false
+}
@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// File is a Store that keeps values in a JSON file, so they survive restarts of the bot.
+// The file is rewritten on every change, which is fine for the small amounts of data rules keep.
+// Values are read once and kept by the process, so the file must not be shared by several
+// instances of the bot, they would drop each other's updates, use the Redis store instead.
+type File struct {
+	mu     sync.Mutex
+	path   string
+	values map[string]string
+}
+
+// validate that File adheres to the Store interface.
+var _ Store = (*File)(nil)
+
+// NewFile creates a file based store, loading existing values from path.
+func NewFile(path string) (*File, error) {
+	if path == "" {
+		return nil, errors.New("'store_path' must be set to use the file store")
+	}
+
+	f := &File{path: path, values: make(map[string]string)}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to read store file: %w", err)
+	}
+
+	if len(b) > 0 {
+		err = json.Unmarshal(b, &f.values)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse store file: %w", err)
+		}
+	}
+
+	return f, nil
+}
+
+// Get implementation to satisfy the Store interface.
+func (f *File) Get(key string) (string, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	value, ok := f.values[key]
+
+	return value, ok, nil
+}
+
+// Set implementation to satisfy the Store interface.
+func (f *File) Set(key, value string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	values := maps.Clone(f.values)
+	values[key] = value
+
+	return f.save(values)
+}
+
+// Incr implementation to satisfy the Store interface.
+func (f *File) Incr(key string, delta int64) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	values := maps.Clone(f.values)
+
+	n, err := incr(values, key, delta)
+	if err != nil {
+		return 0, err
+	}
+
+	return n, f.save(values)
+}
+
+// Delete implementation to satisfy the Store interface.
+func (f *File) Delete(key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.values[key]; !ok {
+		return nil
+	}
+
+	values := maps.Clone(f.values)
+	delete(values, key)
+
+	return f.save(values)
+}
+
+// save atomically writes values to the file and keeps them once they are written.
+func (f *File) save(values map[string]string) error {
+	b, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("unable to encode store values: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), ".store-*")
+	if err != nil {
+		return fmt.Errorf("unable to write store file: %w", err)
+	}
+
+	_, err = tmp.Write(b)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), f.path)
+	}
+
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("unable to write store file: %w", err)
+	}
+
+	f.values = values
+
+	return nil
+}
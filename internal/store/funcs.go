@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package store
+
+import (
+	"github.com/target/flottbot/internal/models"
+)
+
+// FuncMap returns template functions to access the default store,
+// keys are scoped using the given message (see 'ScopedKey').
+//
+//	{{ storeGet "bot" "key" }}
+//	{{ storeSet "channel" "key" "value" }}
+//	{{ storeIncr "user" "key" 1 }}
+//	{{ storeDelete "bot" "key" }}
+func FuncMap(msg *models.Message) map[string]any {
+	return map[string]any{
+		"storeGet": func(scope, key string) (string, error) {
+			k, err := ScopedKey(scope, key, msg)
+			if err != nil {
+				return "", err
+			}
+
+			value, _, err := Default().Get(k)
+
+			return value, err
+		},
+		"storeSet": func(scope, key, value string) (string, error) {
+			k, err := ScopedKey(scope, key, msg)
+			if err != nil {
+				return "", err
+			}
+
+			return "", Default().Set(k, value)
+		},
+		"storeIncr": func(scope, key string, delta int) (int64, error) {
+			k, err := ScopedKey(scope, key, msg)
+			if err != nil {
+				return 0, err
+			}
+
+			return Default().Incr(k, int64(delta))
+		},
+		"storeDelete": func(scope, key string) (string, error) {
+			k, err := ScopedKey(scope, key, msg)
+			if err != nil {
+				return "", err
+			}
+
+			return "", Default().Delete(k)
+		},
+	}
+}
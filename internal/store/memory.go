@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package store
+
+import (
+	"strconv"
+	"sync"
+)
+
+// Memory is a Store that keeps values in memory, values are lost when the bot restarts.
+type Memory struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+// validate that Memory adheres to the Store interface.
+var _ Store = (*Memory)(nil)
+
+// NewMemory creates an in-memory store.
+func NewMemory() *Memory {
+	return &Memory{values: make(map[string]string)}
+}
+
+// Get implementation to satisfy the Store interface.
+func (m *Memory) Get(key string) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	value, ok := m.values[key]
+
+	return value, ok, nil
+}
+
+// Set implementation to satisfy the Store interface.
+func (m *Memory) Set(key, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.values[key] = value
+
+	return nil
+}
+
+// Incr implementation to satisfy the Store interface.
+func (m *Memory) Incr(key string, delta int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n, err := incr(m.values, key, delta)
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// Delete implementation to satisfy the Store interface.
+func (m *Memory) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.values, key)
+
+	return nil
+}
+
+// incr adds delta to the integer value for key in values.
+func incr(values map[string]string, key string, delta int64) (int64, error) {
+	var n int64
+
+	if value, ok := values[key]; ok {
+		var err error
+
+		n, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0, ErrNotInteger
+		}
+	}
+
+	n += delta
+	values[key] = strconv.FormatInt(n, 10)
+
+	return n, nil
+}
@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package store provides a persistent key/value store for rules,
+// so that rules can remember values between invocations.
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+// ErrNotInteger is returned when incrementing a value that is not an integer.
+var ErrNotInteger = errors.New("value is not an integer")
+
+// Store is a key/value store backend.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Get returns the value for key and whether it was found.
+	Get(key string) (string, bool, error)
+
+	// Set stores value for key.
+	Set(key, value string) error
+
+	// Incr adds delta to the integer value for key, a missing key counts as 0.
+	Incr(key string, delta int64) (int64, error)
+
+	// Delete removes key.
+	Delete(key string) error
+}
+
+// Factory creates a Store from the bot configuration.
+type Factory func(bot *models.Bot) (Store, error)
+
+var backends = struct {
+	sync.RWMutex
+	factories map[string]Factory
+}{factories: make(map[string]Factory)}
+
+func init() {
+	Register("memory", func(_ *models.Bot) (Store, error) {
+		return NewMemory(), nil
+	})
+	Register("file", func(bot *models.Bot) (Store, error) {
+		return NewFile(bot.StorePath)
+	})
+	Register("redis", func(bot *models.Bot) (Store, error) {
+		return NewRedis(bot.RedisURL, bot.Name)
+	})
+}
+
+// Register makes a store backend available under the given name,
+// which can then be used for 'store' in the bot.yml.
+// This allows adding backends such as BoltDB or SQLite.
+func Register(name string, factory Factory) {
+	backends.Lock()
+	defer backends.Unlock()
+
+	backends.factories[strings.ToLower(name)] = factory
+}
+
+// New creates the store backend registered under the given name.
+func New(name string, bot *models.Bot) (Store, error) {
+	backends.RLock()
+	factory, ok := backends.factories[strings.ToLower(name)]
+	backends.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("store %#q is not supported", name)
+	}
+
+	return factory(bot)
+}
+
+var defaultStore = struct {
+	sync.RWMutex
+	store Store
+}{store: NewMemory()}
+
+// Default returns the store used by rules, an in-memory store unless configured otherwise.
+func Default() Store {
+	defaultStore.RLock()
+	defer defaultStore.RUnlock()
+
+	return defaultStore.store
+}
+
+// SetDefault sets the store used by rules.
+func SetDefault(s Store) {
+	defaultStore.Lock()
+	defer defaultStore.Unlock()
+
+	defaultStore.store = s
+}
+
+// Supported scopes for keys.
+const (
+	ScopeBot     = "bot"
+	ScopeChannel = "channel"
+	ScopeUser    = "user"
+)
+
+// keyEscaper escapes the separator of the parts of scoped keys.
+var keyEscaper = strings.NewReplacer(`\`, `\\`, ":", `\:`)
+
+// joinKey joins the parts with ':', escaping them so that different parts never yield the same key.
+func joinKey(parts ...string) string {
+	for i, part := range parts {
+		parts[i] = keyEscaper.Replace(part)
+	}
+
+	return strings.Join(parts, ":")
+}
+
+// ScopedKey prefixes key with the given scope, so that keys can be kept per bot,
+// per channel or per user of the message. An empty scope defaults to the bot scope.
+func ScopedKey(scope, key string, msg *models.Message) (string, error) {
+	if key == "" {
+		return "", errors.New("no key was supplied")
+	}
+
+	switch strings.ToLower(scope) {
+	case "", ScopeBot:
+		return joinKey(ScopeBot, key), nil
+	case ScopeChannel:
+		if msg == nil || msg.ChannelID == "" {
+			return "", errors.New("the message has no channel for the 'channel' scope")
+		}
+
+		return joinKey(ScopeChannel, msg.Remote, msg.ChannelID, key), nil
+	case ScopeUser:
+		// names can change and be taken by others, only the id identifies the user
+		var user string
+		if msg != nil {
+			user = msg.Vars["_user.id"]
+		}
+
+		if user == "" {
+			return "", errors.New("the message has no user id for the 'user' scope")
+		}
+
+		return joinKey(ScopeUser, msg.Remote, user, key), nil
+	default:
+		return "", fmt.Errorf("scope %#q is not supported", scope)
+	}
+}
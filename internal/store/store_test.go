@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/target/flottbot/internal/models"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+
+	fileStore, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("NewFile() error = %v", err)
+	}
+
+	redisURL := "redis://" + miniredis.RunT(t).Addr()
+
+	redisStore, err := NewRedis(redisURL, "flottbot")
+	if err != nil {
+		t.Fatalf("NewRedis() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		store Store
+	}{
+		{"Memory", NewMemory()},
+		{"File", fileStore},
+		{"Redis", redisStore},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok, _ := tt.store.Get("missing"); ok {
+				t.Errorf("Get() expected missing key not to be found")
+			}
+
+			err := tt.store.Set("name", "flottbot")
+			if err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+
+			if got, ok, _ := tt.store.Get("name"); !ok || got != "flottbot" {
+				t.Errorf("Get() = %v, want %v", got, "flottbot")
+			}
+
+			for _, step := range []struct{ delta, want int64 }{{1, 1}, {2, 3}} {
+				got, err := tt.store.Incr("count", step.delta)
+				if err != nil || got != step.want {
+					t.Errorf("Incr() = %v, %v, want %v", got, err, step.want)
+				}
+			}
+
+			if _, err := tt.store.Incr("name", 1); !errors.Is(err, ErrNotInteger) {
+				t.Errorf("Incr() error = %v, want %v", err, ErrNotInteger)
+			}
+
+			err = tt.store.Delete("name")
+			if err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+
+			if _, ok, _ := tt.store.Get("name"); ok {
+				t.Errorf("Get() expected deleted key not to be found")
+			}
+		})
+	}
+
+	// bots sharing the redis database keep their values apart
+	otherBot, err := NewRedis(redisURL, "otherbot")
+	if err != nil {
+		t.Fatalf("NewRedis() error = %v", err)
+	}
+
+	if got, ok, _ := otherBot.Get("count"); ok {
+		t.Errorf("Get() of another bot = %v, want the key not to be found", got)
+	}
+
+	// values of the file store survive a restart
+	reopened, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("NewFile() error = %v", err)
+	}
+
+	if got, _, _ := reopened.Get("count"); got != "3" {
+		t.Errorf("Get() after reopening = %v, want %v", got, "3")
+	}
+}
+
+func TestScopedKey(t *testing.T) {
+	msg := models.NewMessage()
+	msg.Remote = "slack"
+	msg.ChannelID = "C123"
+	msg.Vars["_user.id"] = "U123"
+
+	tests := []struct {
+		name    string
+		scope   string
+		key     string
+		msg     *models.Message
+		want    string
+		wantErr bool
+	}{
+		{"Default scope", "", "karma", &msg, "bot:karma", false},
+		{"Bot scope", "bot", "karma", nil, "bot:karma", false},
+		{"Channel scope", "Channel", "karma", &msg, "channel:slack:C123:karma", false},
+		{"User scope", "user", "karma", &msg, "user:slack:U123:karma", false},
+		{"Escaped key", "bot", `a:b\c`, nil, `bot:a\:b\\c`, false},
+		{"Escaped parts", "user", "karma", &models.Message{Remote: "slack:U1", Vars: map[string]string{"_user.id": "U2"}}, `user:slack\:U1:U2:karma`, false},
+		{"User scope without user", "user", "karma", &models.Message{}, "", true},
+		{"User scope without user id", "user", "karma", &models.Message{Vars: map[string]string{"_user.name": "someone"}}, "", true},
+		{"Channel scope without message", "channel", "karma", nil, "", true},
+		{"Missing key", "bot", "", &msg, "", true},
+		{"Unsupported scope", "team", "karma", &msg, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ScopedKey(tt.scope, tt.key, tt.msg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ScopedKey() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if got != tt.want {
+				t.Errorf("ScopedKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
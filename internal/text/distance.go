@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package text
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Distance returns the edit distance between a and b, ignoring case.
+// Insertions, deletions, substitutions and transpositions of adjacent characters count as one edit each.
+func Distance(a, b string) int {
+	s, t := []rune(strings.ToLower(a)), []rune(strings.ToLower(b))
+
+	// rows i-2, i-1 and i of the distance matrix
+	prev2 := make([]int, len(t)+1)
+	prev := make([]int, len(t)+1)
+	cur := make([]int, len(t)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		cur[0] = i
+
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+
+		prev2, prev, cur = prev, cur, prev2
+	}
+
+	return prev[len(t)]
+}
+
+// LiteralPrefix returns the literal text a 'respond' or 'hear' pattern starts with,
+// ie. 'deploy' for both 'deploy' and '/^deploy (\w+)/'.
+func LiteralPrefix(pattern string) string {
+	if strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") && len(pattern) > 1 {
+		pattern = pattern[1 : len(pattern)-1]
+	}
+
+	pattern = strings.TrimPrefix(pattern, "(?i)")
+	pattern = strings.TrimPrefix(pattern, "^")
+
+	prefix, _ := splitLiteral(pattern)
+
+	return strings.TrimSpace(prefix)
+}
+
+// splitLiteral splits a pattern into the literal text it starts with and the rest, from the first special
+// character on. A character that is optional or repeated, ie. the 's' of 'deploys?', is left out of the literal text.
+func splitLiteral(pattern string) (string, string) {
+	end := strings.IndexAny(pattern, `\.+*?()|[]{}^$`)
+	if end < 0 {
+		return pattern, ""
+	}
+
+	prefix, rest := pattern[:end], pattern[end:]
+
+	// a quantifier makes the character before it optional or repeated, it isn't part of the literal text
+	if strings.ContainsRune("+*?{", rune(rest[0])) {
+		_, size := utf8.DecodeLastRuneInString(prefix)
+		prefix = prefix[:len(prefix)-size]
+	}
+
+	return prefix, rest
+}
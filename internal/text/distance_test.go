@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package text
+
+import "testing"
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"deploy", "deploy", 0},
+		{"Deploy", "deploy", 0},
+		{"deplyo", "deploy", 1},
+		{"deply", "deploy", 1},
+		{"deployy", "deploy", 1},
+		{"dxploy", "deploy", 1},
+		{"restart", "deploy", 6},
+		{"", "deploy", 6},
+		{"grüße", "grüsse", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			if got := Distance(tt.a, tt.b); got != tt.want {
+				t.Errorf("Distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+
+			if got := Distance(tt.b, tt.a); got != tt.want {
+				t.Errorf("Distance(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLiteralPrefix(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"deploy", "deploy"},
+		{"show status", "show status"},
+		{`/^deploy (\w+)/`, "deploy"},
+		{`/(?i)^restart\s+(.*)/`, "restart"},
+		{`/^colou?r/`, "colo"},
+		{`/(hi|hello)/`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if got := LiteralPrefix(tt.pattern); got != tt.want {
+				t.Errorf("LiteralPrefix(%q) = %q, want %q", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,321 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package text
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Evaluate evaluates a condition, such as '${_raw_http_status} == 200 && ${env} != "prod"'.
+//
+// Operands are variables (${name}), quoted strings or plain words; variables are looked up
+// in the given vars, then in the environment, and are empty if they don't exist.
+// Supported operators are '==', '!=', '<', '<=', '>', '>=', '=~' and '!~' (regex match),
+// '&&', '||', '!' and parentheses. Operands that both look like numbers are compared
+// as numbers, otherwise as strings. An operand on its own is true unless it is empty,
+// "0" or "false".
+func Evaluate(expr string, vars map[string]string) (bool, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return false, err
+	}
+
+	if len(tokens) == 0 {
+		return false, errors.New("empty expression")
+	}
+
+	p := &exprParser{tokens: tokens, vars: vars}
+
+	value, err := p.or()
+	if err != nil {
+		return false, err
+	}
+
+	if p.pos < len(p.tokens) {
+		return false, fmt.Errorf("unexpected %#q", p.tokens[p.pos].text)
+	}
+
+	return truthy(value), nil
+}
+
+type tokenKind int
+
+const (
+	tokenOperand tokenKind = iota
+	tokenVar
+	tokenOperator
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// operators, longest first so that '==' isn't read as '=' '='.
+var operators = []string{"==", "!=", "<=", ">=", "=~", "!~", "&&", "||", "<", ">", "!", "(", ")"}
+
+// tokenize splits the expression into operands and operators.
+func tokenize(expr string) ([]token, error) {
+	tokens := []token{}
+
+	for i := 0; i < len(expr); {
+		c := expr[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case strings.HasPrefix(expr[i:], "${"):
+			end := strings.IndexByte(expr[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated variable at %d", i)
+			}
+
+			tokens = append(tokens, token{tokenVar, expr[i+2 : i+end]})
+			i += end + 1
+		case c == '"' || c == '\'':
+			var sb strings.Builder
+
+			j := i + 1
+			for ; j < len(expr) && expr[j] != c; j++ {
+				if expr[j] == '\\' && j+1 < len(expr) {
+					j++
+				}
+
+				sb.WriteByte(expr[j])
+			}
+
+			if j >= len(expr) {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+
+			tokens = append(tokens, token{tokenOperand, sb.String()})
+			i = j + 1
+		default:
+			if op := operatorAt(expr, i); op != "" {
+				tokens = append(tokens, token{tokenOperator, op})
+				i += len(op)
+
+				continue
+			}
+
+			j := i
+			for j < len(expr) && !strings.ContainsRune(" \t\n\"'", rune(expr[j])) && operatorAt(expr, j) == "" && !strings.HasPrefix(expr[j:], "${") {
+				j++
+			}
+
+			tokens = append(tokens, token{tokenOperand, expr[i:j]})
+			i = j
+		}
+	}
+
+	return tokens, nil
+}
+
+// operatorAt returns the operator at the given position of the expression, if any.
+func operatorAt(expr string, i int) string {
+	for _, op := range operators {
+		if strings.HasPrefix(expr[i:], op) {
+			return op
+		}
+	}
+
+	return ""
+}
+
+// exprParser is a recursive descent parser over the tokens of an expression,
+// all values are strings, the result of a comparison is "true" or "false".
+type exprParser struct {
+	tokens []token
+	pos    int
+	vars   map[string]string
+}
+
+func (p *exprParser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+
+	return p.tokens[p.pos], true
+}
+
+func (p *exprParser) accept(op string) bool {
+	if t, ok := p.peek(); ok && t.kind == tokenOperator && t.text == op {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+// or := and ('||' and)*.
+func (p *exprParser) or() (string, error) {
+	left, err := p.and()
+	if err != nil {
+		return "", err
+	}
+
+	for p.accept("||") {
+		right, err := p.and()
+		if err != nil {
+			return "", err
+		}
+
+		left = strconv.FormatBool(truthy(left) || truthy(right))
+	}
+
+	return left, nil
+}
+
+// and := unary ('&&' unary)*.
+func (p *exprParser) and() (string, error) {
+	left, err := p.unary()
+	if err != nil {
+		return "", err
+	}
+
+	for p.accept("&&") {
+		right, err := p.unary()
+		if err != nil {
+			return "", err
+		}
+
+		left = strconv.FormatBool(truthy(left) && truthy(right))
+	}
+
+	return left, nil
+}
+
+// unary := '!' unary | comparison.
+func (p *exprParser) unary() (string, error) {
+	if p.accept("!") {
+		value, err := p.unary()
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.FormatBool(!truthy(value)), nil
+	}
+
+	return p.comparison()
+}
+
+// comparison := primary (op primary)?.
+func (p *exprParser) comparison() (string, error) {
+	left, err := p.primary()
+	if err != nil {
+		return "", err
+	}
+
+	t, ok := p.peek()
+	if !ok || t.kind != tokenOperator {
+		return left, nil
+	}
+
+	switch t.text {
+	case "==", "!=", "<", "<=", ">", ">=", "=~", "!~":
+		p.pos++
+	default:
+		return left, nil
+	}
+
+	right, err := p.primary()
+	if err != nil {
+		return "", err
+	}
+
+	result, err := compare(left, t.text, right)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.FormatBool(result), nil
+}
+
+// primary := '(' or ')' | operand | variable.
+func (p *exprParser) primary() (string, error) {
+	t, ok := p.peek()
+	if !ok {
+		return "", errors.New("unexpected end of expression")
+	}
+
+	p.pos++
+
+	switch t.kind {
+	case tokenVar:
+		if value, ok := p.vars[t.text]; ok {
+			return value, nil
+		}
+
+		return os.Getenv(t.text), nil
+	case tokenOperand:
+		return t.text, nil
+	case tokenOperator:
+		if t.text == "(" {
+			value, err := p.or()
+			if err != nil {
+				return "", err
+			}
+
+			if !p.accept(")") {
+				return "", errors.New("missing closing parenthesis")
+			}
+
+			return value, nil
+		}
+	}
+
+	return "", fmt.Errorf("unexpected %#q", t.text)
+}
+
+// compare compares two operands, numerically if both are numbers.
+func compare(left, op, right string) (bool, error) {
+	if op == "=~" || op == "!~" {
+		re, err := regexp.Compile(right)
+		if err != nil {
+			return false, fmt.Errorf("invalid regex %#q: %w", right, err)
+		}
+
+		return re.MatchString(left) == (op == "=~"), nil
+	}
+
+	c := strings.Compare(left, right)
+
+	l, lErr := strconv.ParseFloat(left, 64)
+	r, rErr := strconv.ParseFloat(right, 64)
+
+	if lErr == nil && rErr == nil {
+		switch {
+		case l < r:
+			c = -1
+		case l > r:
+			c = 1
+		default:
+			c = 0
+		}
+	}
+
+	switch op {
+	case "==":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default: // ">="
+		return c >= 0, nil
+	}
+}
+
+// truthy tells whether a value counts as true.
+func truthy(value string) bool {
+	return value != "" && value != "0" && !strings.EqualFold(value, "false")
+}
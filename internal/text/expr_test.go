@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package text
+
+import (
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	t.Setenv("FLOTTBOT_TEST_ENV", "qa")
+
+	vars := map[string]string{
+		"_raw_http_status": "200",
+		"_exec_output":     "all good && more",
+		"count":            "9",
+		"name":             "flottbot",
+		"empty":            "",
+	}
+
+	tests := []struct {
+		name    string
+		expr    string
+		want    bool
+		wantErr bool
+	}{
+		{"Equal number", `${_raw_http_status} == 200`, true, false},
+		{"Not equal number", `${_raw_http_status} != 200`, false, false},
+		{"Numeric comparison", `${count} < 10`, true, false},
+		{"Numbers are not compared as strings", `${count} > 10`, false, false},
+		{"Status range", `${_raw_http_status} >= 200 && ${_raw_http_status} < 300`, true, false},
+		{"Quoted string", `${name} == "flottbot"`, true, false},
+		{"Single quoted string", `${name} != 'other bot'`, true, false},
+		{"Operators in values are not evaluated", `${_exec_output} == "all good && more"`, true, false},
+		{"Regex match", `${name} =~ "^flott"`, true, false},
+		{"Regex no match", `${name} !~ "bot$"`, false, false},
+		{"Or", `${name} == other || ${count} == 9`, true, false},
+		{"Not", `!(${name} == flottbot)`, false, false},
+		{"Parentheses", `(${count} == 1 || ${count} == 9) && ${name} == flottbot`, true, false},
+		{"Truthy var", `${name}`, true, false},
+		{"Empty var", `${empty}`, false, false},
+		{"Undefined var is empty", `${nope} == ""`, true, false},
+		{"Environment var", `${FLOTTBOT_TEST_ENV} == qa`, true, false},
+		{"False literal", `false`, false, false},
+		{"Empty expression", ``, false, true},
+		{"Unterminated string", `${name} == "flott`, false, true},
+		{"Unterminated var", `${name == flott`, false, true},
+		{"Missing operand", `${name} ==`, false, true},
+		{"Missing parenthesis", `(${name} == flottbot`, false, true},
+		{"Trailing tokens", `${name} == flottbot flottbot`, false, true},
+		{"Invalid regex", `${name} =~ "("`, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Evaluate(tt.expr, vars)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Evaluate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -13,37 +13,16 @@ import (
 )
 
 // Match checks given value against given pattern.
+// The pattern is compiled on every call, use 'Compile' to match a pattern repeatedly.
 func Match(pattern, value string, trimValue bool) (string, bool) {
-	// set the default regex pattern; assumes given pattern is not regex already
-	regxPattern := fmt.Sprintf(`(?i)^(%s$|%s[^\S])`, pattern, pattern)
-	// check if we're dealing with regex
-	isRegex := strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/")
-
-	// check if the given pattern was a regex expression
-	if isRegex {
-		regxPattern = fmt.Sprintf(`(?i)%s`, pattern[1:len(pattern)-1])
-	}
-
-	// try to compile the regex,
-	regx, err := regexp.Compile(regxPattern)
+	p, err := Compile(pattern)
 	if err != nil {
 		log.Error().Msgf("unsupported regex: %s", pattern)
 
 		return "", false
 	}
 
-	// check whether given value matches regex
-	matchFound := regx.MatchString(value)
-	if !matchFound {
-		return "", false
-	}
-
-	// remove the regex match from the given value and trim the space
-	if trimValue {
-		value = strings.Trim(strings.Replace(value, regx.FindString(value), "", 1), " ")
-	}
-
-	return value, matchFound
+	return p.Match(value, trimValue)
 }
 
 // Substitute checks given value for variables and looks them up
@@ -87,6 +66,18 @@ func Substitute(value string, tokens map[string]string) (string, error) {
 	return value, nil
 }
 
+// Vars returns the names of the variables used in the value, ie. 'app' for '${app}'.
+func Vars(value string) []string {
+	_, hits := findVars(value)
+
+	names := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		names = append(names, strip(hit))
+	}
+
+	return names
+}
+
 // RuleArgTokenizer goes through a string and tokenizes as parameters for use when identifying rules to be triggered (ignoring empty arguments).
 func RuleArgTokenizer(stripped string) []string {
 	re := regexp.MustCompile(`["“]([^"“”]+)["”]|([^"“”\s]+)`)
@@ -4,6 +4,7 @@ package text
 
 import (
 	"reflect"
+	"slices"
 	"testing"
 )
 
@@ -81,6 +82,25 @@ func TestSubstitute(t *testing.T) {
 	}
 }
 
+func TestVars(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{"None", "deploying", []string{}},
+		{"Vars", "deploying ${app} to ${_user.name}", []string{"app", "_user.name"}},
+		{"Escaped", "costs $${price}", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Vars(tt.value); !slices.Equal(got, tt.want) {
+				t.Errorf("Vars() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestRuleArgTokenizer(t *testing.T) {
 	type args struct {
 		stripped string
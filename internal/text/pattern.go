@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package text
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Pattern is a compiled rule pattern, ie. of 'respond' or 'hear'. Patterns wrapped in slashes
+// are regular expressions, any other pattern matches the start of the value up to a space.
+// Matching is case-insensitive.
+type Pattern struct {
+	raw     string
+	isRegex bool
+	regx    *regexp.Regexp
+}
+
+// Compile compiles the pattern, so it can be matched any number of times.
+func Compile(pattern string) (*Pattern, error) {
+	// set the default regex pattern; assumes given pattern is not regex already
+	regxPattern := fmt.Sprintf(`(?i)^(%s$|%s[^\S])`, pattern, pattern)
+	// check if we're dealing with regex
+	isRegex := strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") && len(pattern) > 1
+
+	// check if the given pattern was a regex expression
+	if isRegex {
+		regxPattern = fmt.Sprintf(`(?i)%s`, pattern[1:len(pattern)-1])
+	}
+
+	regx, err := regexp.Compile(regxPattern)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported regex %#q: %w", pattern, err)
+	}
+
+	return &Pattern{raw: pattern, isRegex: isRegex, regx: regx}, nil
+}
+
+// String returns the pattern as it was declared.
+func (p *Pattern) String() string {
+	return p.raw
+}
+
+// Match checks the value against the pattern. With trimValue, the match is removed
+// from the returned value, leaving ie. the arguments of a command.
+func (p *Pattern) Match(value string, trimValue bool) (string, bool) {
+	loc := p.regx.FindStringIndex(value)
+	if loc == nil {
+		return "", false
+	}
+
+	// remove the regex match from the given value and trim the space
+	if trimValue {
+		value = strings.Trim(strings.Replace(value, value[loc[0]:loc[1]], "", 1), " ")
+	}
+
+	return value, true
+}
+
+// Captures returns the values of the named capture groups of the pattern in the value.
+func (p *Pattern) Captures(value string) map[string]string {
+	captures := make(map[string]string)
+
+	values := p.regx.FindStringSubmatch(value)
+	if values == nil {
+		return captures
+	}
+
+	for index, name := range p.regx.SubexpNames() {
+		if index == 0 || name == "" {
+			continue
+		}
+
+		captures[name] = values[index]
+	}
+
+	return captures
+}
+
+// CaptureNames returns the names of the named capture groups of the pattern.
+func (p *Pattern) CaptureNames() []string {
+	var names []string
+
+	for _, name := range p.regx.SubexpNames() {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// Keyword returns the lowercase first word of any value the pattern matches, ie. 'deploy' for
+// 'deploy' or '/^deploy\s+(\w+)/'. It is empty if the pattern can match values starting with
+// different words, ie. for unanchored regular expressions or alternatives.
+func (p *Pattern) Keyword() string {
+	pattern := p.raw
+
+	if p.isRegex {
+		pattern = strings.TrimPrefix(pattern[1:len(pattern)-1], "(?i)")
+
+		if !strings.HasPrefix(pattern, "^") {
+			return ""
+		}
+
+		pattern = pattern[1:]
+	}
+
+	// alternatives may start with other words
+	if strings.Contains(pattern, "|") {
+		return ""
+	}
+
+	prefix, rest := splitLiteral(pattern)
+	if prefix == "" || unicode.IsSpace(rune(prefix[0])) {
+		return ""
+	}
+
+	if space := strings.IndexFunc(prefix, unicode.IsSpace); space > 0 {
+		return strings.ToLower(prefix[:space])
+	}
+
+	// the word ends with the literal text, if nothing but the end or a space may follow
+	if rest == "" && !p.isRegex || strings.HasPrefix(rest, `\s`) || strings.HasPrefix(rest, "$") {
+		return strings.ToLower(prefix)
+	}
+
+	return ""
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package text
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	if _, err := Compile(`/deploy (\w+/`); err == nil {
+		t.Errorf("Compile() expected error for invalid regex")
+	}
+
+	if _, err := Compile(`deploy`); err != nil {
+		t.Errorf("Compile() unexpected error = %v", err)
+	}
+}
+
+func TestPattern_Keyword(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{`deploy`, "deploy"},
+		{`Deploy App`, "deploy"},
+		{`deploy.*`, ""},
+		{`deploy|ship`, ""},
+		{`/^deploy\s+(\w+)/`, "deploy"},
+		{`/(?i)^deploy$/`, "deploy"},
+		{`/^deploy app/`, "deploy"},
+		{`/^deploy/`, ""},
+		{`/^deploys?\s/`, ""},
+		{`/deploy\s+(\w+)/`, ""},
+		{`/^(deploy|ship)/`, ""},
+		{`/.*/`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			p, err := Compile(tt.pattern)
+			if err != nil {
+				t.Fatalf("Compile() unexpected error = %v", err)
+			}
+
+			if got := p.Keyword(); got != tt.want {
+				t.Errorf("Keyword() = %#q, want %#q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPattern_Captures(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		value   string
+		want    map[string]string
+	}{
+		{"Named groups", `/deploy (?P<app>\w+) to (?P<env>\w+)/`, "deploy api to prod", map[string]string{"app": "api", "env": "prod"}},
+		{"Ignores case", `/deploy (?P<app>\w+)/`, "DEPLOY api", map[string]string{"app": "api"}},
+		{"No named groups", `/deploy (\w+)/`, "deploy api", map[string]string{}},
+		{"No match", `/deploy (?P<app>\w+)/`, "rollback api", map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Compile(tt.pattern)
+			if err != nil {
+				t.Fatalf("Compile() unexpected error = %v", err)
+			}
+
+			if got := p.Captures(tt.value); !maps.Equal(got, tt.want) {
+				t.Errorf("Captures() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkMatch(b *testing.B) {
+	b.Run("compile every time", func(b *testing.B) {
+		for b.Loop() {
+			Match(`/^deploy\s+(\w+)/`, "deploy flottbot to prod", true)
+		}
+	})
+
+	b.Run("precompiled", func(b *testing.B) {
+		p, err := Compile(`/^deploy\s+(\w+)/`)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		for b.Loop() {
+			p.Match("deploy flottbot to prod", true)
+		}
+	})
+}